@@ -13,18 +13,55 @@ import (
 // IMPORTANT: More specific routes MUST be registered before less specific ones
 // because http.ServeMux uses longest-prefix matching.
 func SetupRoutes(mux *http.ServeMux) {
-	// Categories routes (more specific, must come before /products/)
+	// Categories routes (more specific, must come before /products/),
+	// including /products/categories/{id}/attributes
+	mux.HandleFunc("/products/categories/tree", crud.CategoryTreeHandler)
+	mux.HandleFunc("/products/categories/by-slug/", crud.CategoryBySlugHandler)
 	mux.HandleFunc("/products/categories/", crud.CategoryItemHandler)
 	mux.HandleFunc("/products/categories", crud.CategoriesHandler)
 
 	// Manufacturers routes (more specific, must come before /products/)
+	mux.HandleFunc("/products/manufacturers/by-slug/", crud.ManufacturerBySlugHandler)
 	mux.HandleFunc("/products/manufacturers/", crud.ManufacturerItemHandler)
 	mux.HandleFunc("/products/manufacturers", crud.ManufacturersHandler)
 
-	// Products routes (less specific)
+	// Products routes (less specific), including /products/{id}/reviews,
+	// /products/{id}/variants, /products/{id}/attributes and
+	// /products/{id}/subscriptions
+	mux.HandleFunc("/products/by-slug/", crud.ProductBySlugHandler)
+	mux.HandleFunc("/products/compare", crud.ProductCompareHandler)
 	mux.HandleFunc("/products/", crud.ProductItemHandler)
 	mux.HandleFunc("/products", crud.ProductsHandler)
 
+	// Unsubscribe links of subscription emails
+	mux.HandleFunc("/subscriptions/unsubscribe", crud.UnsubscribeHandler)
+
+	// Admin routes
+	mux.HandleFunc("/admin/products/import", crud.ProductImportHandler)
+	mux.HandleFunc("/admin/products/export", crud.ProductExportHandler)
+	mux.HandleFunc("/admin/media", crud.MediaHandler)
+	mux.HandleFunc("/admin/reviews/", crud.ReviewAdminItemHandler)
+	mux.HandleFunc("/admin/reviews", crud.ReviewsAdminHandler)
+	mux.HandleFunc("/admin/emails/", crud.EmailAdminItemHandler)
+	mux.HandleFunc("/admin/emails", crud.EmailsAdminHandler)
+	mux.HandleFunc("/admin/warehouses/", crud.WarehouseItemHandler)
+	mux.HandleFunc("/admin/warehouses", crud.WarehousesHandler)
+	mux.HandleFunc("/admin/stock/movements", crud.StockMovementsHandler)
+	mux.HandleFunc("/admin/stock/reconciliation", crud.StockReconciliationHandler)
+	mux.HandleFunc("/admin/stock", crud.StockLevelsHandler)
+
+	// Uploaded media, when stored locally, and its resized variants
+	mux.HandleFunc("/media/", crud.MediaFilesHandler)
+	mux.HandleFunc("/images/", crud.ImageVariantHandler)
+
+	// Marketplace feeds
+	mux.HandleFunc("/feeds/yml.xml", crud.YMLFeedHandler)
+	mux.HandleFunc("/feeds/kaspi.xml", crud.KaspiFeedHandler)
+
+	// Sitemap
+	mux.HandleFunc("/sitemap.xml", crud.SitemapHandler)
+	mux.HandleFunc("/sitemaps/", crud.SitemapPageHandler)
+
 	// Cart routes
 	mux.HandleFunc("/cart/", crud.CartItemHandler)
 	mux.HandleFunc("/cart", crud.CartHandler)
@@ -0,0 +1,17 @@
+package v2
+
+import (
+	"net/http"
+
+	"noble-group-services/crud"
+)
+
+// SetupRoutes sets up the /v2 API routes.
+// v2 listings return a PageResponse envelope instead of a bare array;
+// everything else is still served by v1.
+func SetupRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/v2/products/categories", crud.CategoriesPageHandler)
+	mux.HandleFunc("/v2/products/manufacturers", crud.ManufacturersPageHandler)
+	mux.HandleFunc("/v2/products", crud.ProductsPageHandler)
+	mux.HandleFunc("/v2/orders", crud.OrdersPageHandler)
+}
@@ -0,0 +1,882 @@
+package crud
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"noble-group-services/models"
+)
+
+// attributeFilterPrefix starts the listing parameters that filter on
+// attribute values: attr.<code>=<value> for enums and booleans, repeated for
+// any of several values, and attr.<code>.min / attr.<code>.max for numbers.
+const attributeFilterPrefix = "attr."
+
+// maxCompareProducts bounds GET /products/compare.
+const maxCompareProducts = 10
+
+var attributeCodePattern = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// filterError reports a malformed product listing filter parameter.
+type filterError struct{ param string }
+
+func (e filterError) Error() string { return "invalid filter " + e.param }
+
+// writeListQueryError reports an invalid sort or filter of a product listing.
+func writeListQueryError(w http.ResponseWriter, err error) {
+	var fe filterError
+	if errors.As(err, &fe) {
+		http.Error(w, "Invalid filter: "+fe.param, http.StatusBadRequest)
+		return
+	}
+	http.Error(w, "Invalid sort", http.StatusBadRequest)
+}
+
+// attributeValueRow is a stored attribute value of a product.
+type attributeValueRow struct {
+	ProductID   string   `db:"product_id"`
+	AttributeID string   `db:"attribute_id"`
+	NumberValue *float64 `db:"number_value"`
+	TextValue   *string  `db:"text_value"`
+}
+
+// value returns the JSON value of the row for an attribute of type typ.
+func (r attributeValueRow) value(typ string) interface{} {
+	switch {
+	case typ == models.AttributeNumber && r.NumberValue != nil:
+		return *r.NumberValue
+	case typ == models.AttributeBoolean && r.TextValue != nil:
+		return *r.TextValue == "true"
+	case r.TextValue != nil:
+		return *r.TextValue
+	}
+	return nil
+}
+
+// attributeColumns converts a JSON value of attribute a to its stored
+// columns, or returns a validation message.
+func attributeColumns(a models.Attribute, v interface{}) (number *float64, text *string, msg string) {
+	switch a.Type {
+	case models.AttributeNumber:
+		n, ok := v.(float64)
+		if !ok {
+			return nil, nil, a.Name + " must be a number"
+		}
+		return &n, nil, ""
+	case models.AttributeBoolean:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, nil, a.Name + " must be true or false"
+		}
+		s := strconv.FormatBool(b)
+		return nil, &s, ""
+	default:
+		s, ok := v.(string)
+		if !ok || !slices.Contains(a.Values, s) {
+			return nil, nil, a.Name + " must be one of " + strings.Join(a.Values, ", ")
+		}
+		return nil, &s, ""
+	}
+}
+
+// categoryAttributes returns the attributes that apply to the products of a
+// category: its own and those of its ancestors, from the root down.
+func categoryAttributes(q sqlx.Queryer, categoryID string) ([]models.Attribute, error) {
+	path, err := categoryPath(q, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	depth := make(map[string]int, len(path))
+	ids := make([]string, len(path))
+	for i, c := range path {
+		depth[c.ID] = i
+		ids[i] = c.ID
+	}
+
+	attrs := []models.Attribute{}
+	err = sqlx.Select(q, &attrs, `SELECT * FROM category_attributes WHERE category_id = ANY($1) ORDER BY position, name`, ids)
+	sort.SliceStable(attrs, func(i, j int) bool {
+		return depth[attrs[i].CategoryID] < depth[attrs[j].CategoryID]
+	})
+	return attrs, err
+}
+
+// productAttributes returns the values a product has for the attributes of
+// its category, in attribute order.
+func productAttributes(q sqlx.Queryer, p models.Product) ([]models.AttributeValue, error) {
+	attrs, err := categoryAttributes(q, p.CategoryID)
+	if err != nil || len(attrs) == 0 {
+		return nil, err
+	}
+	var rows []attributeValueRow
+	if err := sqlx.Select(q, &rows, `SELECT * FROM product_attributes WHERE product_id = $1`, p.ID); err != nil {
+		return nil, err
+	}
+	byAttribute := make(map[string]attributeValueRow, len(rows))
+	for _, row := range rows {
+		byAttribute[row.AttributeID] = row
+	}
+
+	var values []models.AttributeValue
+	for _, a := range attrs {
+		row, ok := byAttribute[a.ID]
+		if !ok {
+			continue
+		}
+		values = append(values, models.AttributeValue{Code: a.Code, Name: a.Name, Type: a.Type, Unit: a.Unit, Value: row.value(a.Type)})
+	}
+	return values, nil
+}
+
+// attributeFilter restricts a product listing to the attr.* parameters of
+// query. Every filtered attribute must match.
+func attributeFilter(lq *listQuery, query url.Values) error {
+	type condition struct {
+		values   []string
+		min, max *float64
+	}
+	conds := map[string]*condition{}
+	for key, values := range query {
+		rest, ok := strings.CutPrefix(key, attributeFilterPrefix)
+		if !ok {
+			continue
+		}
+		code, bound := rest, ""
+		if c, ok := strings.CutSuffix(rest, ".min"); ok {
+			code, bound = c, "min"
+		} else if c, ok := strings.CutSuffix(rest, ".max"); ok {
+			code, bound = c, "max"
+		}
+		if !attributeCodePattern.MatchString(code) {
+			return filterError{key}
+		}
+		cond := conds[code]
+		if cond == nil {
+			cond = &condition{}
+			conds[code] = cond
+		}
+
+		if bound == "" {
+			for _, v := range values {
+				if v != "" {
+					cond.values = append(cond.values, v)
+				}
+			}
+			continue
+		}
+		if values[0] == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(values[0], 64)
+		if err != nil {
+			return filterError{key}
+		}
+		if bound == "min" {
+			cond.min = &n
+		} else {
+			cond.max = &n
+		}
+	}
+
+	codes := make([]string, 0, len(conds))
+	for code := range conds {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		cond := conds[code]
+		if len(cond.values) == 0 && cond.min == nil && cond.max == nil {
+			continue
+		}
+		where := ` AND a.code = ` + lq.Args.add(code)
+		if len(cond.values) > 0 {
+			where += ` AND pa.text_value = ANY(` + lq.Args.add(cond.values) + `)`
+		}
+		if cond.min != nil {
+			where += ` AND pa.number_value >= ` + lq.Args.add(*cond.min) + `::numeric`
+		}
+		if cond.max != nil {
+			where += ` AND pa.number_value <= ` + lq.Args.add(*cond.max) + `::numeric`
+		}
+		lq.Where += ` AND EXISTS (SELECT 1 FROM product_attributes pa JOIN category_attributes a ON a.id = pa.attribute_id
+			WHERE pa.product_id = p.id` + where + `)`
+	}
+	return nil
+}
+
+// CategoryAttributesHandler handles GET, POST
+// /products/categories/{id}/attributes and PUT, DELETE
+// /products/categories/{id}/attributes/{attributeId}
+func CategoryAttributesHandler(w http.ResponseWriter, r *http.Request) {
+	_, attributeID, _ := parseAttributePath(r.URL.Path, "/products/categories/")
+	switch {
+	case attributeID == "" && r.Method == http.MethodGet:
+		GetCategoryAttributes(w, r)
+	case attributeID == "" && r.Method == http.MethodPost:
+		CreateAttribute(w, r)
+	case attributeID != "" && r.Method == http.MethodPut:
+		UpdateAttribute(w, r)
+	case attributeID != "" && r.Method == http.MethodDelete:
+		DeleteAttribute(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// parseAttributePath splits prefix{id}/attributes and
+// prefix{id}/attributes/{attributeId}.
+func parseAttributePath(path, prefix string) (id, attributeID string, ok bool) {
+	rest, found := strings.CutPrefix(path, prefix)
+	if !found {
+		return "", "", false
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] != "attributes" {
+		return "", "", false
+	}
+	if len(parts) == 3 {
+		if parts[2] == "" {
+			return "", "", false
+		}
+		attributeID = parts[2]
+	}
+	return parts[0], attributeID, true
+}
+
+// GetCategoryAttributes godoc
+// @Summary List category attributes
+// @Description Get the attributes that apply to the products of a category: its own and those inherited from its parents, from the root down.
+// @Tags attributes
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {array} models.Attribute
+// @Failure 404 {string} string "Category not found"
+// @Router /products/categories/{id}/attributes [get]
+func GetCategoryAttributes(w http.ResponseWriter, r *http.Request) {
+	categoryID, _, _ := parseAttributePath(r.URL.Path, "/products/categories/")
+
+	body, _, err := catalogCache.load("attributes:"+categoryID, func() (interface{}, []string, error) {
+		var exists bool
+		if err := db.Get(&exists, `SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1)`, categoryID); err != nil {
+			return nil, nil, err
+		}
+		if !exists {
+			return nil, nil, sql.ErrNoRows
+		}
+		attrs, err := categoryAttributes(db, categoryID)
+		return attrs, []string{"categories:" + categoryID}, err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Category not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONBody(w, body)
+}
+
+// CreateAttribute godoc
+// @Summary Create a category attribute
+// @Description Define an attribute for the products of a category and its subcategories. Codes are unique along a category's ancestors and subcategories; values are only allowed for enums and unit only for numbers.
+// @Tags attributes
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param attribute body models.Attribute true "Attribute"
+// @Success 201 {object} models.Attribute
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 404 {string} string "Category not found"
+// @Failure 409 {string} string "Code already used"
+// @Router /products/categories/{id}/attributes [post]
+func CreateAttribute(w http.ResponseWriter, r *http.Request) {
+	categoryID, _, _ := parseAttributePath(r.URL.Path, "/products/categories/")
+
+	var a models.Attribute
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	a.ID = uuid.New().String()
+	a.CategoryID = categoryID
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = a.CreatedAt
+	normalizeAttribute(&a)
+	if details := validateAttribute(a); len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT 1 FROM categories WHERE id = $1 FOR UPDATE`, categoryID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	path, err := categoryPath(tx, categoryID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if len(path) == 0 {
+		http.Error(w, "Category not found", http.StatusNotFound)
+		return
+	}
+	ancestors := make([]string, len(path))
+	for i, c := range path {
+		ancestors[i] = c.ID
+	}
+
+	var used bool
+	err = tx.Get(&used, `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = $1
+			UNION
+			SELECT ch.id FROM categories ch JOIN subtree ON ch.parent_id = subtree.id
+		)
+		SELECT EXISTS (
+			SELECT 1 FROM category_attributes
+			WHERE code = $2 AND (category_id IN (SELECT id FROM subtree) OR category_id = ANY($3))
+		)
+	`, categoryID, a.Code, ancestors)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if used {
+		http.Error(w, "Code "+a.Code+" is already used by this category, a parent or a subcategory", http.StatusConflict)
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO category_attributes (id, category_id, code, name, type, unit, enum_values, position, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, a.ID, a.CategoryID, a.Code, a.Name, a.Type, a.Unit, a.Values, a.Position, a.CreatedAt, a.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			writeConflict(w, err)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := touchCategoryTree(tx, categoryID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	catalogCache.invalidate("categories", "")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a)
+}
+
+// UpdateAttribute godoc
+// @Summary Replace a category attribute
+// @Description Replace the name, unit, enum values and position of an attribute. Code and type cannot change, and enum values in use cannot be removed.
+// @Tags attributes
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param attributeId path string true "Attribute ID"
+// @Param attribute body models.Attribute true "Attribute"
+// @Success 200 {object} models.Attribute
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 404 {string} string "Attribute not found"
+// @Router /products/categories/{id}/attributes/{attributeId} [put]
+func UpdateAttribute(w http.ResponseWriter, r *http.Request) {
+	categoryID, attributeID, _ := parseAttributePath(r.URL.Path, "/products/categories/")
+
+	var a models.Attribute
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var current models.Attribute
+	err = tx.Get(&current, `SELECT * FROM category_attributes WHERE id = $1 AND category_id = $2 FOR UPDATE`, attributeID, categoryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Attribute not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	a.ID, a.CategoryID, a.CreatedAt = current.ID, current.CategoryID, current.CreatedAt
+	normalizeAttribute(&a)
+	details := validateAttribute(a)
+	if a.Code != current.Code {
+		details = append(details, ValidationErrorDetail{Field: "code", Message: "Code cannot change"})
+	}
+	if a.Type != current.Type {
+		details = append(details, ValidationErrorDetail{Field: "type", Message: "Type cannot change"})
+	}
+	if len(details) == 0 && a.Type == models.AttributeEnum {
+		var used []string
+		err := tx.Select(&used, `
+			SELECT DISTINCT text_value FROM product_attributes
+			WHERE attribute_id = $1 AND NOT text_value = ANY($2)
+			ORDER BY text_value
+		`, a.ID, []string(a.Values))
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if len(used) > 0 {
+			details = append(details, ValidationErrorDetail{Field: "values", Message: "Values in use cannot be removed: " + strings.Join(used, ", ")})
+		}
+	}
+	if len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+
+	err = tx.Get(&a, `
+		UPDATE category_attributes SET name=$1, unit=$2, enum_values=$3, position=$4, updated_at=NOW()
+		WHERE id=$5
+		RETURNING *
+	`, a.Name, a.Unit, a.Values, a.Position, a.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := touchCategoryTree(tx, categoryID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	catalogCache.invalidate("categories", "")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
+}
+
+// DeleteAttribute godoc
+// @Summary Delete a category attribute
+// @Description Delete an attribute together with the product values of it.
+// @Tags attributes
+// @Param id path string true "Category ID"
+// @Param attributeId path string true "Attribute ID"
+// @Success 204 {string} string "No Content"
+// @Failure 404 {string} string "Attribute not found"
+// @Router /products/categories/{id}/attributes/{attributeId} [delete]
+func DeleteAttribute(w http.ResponseWriter, r *http.Request) {
+	categoryID, attributeID, _ := parseAttributePath(r.URL.Path, "/products/categories/")
+
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM category_attributes WHERE id = $1 AND category_id = $2`, attributeID, categoryID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		http.Error(w, "Attribute not found", http.StatusNotFound)
+		return
+	}
+
+	if err := touchCategoryTree(tx, categoryID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	catalogCache.invalidate("categories", "")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// normalizeAttribute trims a and drops the fields its type does not use.
+func normalizeAttribute(a *models.Attribute) {
+	a.Code = strings.ToLower(strings.TrimSpace(a.Code))
+	a.Name = strings.TrimSpace(a.Name)
+	if a.Type != models.AttributeNumber || (a.Unit != nil && strings.TrimSpace(*a.Unit) == "") {
+		a.Unit = nil
+	}
+	if a.Type != models.AttributeEnum || a.Values == nil {
+		a.Values = models.JSONStringArray{}
+	}
+}
+
+// validateAttribute checks an attribute definition.
+func validateAttribute(a models.Attribute) []ValidationErrorDetail {
+	var details []ValidationErrorDetail
+
+	if !attributeCodePattern.MatchString(a.Code) {
+		details = append(details, ValidationErrorDetail{Field: "code", Message: "Code must be 1 to 64 lowercase letters, digits or underscores"})
+	}
+	if a.Name == "" {
+		details = append(details, ValidationErrorDetail{Field: "name", Message: "Name is required"})
+	}
+	switch a.Type {
+	case models.AttributeNumber, models.AttributeBoolean:
+	case models.AttributeEnum:
+		seen := map[string]bool{}
+		for _, v := range a.Values {
+			if strings.TrimSpace(v) == "" || seen[v] {
+				details = append(details, ValidationErrorDetail{Field: "values", Message: "Values must be unique and not empty"})
+				break
+			}
+			seen[v] = true
+		}
+		if len(a.Values) == 0 {
+			details = append(details, ValidationErrorDetail{Field: "values", Message: "Enum attributes need at least one value"})
+		}
+	default:
+		details = append(details, ValidationErrorDetail{Field: "type", Message: "Type must be number, enum or boolean"})
+	}
+
+	return details
+}
+
+// touchCategoryTree marks a category and its subcategories as changed, so
+// that responses with the attributes of their products are revalidated.
+func touchCategoryTree(tx *sqlx.Tx, categoryID string) error {
+	_, err := tx.Exec(`
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = $1
+			UNION
+			SELECT ch.id FROM categories ch JOIN subtree ON ch.parent_id = subtree.id
+		)
+		UPDATE categories SET updated_at = NOW() WHERE id IN (SELECT id FROM subtree)
+	`, categoryID)
+	return err
+}
+
+// ProductAttributesHandler handles GET, PUT /products/{id}/attributes
+func ProductAttributesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		GetProductAttributes(w, r)
+	case http.MethodPut:
+		SetProductAttributes(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetProductAttributes godoc
+// @Summary Get product attributes
+// @Description Get the attribute values of a product, in the order of its category's attributes.
+// @Tags attributes
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {array} models.AttributeValue
+// @Failure 404 {string} string "Product not found"
+// @Router /products/{id}/attributes [get]
+func GetProductAttributes(w http.ResponseWriter, r *http.Request) {
+	productID, _, _ := parseAttributePath(r.URL.Path, "/products/")
+
+	body, _, err := catalogCache.load("product-attributes:"+productID, func() (interface{}, []string, error) {
+		var p models.Product
+		if err := db.Get(&p, `SELECT * FROM products WHERE id = $1`, productID); err != nil {
+			return nil, nil, err
+		}
+		values, err := productAttributes(db, p)
+		if values == nil {
+			values = []models.AttributeValue{}
+		}
+		return values, productDetailTags(p), err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONBody(w, body)
+}
+
+// SetProductAttributes godoc
+// @Summary Set product attributes
+// @Description Replace the attribute values of a product with a map from attribute code to value: a number, one of the enum values, or true/false. Attributes left out or null are cleared.
+// @Tags attributes
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param values body map[string]interface{} true "Values by attribute code"
+// @Success 200 {array} models.AttributeValue
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 404 {string} string "Product not found"
+// @Router /products/{id}/attributes [put]
+func SetProductAttributes(w http.ResponseWriter, r *http.Request) {
+	productID, _, _ := parseAttributePath(r.URL.Path, "/products/")
+
+	var values map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var p models.Product
+	err = tx.Get(&p, `SELECT * FROM products WHERE id = $1 FOR UPDATE`, productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	attrs, err := categoryAttributes(tx, p.CategoryID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	byCode := make(map[string]models.Attribute, len(attrs))
+	for _, a := range attrs {
+		byCode[a.Code] = a
+	}
+
+	codes := make([]string, 0, len(values))
+	for code := range values {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	var details []ValidationErrorDetail
+	var rows []attributeValueRow
+	for _, code := range codes {
+		if values[code] == nil {
+			continue
+		}
+		a, ok := byCode[code]
+		if !ok {
+			details = append(details, ValidationErrorDetail{Field: code, Message: "Unknown attribute for this category"})
+			continue
+		}
+		number, text, msg := attributeColumns(a, values[code])
+		if msg != "" {
+			details = append(details, ValidationErrorDetail{Field: code, Message: msg})
+			continue
+		}
+		rows = append(rows, attributeValueRow{ProductID: p.ID, AttributeID: a.ID, NumberValue: number, TextValue: text})
+	}
+	if len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+
+	if _, err := tx.Exec(`DELETE FROM product_attributes WHERE product_id = $1`, p.ID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	for _, row := range rows {
+		_, err := tx.NamedExec(`
+			INSERT INTO product_attributes (product_id, attribute_id, number_value, text_value)
+			VALUES (:product_id, :attribute_id, :number_value, :text_value)
+		`, row)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+	}
+	// Attribute values are not part of the product version, but listings
+	// filtered on them change.
+	if _, err := tx.Exec(`UPDATE products SET updated_at = NOW() WHERE id = $1`, p.ID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	stored, err := productAttributes(tx, p)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	catalogCache.invalidate("products", p.ID)
+
+	if stored == nil {
+		stored = []models.AttributeValue{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stored)
+}
+
+// ProductComparison is a specification matrix of several products.
+type ProductComparison struct {
+	Products   []models.Product    `json:"products"`
+	Attributes []ComparedAttribute `json:"attributes"`
+}
+
+// ComparedAttribute is a row of a ProductComparison: the values of one
+// attribute for each compared product, null where a product has none.
+type ComparedAttribute struct {
+	Code   string        `json:"code"`
+	Name   string        `json:"name"`
+	Type   string        `json:"type"`
+	Unit   *string       `json:"unit,omitempty"`
+	Values []interface{} `json:"values"`
+	// Differs is set when the products do not all have the same value.
+	Differs bool `json:"differs"`
+}
+
+// ProductCompareHandler handles GET /products/compare
+func ProductCompareHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		serveCatalog(w, r, CompareProducts, productTables...)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// CompareProducts godoc
+// @Summary Compare products
+// @Description Get products side by side with a matrix of their attribute values. Rows follow the attribute order of the first product's category; each row holds one value per product, in the requested order.
+// @Tags attributes
+// @Produce json
+// @Param ids query string true "Comma separated product IDs, at most 10"
+// @Success 200 {object} ProductComparison
+// @Success 304 {string} string "Not Modified"
+// @Failure 400 {string} string "Invalid ids"
+// @Failure 404 {string} string "Product not found"
+// @Router /products/compare [get]
+func CompareProducts(w http.ResponseWriter, r *http.Request) {
+	ids := compareIDs(r.URL.Query()["ids"])
+	if len(ids) == 0 {
+		http.Error(w, "ids is required", http.StatusBadRequest)
+		return
+	}
+	if len(ids) > maxCompareProducts {
+		http.Error(w, "At most "+strconv.Itoa(maxCompareProducts)+" products can be compared", http.StatusBadRequest)
+		return
+	}
+
+	body, _, err := catalogCache.load("compare:"+strings.Join(ids, ","), func() (interface{}, []string, error) {
+		return compareProducts(ids)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONBody(w, body)
+}
+
+// compareIDs splits the ids parameters, dropping blanks and repeats.
+func compareIDs(params []string) []string {
+	var ids []string
+	for _, param := range params {
+		for _, id := range strings.Split(param, ",") {
+			if id = strings.TrimSpace(id); id != "" && !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+func compareProducts(ids []string) (ProductComparison, []string, error) {
+	var found []models.Product
+	// The detail columns include category_id, which selects the attributes,
+	// and the IDs the cache tags are built from
+	err := db.Select(&found, productDetailSelect+` WHERE p.id = ANY($1)`, ids)
+	if err != nil {
+		return ProductComparison{}, nil, err
+	}
+	if len(found) != len(ids) {
+		return ProductComparison{}, nil, sql.ErrNoRows
+	}
+	byID := make(map[string]models.Product, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+
+	cmp := ProductComparison{Products: make([]models.Product, len(ids)), Attributes: []ComparedAttribute{}}
+	var tags []string
+	rows := map[string]int{}
+	for i, id := range ids {
+		p := byID[id]
+		cmp.Products[i] = withImages(p)
+		tags = append(tags, productDetailTags(p)...)
+
+		values, err := productAttributes(db, p)
+		if err != nil {
+			return ProductComparison{}, nil, err
+		}
+		for _, v := range values {
+			row, ok := rows[v.Code]
+			if !ok {
+				row = len(cmp.Attributes)
+				rows[v.Code] = row
+				cmp.Attributes = append(cmp.Attributes, ComparedAttribute{
+					Code: v.Code, Name: v.Name, Type: v.Type, Unit: v.Unit,
+					Values: make([]interface{}, len(ids)),
+				})
+			}
+			cmp.Attributes[row].Values[i] = v.Value
+		}
+	}
+	for i := range cmp.Attributes {
+		values := cmp.Attributes[i].Values
+		for _, v := range values[1:] {
+			if v != values[0] {
+				cmp.Attributes[i].Differs = true
+				break
+			}
+		}
+	}
+	return cmp, tags, nil
+}
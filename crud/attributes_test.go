@@ -0,0 +1,212 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"noble-group-services/models"
+)
+
+func TestAttributeFilter(t *testing.T) {
+	query := url.Values{
+		"attr.power.min": {"5"},
+		"attr.power.max": {""},
+		"attr.fuel":      {"diesel", "gas"},
+		"attr.portable":  {""},
+		"search":         {"x"},
+	}
+	var lq listQuery
+	require.NoError(t, attributeFilter(&lq, query))
+	const exists = ` AND EXISTS (SELECT 1 FROM product_attributes pa JOIN category_attributes a ON a.id = pa.attribute_id
+			WHERE pa.product_id = p.id`
+	assert.Equal(t, exists+` AND a.code = $1 AND pa.text_value = ANY($2))`+
+		exists+` AND a.code = $3 AND pa.number_value >= $4::numeric)`, lq.Where)
+	assert.Equal(t, queryArgs{"fuel", []string{"diesel", "gas"}, "power", 5.0}, lq.Args)
+
+	for _, bad := range []url.Values{{"attr.power.min": {"five"}}, {"attr.Power": {"1"}}, {"attr.": {"1"}}} {
+		err := attributeFilter(&listQuery{}, bad)
+		var fe filterError
+		assert.ErrorAs(t, err, &fe, "%v", bad)
+	}
+}
+
+func TestParseAttributePath(t *testing.T) {
+	id, attr, ok := parseAttributePath("/products/categories/c1/attributes/a1", "/products/categories/")
+	assert.True(t, ok)
+	assert.Equal(t, "c1", id)
+	assert.Equal(t, "a1", attr)
+
+	id, attr, ok = parseAttributePath("/products/p1/attributes", "/products/")
+	assert.True(t, ok)
+	assert.Equal(t, "p1", id)
+	assert.Empty(t, attr)
+
+	for _, path := range []string{"/products/p1", "/products/p1/attributes/", "/products/p1/variants", "/products//attributes"} {
+		_, _, ok := parseAttributePath(path, "/products/")
+		assert.False(t, ok, path)
+	}
+}
+
+func TestAttributeValues(t *testing.T) {
+	kw := "кВт"
+	power := models.Attribute{Name: "Мощность", Type: models.AttributeNumber, Unit: &kw}
+	fuel := models.Attribute{Name: "Топливо", Type: models.AttributeEnum, Values: models.JSONStringArray{"diesel", "gas"}}
+	portable := models.Attribute{Name: "Переносной", Type: models.AttributeBoolean}
+
+	n, text, msg := attributeColumns(power, 5.5)
+	require.Empty(t, msg)
+	assert.Nil(t, text)
+	assert.Equal(t, 5.5, attributeValueRow{NumberValue: n}.value(power.Type))
+
+	_, text, msg = attributeColumns(portable, true)
+	require.Empty(t, msg)
+	assert.Equal(t, true, attributeValueRow{TextValue: text}.value(portable.Type))
+
+	_, text, msg = attributeColumns(fuel, "gas")
+	require.Empty(t, msg)
+	assert.Equal(t, "gas", attributeValueRow{TextValue: text}.value(fuel.Type))
+
+	for _, bad := range []struct {
+		a models.Attribute
+		v interface{}
+	}{{power, "5"}, {portable, "yes"}, {fuel, "petrol"}, {fuel, 1.0}} {
+		_, _, msg := attributeColumns(bad.a, bad.v)
+		assert.NotEmpty(t, msg, "%s %v", bad.a.Name, bad.v)
+	}
+}
+
+func TestValidateAttribute(t *testing.T) {
+	a := models.Attribute{Code: " Fuel ", Name: "Топливо", Type: models.AttributeEnum, Values: models.JSONStringArray{"diesel"}}
+	normalizeAttribute(&a)
+	assert.Equal(t, "fuel", a.Code)
+	assert.Empty(t, validateAttribute(a))
+
+	unit := "kg"
+	b := models.Attribute{Code: "portable", Name: "Переносной", Type: models.AttributeBoolean, Unit: &unit, Values: models.JSONStringArray{"x"}}
+	normalizeAttribute(&b)
+	assert.Nil(t, b.Unit)
+	assert.Empty(t, b.Values)
+
+	var fields []string
+	for _, d := range validateAttribute(models.Attribute{Code: "bad code", Type: "text"}) {
+		fields = append(fields, d.Field)
+	}
+	assert.Equal(t, []string{"code", "name", "type"}, fields)
+	assert.NotEmpty(t, validateAttribute(models.Attribute{Code: "fuel", Name: "Fuel", Type: models.AttributeEnum}))
+}
+
+func TestCompareIDs(t *testing.T) {
+	assert.Equal(t, []string{"a", "b", "c"}, compareIDs([]string{"a, b,,a", "c"}))
+	assert.Empty(t, compareIDs(nil))
+}
+
+func TestProductAttributes(t *testing.T) {
+	setupTestDB(t)
+
+	var products []models.Product
+	require.NoError(t, db.Select(&products, `SELECT * FROM products ORDER BY id LIMIT 2`))
+	require.Len(t, products, 2)
+
+	// Both products get a temporary category, so that no other attribute applies
+	categoryID := uuid.New().String()
+	_, err := db.Exec(`INSERT INTO categories (id, name, slug) VALUES ($1, 'Attribute Test', $2)`, categoryID, "attribute-test-"+categoryID[:8])
+	require.NoError(t, err)
+	defer db.Exec(`DELETE FROM categories WHERE id = $1`, categoryID)
+	for _, p := range products {
+		_, err := db.Exec(`UPDATE products SET category_id = $2 WHERE id = $1`, p.ID, categoryID)
+		require.NoError(t, err)
+		defer db.Exec(`UPDATE products SET category_id = $2 WHERE id = $1`, p.ID, p.CategoryID)
+	}
+	catalogCache.purge()
+
+	define := func(a models.Attribute) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(a)
+		w := httptest.NewRecorder()
+		CategoryItemHandler(w, httptest.NewRequest(http.MethodPost, "/products/categories/"+categoryID+"/attributes", bytes.NewReader(body)))
+		return w
+	}
+	kw := "кВт"
+	require.Equal(t, http.StatusCreated, define(models.Attribute{Code: "power", Name: "Мощность", Type: models.AttributeNumber, Unit: &kw}).Code)
+	w := define(models.Attribute{Code: "fuel", Name: "Топливо", Type: models.AttributeEnum, Values: models.JSONStringArray{"diesel", "gas"}})
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+	assert.Equal(t, http.StatusConflict, define(models.Attribute{Code: "power", Name: "Power", Type: models.AttributeNumber}).Code)
+
+	setValues := func(productID, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ProductItemHandler(w, httptest.NewRequest(http.MethodPut, "/products/"+productID+"/attributes", strings.NewReader(body)))
+		return w
+	}
+	w = setValues(products[0].ID, `{"power": 5, "fuel": "diesel"}`)
+	require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+	require.Equal(t, http.StatusOK, setValues(products[1].ID, `{"power": 12.5, "fuel": "diesel"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, setValues(products[1].ID, `{"fuel": "petrol"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, setValues(products[1].ID, `{"weight": 3}`).Code)
+
+	listed := func(query string) int {
+		w := httptest.NewRecorder()
+		ProductsHandler(w, httptest.NewRequest(http.MethodGet, "/products?limit=100&"+query, nil))
+		require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+		var found []models.Product
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &found))
+		return len(found)
+	}
+	assert.Equal(t, 2, listed("attr.fuel=diesel&attr.fuel=gas"))
+	assert.Equal(t, 1, listed("attr.power.min=10"))
+	assert.Equal(t, 1, listed("attr.power.min=1&attr.power.max=5"))
+	assert.Zero(t, listed("attr.fuel=gas"))
+
+	w = httptest.NewRecorder()
+	ProductsHandler(w, httptest.NewRequest(http.MethodGet, "/products?attr.power.min=lots", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// The detail response and the comparison show the values
+	w = httptest.NewRecorder()
+	ProductItemHandler(w, httptest.NewRequest(http.MethodGet, "/products/"+products[0].ID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var detail models.Product
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &detail))
+	require.Len(t, detail.Attributes, 2)
+	assert.Equal(t, "power", detail.Attributes[0].Code)
+	assert.Equal(t, 5.0, detail.Attributes[0].Value)
+
+	w = httptest.NewRecorder()
+	ProductCompareHandler(w, httptest.NewRequest(http.MethodGet, "/products/compare?ids="+products[1].ID+","+products[0].ID, nil))
+	require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+	var cmp ProductComparison
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cmp))
+	require.Len(t, cmp.Products, 2)
+	assert.Equal(t, products[1].ID, cmp.Products[0].ID)
+	require.Len(t, cmp.Attributes, 2)
+	assert.Equal(t, []interface{}{12.5, 5.0}, cmp.Attributes[0].Values)
+	assert.True(t, cmp.Attributes[0].Differs)
+	assert.False(t, cmp.Attributes[1].Differs)
+	assert.Equal(t, "fuel", cmp.Attributes[1].Code)
+	assert.Equal(t, []interface{}{"diesel", "diesel"}, cmp.Attributes[1].Values)
+
+	// The cached comparison depends on the category and its attributes
+	_, tags, err := compareProducts([]string{products[0].ID})
+	require.NoError(t, err)
+	assert.Contains(t, tags, "categories:"+categoryID)
+	assert.Contains(t, tags, "manufacturers:"+products[0].ManufacturerID)
+	require.Equal(t, http.StatusOK, setValues(products[0].ID, `{"power": 5, "fuel": "gas"}`).Code)
+	w = httptest.NewRecorder()
+	ProductCompareHandler(w, httptest.NewRequest(http.MethodGet, "/products/compare?ids="+products[1].ID+","+products[0].ID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cmp))
+	require.Len(t, cmp.Attributes, 2)
+	assert.Equal(t, []interface{}{"diesel", "gas"}, cmp.Attributes[1].Values)
+	assert.True(t, cmp.Attributes[1].Differs)
+
+	w = httptest.NewRecorder()
+	ProductCompareHandler(w, httptest.NewRequest(http.MethodGet, "/products/compare?ids="+products[0].ID+",missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,147 @@
+package crud
+
+import (
+	"container/list"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// defaultCatalogCacheBytes bounds the encoded responses kept in catalogCache.
+const defaultCatalogCacheBytes = 32 << 20
+
+// catalogCache holds encoded catalog reads. Entries are tagged with the tables
+// ("categories") and rows ("products:<id>") they were built from and dropped
+// when one of those changes, see invalidate.
+var catalogCache = newResponseCache(defaultCatalogCacheBytes)
+
+// SetCatalogCacheSize replaces the catalog cache with an empty one holding at
+// most maxBytes of responses. Zero disables caching.
+func SetCatalogCacheSize(maxBytes int) {
+	catalogCache = newResponseCache(maxBytes)
+}
+
+type cacheEntry struct {
+	key   string
+	tags  []string
+	value interface{}
+	body  []byte
+}
+
+func (e *cacheEntry) size() int {
+	return len(e.key) + len(e.body)
+}
+
+// responseCache is a size bounded LRU cache of JSON bodies.
+type responseCache struct {
+	mu       sync.Mutex
+	maxBytes int
+	size     int
+	gen      uint64 // bumped by every invalidation
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+func newResponseCache(maxBytes int) *responseCache {
+	return &responseCache{
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// load returns the value produced by fn for key together with its JSON
+// encoding, calling fn only on a miss. fn also returns the tags that
+// invalidate the value. Cached values are shared and must not be modified.
+//
+// A value is not stored when an invalidation ran while fn was loading it, as
+// it may already be stale.
+func (c *responseCache) load(key string, fn func() (interface{}, []string, error)) ([]byte, interface{}, error) {
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		e := el.Value.(*cacheEntry)
+		c.mu.Unlock()
+		return e.body, e.value, nil
+	}
+	gen := c.gen
+	c.mu.Unlock()
+
+	v, tags, err := fn()
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	body = append(body, '\n')
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gen == gen {
+		c.add(&cacheEntry{key: key, tags: tags, value: v, body: body})
+	}
+	return body, v, nil
+}
+
+// add stores e and evicts least recently used entries beyond maxBytes.
+// c.mu must be held.
+func (c *responseCache) add(e *cacheEntry) {
+	if e.size() > c.maxBytes {
+		return
+	}
+	if el, ok := c.items[e.key]; ok {
+		c.remove(el)
+	}
+	c.items[e.key] = c.ll.PushFront(e)
+	c.size += e.size()
+
+	for c.size > c.maxBytes {
+		c.remove(c.ll.Back())
+	}
+}
+
+// remove drops el. c.mu must be held.
+func (c *responseCache) remove(el *list.Element) {
+	e := c.ll.Remove(el).(*cacheEntry)
+	delete(c.items, e.key)
+	c.size -= e.size()
+}
+
+// invalidate drops the entries built from row id of table, or from any row
+// of table when id is empty, as well as those built from the whole table.
+func (c *responseCache) invalidate(table, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	for el := c.ll.Front(); el != nil; {
+		next := el.Next()
+		for _, tag := range el.Value.(*cacheEntry).tags {
+			if tag == table || tag == table+":"+id || (id == "" && strings.HasPrefix(tag, table+":")) {
+				c.remove(el)
+				break
+			}
+		}
+		el = next
+	}
+}
+
+// purge drops every entry.
+func (c *responseCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.size = 0
+}
+
+// writeJSONBody writes an already encoded JSON response.
+func writeJSONBody(w http.ResponseWriter, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,70 @@
+package crud
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// catalogChangesChannel is notified by the triggers in
+// models/schema_catalog_notify.sql.
+const catalogChangesChannel = "catalog_changes"
+
+// catalogListenerRetry is the pause before reconnecting a lost listener.
+const catalogListenerRetry = 5 * time.Second
+
+type catalogChange struct {
+	Table string `json:"table"`
+	ID    string `json:"id"`
+}
+
+// ListenCatalogChanges keeps the catalog cache coherent with writes made by
+// other replicas, invalidating it on every catalog_changes notification until
+// ctx is done. The cache is purged whenever the listener (re)connects, since
+// notifications sent while it was down are lost.
+func ListenCatalogChanges(ctx context.Context, dsn string) {
+	for {
+		err := listenCatalogChanges(ctx, dsn)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Catalog change listener stopped: %v; reconnecting in %s", err, catalogListenerRetry)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(catalogListenerRetry):
+		}
+	}
+}
+
+func listenCatalogChanges(ctx context.Context, dsn string) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+catalogChangesChannel); err != nil {
+		return err
+	}
+	catalogCache.purge()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var change catalogChange
+		if err := json.Unmarshal([]byte(n.Payload), &change); err != nil {
+			log.Printf("Invalid catalog change %q: %v", n.Payload, err)
+			catalogCache.purge()
+			continue
+		}
+		catalogCache.invalidate(change.Table, change.ID)
+	}
+}
@@ -0,0 +1,90 @@
+package crud
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCache_Load(t *testing.T) {
+	c := newResponseCache(1 << 10)
+	calls := 0
+	load := func() (interface{}, []string, error) {
+		calls++
+		return []string{"a"}, []string{"categories"}, nil
+	}
+
+	body, v, err := c.load("categories", load)
+	require.NoError(t, err)
+	assert.Equal(t, "[\"a\"]\n", string(body))
+	assert.Equal(t, []string{"a"}, v)
+
+	_, _, err = c.load("categories", load)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	c.invalidate("categories", "c1")
+	_, _, err = c.load("categories", load)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestResponseCache_ErrorsAreNotCached(t *testing.T) {
+	c := newResponseCache(1 << 10)
+	errLoad := errors.New("boom")
+
+	_, _, err := c.load("k", func() (interface{}, []string, error) { return nil, nil, errLoad })
+	assert.ErrorIs(t, err, errLoad)
+	assert.Empty(t, c.items)
+}
+
+func TestResponseCache_InvalidateRow(t *testing.T) {
+	c := newResponseCache(1 << 10)
+	put := func(key string, tags ...string) {
+		_, _, err := c.load(key, func() (interface{}, []string, error) { return key, tags, nil })
+		require.NoError(t, err)
+	}
+	put("product:p1", "products:p1", "categories:c1")
+	put("product:p2", "products:p2", "categories:c2")
+	put("products", "products", "categories")
+
+	c.invalidate("products", "p1")
+	assert.NotContains(t, c.items, "product:p1")
+	assert.Contains(t, c.items, "product:p2")
+	assert.NotContains(t, c.items, "products")
+
+	c.invalidate("categories", "")
+	assert.Empty(t, c.items)
+	assert.Zero(t, c.size)
+}
+
+func TestResponseCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newResponseCache(30)
+	put := func(key string) {
+		_, _, err := c.load(key, func() (interface{}, []string, error) { return "0123456789", nil, nil })
+		require.NoError(t, err)
+	}
+
+	put("a") // 1 + 13 bytes
+	put("b")
+	put("a") // hit, a becomes most recent
+	put("c") // evicts b
+
+	assert.Contains(t, c.items, "a")
+	assert.NotContains(t, c.items, "b")
+	assert.Contains(t, c.items, "c")
+	assert.LessOrEqual(t, c.size, 30)
+}
+
+func TestResponseCache_SkipsValuesLoadedDuringInvalidation(t *testing.T) {
+	c := newResponseCache(1 << 10)
+
+	_, _, err := c.load("categories", func() (interface{}, []string, error) {
+		c.invalidate("categories", "c1") // a write commits while loading
+		return "stale", []string{"categories"}, nil
+	})
+	require.NoError(t, err)
+	assert.Empty(t, c.items)
+}
@@ -2,7 +2,9 @@
 package crud
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"sync"
@@ -63,21 +65,22 @@ func GetCart(w http.ResponseWriter, r *http.Request) {
 
 // AddToCart godoc
 // @Summary Add item to cart
-// @Description Add a product to the cart
+// @Description Add a product to the cart. Products with variants need the variantId of the chosen variant, which sets the price.
 // @Tags cart
 // @Accept json
 // @Produce json
 // @Param X-Session-ID header string false "Session ID"
-// @Param request body object{productId=string,quantity=int} true "Product ID and Quantity"
+// @Param request body object{productId=string,variantId=string,quantity=int} true "Product ID, Variant ID and Quantity"
 // @Success 200 {object} CartResponse
 // @Failure 400 {string} string "Invalid request"
-// @Failure 404 {string} string "Product not found"
+// @Failure 404 {string} string "Product or variant not found"
 // @Router /cart [post]
 func AddToCart(w http.ResponseWriter, r *http.Request) {
 	sessionID := getSessionID(w, r)
 
 	var req struct {
 		ProductID string `json:"productId"`
+		VariantID string `json:"variantId,omitempty"`
 		Quantity  *int   `json:"quantity,omitempty"`
 	}
 
@@ -101,7 +104,7 @@ func AddToCart(w http.ResponseWriter, r *http.Request) {
 	err := db.Get(&product, `
 		SELECT 
 			p.id, p.name, p.slug, p.price, p.old_price, p.description, 
-			p.features, p.image, p.stock, p.sku, p.availability,
+			p.features, p.image, p.stock, p.sku, p.availability, p.allow_backorder,
 			m.id AS "manufacturer.id", m.name AS "manufacturer.name", m.slug AS "manufacturer.slug", m.logo AS "manufacturer.logo",
 			c.id AS "category.id", c.name AS "category.name", c.slug AS "category.slug"
 		FROM products p
@@ -115,7 +118,26 @@ func AddToCart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if product.Stock < qty {
+	variant, needed, err := loadCartVariant(product.ID, req.VariantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Variant not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if needed && variant == nil {
+		http.Error(w, "variantId is required", http.StatusBadRequest)
+		return
+	}
+	lineID := product.ID
+	if variant != nil {
+		product = applyVariant(product, *variant)
+		lineID = variant.ID
+	}
+
+	if product.Stock < qty && !product.AllowBackorder {
 		http.Error(w, "Not enough stock", http.StatusBadRequest)
 		return
 	}
@@ -124,7 +146,7 @@ func AddToCart(w http.ResponseWriter, r *http.Request) {
 	cart := getCartUnsafe(sessionID)
 	found := false
 	for i := range cart.Items {
-		if cart.Items[i].ID == req.ProductID {
+		if cart.Items[i].LineID() == lineID {
 			cart.Items[i].Quantity += qty
 			found = true
 			break
@@ -134,6 +156,7 @@ func AddToCart(w http.ResponseWriter, r *http.Request) {
 		cart.Items = append(cart.Items, models.CartItem{
 			Product:  product,
 			Quantity: qty,
+			Variant:  variant,
 		})
 	}
 	cart.CalculateTotals()
@@ -204,7 +227,7 @@ func CartItemHandler(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param X-Session-ID header string false "Session ID"
-// @Param id path string true "Product ID"
+// @Param id path string true "Variant ID, or Product ID for products without variants"
 // @Param request body object{quantity=int} true "New Quantity"
 // @Success 200 {object} CartResponse
 // @Failure 400 {string} string "Invalid request"
@@ -235,7 +258,7 @@ func UpdateCartItem(w http.ResponseWriter, r *http.Request) {
 	cart := getCartUnsafe(sessionID)
 	found := false
 	for i := range cart.Items {
-		if cart.Items[i].ID == productID {
+		if cart.Items[i].LineID() == productID {
 			cart.Items[i].Quantity = req.Quantity
 			found = true
 			break
@@ -265,7 +288,7 @@ func UpdateCartItem(w http.ResponseWriter, r *http.Request) {
 // @Tags cart
 // @Produce json
 // @Param X-Session-ID header string false "Session ID"
-// @Param id path string true "Product ID"
+// @Param id path string true "Variant ID, or Product ID for products without variants"
 // @Success 200 {object} CartResponse
 // @Failure 404 {string} string "Item not found"
 // @Router /cart/{id} [delete]
@@ -287,7 +310,7 @@ func RemoveCartItem(w http.ResponseWriter, r *http.Request) {
 
 	found := false
 	for i, item := range cart.Items {
-		if item.ID == productID {
+		if item.LineID() == productID {
 			cart.Items = append(cart.Items[:i], cart.Items[i+1:]...)
 			found = true
 			break
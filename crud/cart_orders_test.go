@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"noble-group-services/models"
+	"noble-group-services/services/notify"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -540,6 +543,134 @@ func TestOrdersHandler_Post_EmptyCart(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestOrdersHandler_Post_QueuesOrderEmail(t *testing.T) {
+	setupTestDB(t)
+
+	var p models.Product
+	err := db.Get(&p, "SELECT id, name FROM products p WHERE stock > 0 AND NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id) LIMIT 1")
+	if err != nil {
+		t.Skip("No products in database")
+	}
+
+	// Individuals get the email too, in the language they asked for
+	email := "email-" + uuid.New().String()[:8] + "@example.com"
+	orderJSON, _ := json.Marshal(models.CheckoutForm{
+		Name:         "Email Test",
+		Phone:        "+77001234567",
+		Email:        email,
+		Address:      "Email Test Address",
+		CustomerType: "individual",
+		Carts:        []models.CartItemRequest{{ProductID: p.ID, Quantity: 2}},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBuffer(orderJSON))
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	w := httptest.NewRecorder()
+	OrdersHandler(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+
+	var response map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&response)
+	orderID := response["orderId"].(string)
+	defer db.Exec("DELETE FROM orders WHERE id = $1", orderID)
+
+	queued := func() []models.OutboxEmail {
+		var queued []models.OutboxEmail
+		require.NoError(t, db.Select(&queued, "SELECT * FROM email_outbox WHERE order_id = $1 ORDER BY created_at", orderID))
+		return queued
+	}
+	defer db.Exec("DELETE FROM email_outbox WHERE order_id = $1", orderID)
+	require.Len(t, queued(), 1)
+	placed := queued()[0]
+	assert.Equal(t, email, placed.Recipient)
+	assert.Equal(t, models.EmailPending, placed.Status)
+	assert.Contains(t, placed.Subject, response["orderNumber"].(string))
+	assert.Contains(t, placed.TextBody, p.Name)
+	assert.Contains(t, placed.HTMLBody, p.Name)
+
+	// Status changes are emailed; repeating a status is not
+	patch := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		OrderItemHandler(w, httptest.NewRequest(http.MethodPatch, "/orders/"+orderID, strings.NewReader(body)))
+		return w
+	}
+	w = patch(`{"status":"shipped","trackingNumber":"KZ123456789"}`)
+	require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+	var order models.Order
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+	assert.Equal(t, "en", order.Locale)
+	require.Len(t, queued(), 2)
+	assert.Contains(t, queued()[1].TextBody, "KZ123456789")
+
+	require.Equal(t, http.StatusOK, patch(`{"status":"shipped"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch(`{"status":"lost"}`).Code)
+	w = httptest.NewRecorder()
+	OrderItemHandler(w, httptest.NewRequest(http.MethodPatch, "/orders/missing", strings.NewReader(`{"status":"shipped"}`)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Len(t, queued(), 2)
+}
+
+func TestOrdersHandler_Post_NotifiesStaff(t *testing.T) {
+	setupTestDB(t)
+
+	// A stand-in for the Telegram Bot API
+	received := make(chan string, 1)
+	telegram := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Text string `json:"text"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		received <- body.Text
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer telegram.Close()
+	defer SetStaffNotifier(staffNotifier)
+	SetStaffNotifier(&notify.TelegramNotifier{Token: "t", ChatID: "1", APIURL: telegram.URL})
+	defer SetStaffEmails(staffEmails)
+	SetStaffEmails([]string{"sales@example.com", "boss@example.com"})
+
+	var p models.Product
+	err := db.Get(&p, "SELECT id FROM products WHERE stock > 0 LIMIT 1")
+	if err != nil {
+		t.Skip("No products in database")
+	}
+
+	companyName, bin := "ТОО Тест", "123456789012"
+	orderJSON, _ := json.Marshal(models.CheckoutForm{
+		Name:         "Staff Test",
+		Phone:        "+77001234567",
+		Email:        "staff-test@example.com",
+		Address:      "Staff Test Address",
+		CustomerType: "legal",
+		CompanyName:  &companyName,
+		BIN:          &bin,
+		Carts:        []models.CartItemRequest{{ProductID: p.ID, Quantity: 1}},
+	})
+	w := httptest.NewRecorder()
+	OrdersHandler(w, httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBuffer(orderJSON)))
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+
+	var response map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&response)
+	defer db.Exec("DELETE FROM orders WHERE id = $1", response["orderId"])
+	defer db.Exec("DELETE FROM email_outbox WHERE order_id = $1", response["orderId"])
+
+	// Staff emails are queued with the order, so they survive SMTP outages
+	var staff []models.OutboxEmail
+	require.NoError(t, db.Select(&staff, "SELECT * FROM email_outbox WHERE order_id = $1 AND kind = $2 ORDER BY recipient", response["orderId"], staffOrderEmail))
+	require.Len(t, staff, 2)
+	assert.Equal(t, "boss@example.com", staff[0].Recipient)
+	assert.Contains(t, staff[0].TextBody, "123456789012")
+
+	select {
+	case text := <-received:
+		assert.Contains(t, text, response["orderNumber"].(string))
+		assert.Contains(t, text, "ТОО Тест")
+		assert.Contains(t, text, "123456789012")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Staff were not notified")
+	}
+}
+
 func TestOrdersHandler_MethodNotAllowed(t *testing.T) {
 	setupTestDB(t)
 
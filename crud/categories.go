@@ -1,7 +1,10 @@
 package crud
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strings"
 
@@ -10,11 +13,14 @@ import (
 	"github.com/google/uuid"
 )
 
+// categoryColumns are the columns of models.Category.
+const categoryColumns = `id, name, slug, parent_id, image, version, updated_at`
+
 // CategoriesHandler handles GET /categories and POST /categories
 func CategoriesHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		GetCategories(w, r)
+		serveCatalog(w, r, GetCategories, categoryTables...)
 	case http.MethodPost:
 		CreateCategory(w, r)
 	default:
@@ -22,13 +28,19 @@ func CategoriesHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// CategoryItemHandler handles GET, PUT, DELETE /categories/{id}
+// CategoryItemHandler handles GET, PUT, PATCH, DELETE /categories/{id}
 func CategoryItemHandler(w http.ResponseWriter, r *http.Request) {
+	if _, _, ok := parseAttributePath(r.URL.Path, "/products/categories/"); ok {
+		CategoryAttributesHandler(w, r)
+		return
+	}
 	switch r.Method {
 	case http.MethodGet:
 		GetCategory(w, r)
 	case http.MethodPut:
 		UpdateCategory(w, r)
+	case http.MethodPatch:
+		PatchCategory(w, r)
 	case http.MethodDelete:
 		DeleteCategory(w, r)
 	default:
@@ -36,33 +48,100 @@ func CategoryItemHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// CategoryBySlugHandler handles GET /products/categories/by-slug/{slug}
+func CategoryBySlugHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		GetCategoryBySlug(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// CategoriesPageHandler handles GET /v2/products/categories
+func CategoriesPageHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		serveCatalog(w, r, GetCategoriesPage, categoryTables...)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 // GetCategories godoc
 // @Summary      Get all categories
 // @Description  Get a list of all product categories
 // @Tags         categories
 // @Produce      json
+// @Param        If-None-Match  header  string  false  "ETag of a cached copy"
 // @Success      200  {array}  models.Category
+// @Header       200  {string}  ETag           "Strong validator of the listing"
+// @Header       200  {string}  Last-Modified  "Last catalog change"
+// @Header       200  {string}  Cache-Control  "Configured with CATALOG_CACHE_CONTROL"
+// @Success      304  {string}  string  "Not Modified"
 // @Router       /products/categories [get]
 func GetCategories(w http.ResponseWriter, _ *http.Request) {
-	var categories []models.Category
-	if err := db.Select(&categories, `SELECT id, name, slug, parent_id, image FROM categories ORDER BY name`); err != nil {
+	body, _, err := catalogCache.load("categories", func() (interface{}, []string, error) {
+		var categories []models.Category
+		err := db.Select(&categories, `SELECT `+categoryColumns+` FROM categories ORDER BY name`)
+		return categories, categoryTables, err
+	})
+	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(categories)
+	writeJSONBody(w, body)
+}
+
+// GetCategoriesPage godoc
+// @Summary      Get a page of categories
+// @Description  Get categories wrapped in a pagination envelope. Pass either page or the nextCursor of a previous response.
+// @Tags         categories
+// @Produce      json
+// @Param        page    query  int     false  "Page number"  default(1)
+// @Param        limit   query  int     false  "Items per page"  default(20)
+// @Param        cursor  query  string  false  "Keyset cursor"
+// @Param        If-None-Match  header  string  false  "ETag of a cached copy"
+// @Success      200  {object}  PageResponse[models.Category]
+// @Header       200  {string}  ETag           "Strong validator of the listing"
+// @Header       200  {string}  Last-Modified  "Last catalog change"
+// @Header       200  {string}  Cache-Control  "Configured with CATALOG_CACHE_CONTROL"
+// @Success      304  {string}  string  "Not Modified"
+// @Failure      400  {string}  string  "Invalid cursor"
+// @Router       /v2/products/categories [get]
+func GetCategoriesPage(w http.ResponseWriter, r *http.Request) {
+	lq := listQuery{
+		Columns: categoryColumns,
+		From:    `categories`,
+		Sort:    "name",
+		Keys:    []sortKey{{Expr: "name", Type: "text"}, {Expr: "id", Type: "text"}},
+	}
+
+	page, err := fetchPage(lq, parsePageParams(r.URL.Query()), func(row categoryRow) models.Category {
+		return row.Category
+	})
+	writePage(w, page, err)
+}
+
+// categoryRow is a category listing row together with its keyset sort key.
+type categoryRow struct {
+	models.Category
+	SortKey string `db:"sort_key"`
 }
 
+func (r categoryRow) sortKey() string { return r.SortKey }
+
 // CreateCategory godoc
 // @Summary      Create a category
-// @Description  Create a new product category
+// @Description  Create a new product category. When slug is omitted it is generated from the name, transliterating Cyrillic.
 // @Tags         categories
 // @Accept       json
 // @Produce      json
 // @Param        category  body  models.Category  true  "Category"
 // @Success      201  {object}  models.Category
 // @Failure      400  {string}  string  "Invalid request"
+// @Failure      409  {string}  string  "Slug already exists"
 // @Router       /products/categories [post]
 func CreateCategory(w http.ResponseWriter, r *http.Request) {
 	var c models.Category
@@ -71,31 +150,61 @@ func CreateCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if strings.TrimSpace(c.Name) == "" || strings.TrimSpace(c.Slug) == "" {
-		http.Error(w, "Name and Slug are required", http.StatusBadRequest)
+	if strings.TrimSpace(c.Name) == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
 		return
 	}
 
 	c.ID = uuid.New().String()
 
-	if _, err := db.Exec(`INSERT INTO categories (id, name, slug, parent_id, image) VALUES ($1, $2, $3, $4, $5)`,
-		c.ID, c.Name, c.Slug, c.ParentID, c.Image); err != nil {
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	c.Slug, err = resolveSlug(tx, categorySlugs, c.Slug, c.Name)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.QueryRowx(`INSERT INTO categories (id, name, slug, parent_id, image) VALUES ($1, $2, $3, $4, $5) RETURNING version, updated_at`,
+		c.ID, c.Name, c.Slug, c.ParentID, c.Image).Scan(&c.Version, &c.UpdatedAt); err != nil {
+		if isUniqueViolation(err) {
+			writeConflict(w, err)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := claimSlug(tx, categorySlugs, c.Slug); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
+	catalogCache.invalidate("categories", c.ID)
 
 	w.Header().Set("Content-Type", "application/json")
+	setVersionETag(w, c.Version)
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(c)
 }
 
 // GetCategory godoc
 // @Summary      Get category by ID
-// @Description  Get details of a specific category
+// @Description  Get details of a specific category, including its breadcrumb path. The ETag header carries the category version expected in If-Match by writes.
 // @Tags         categories
 // @Produce      json
 // @Param        id   path  string  true  "Category ID"
 // @Success      200  {object}  models.Category
+// @Header       200  {string}  ETag  "Category version"
 // @Failure      404  {string}  string  "Category not found"
 // @Router       /products/categories/{id} [get]
 func GetCategory(w http.ResponseWriter, r *http.Request) {
@@ -106,56 +215,225 @@ func GetCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var c models.Category
-	if err := db.Get(&c, `SELECT id, name, slug, parent_id, image FROM categories WHERE id = $1`, id); err != nil {
+	if err := db.Get(&c, `SELECT `+categoryColumns+` FROM categories WHERE id = $1`, id); err != nil {
 		http.NotFound(w, r)
 		return
 	}
 
+	path, err := categoryPath(db, c.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	c.Path = path
+
 	w.Header().Set("Content-Type", "application/json")
+	setVersionETag(w, c.Version)
+	_ = json.NewEncoder(w).Encode(c)
+}
+
+// GetCategoryBySlug godoc
+// @Summary      Get category by slug
+// @Description  Get details of a specific category by its slug, including its breadcrumb path. Former slugs redirect to the current one.
+// @Tags         categories
+// @Produce      json
+// @Param        slug  path  string  true  "Category Slug"
+// @Success      200  {object}  models.Category
+// @Header       200  {string}  ETag  "Category version"
+// @Success      301  {string}  string  "Moved to the current slug"
+// @Failure      404  {string}  string  "Category not found"
+// @Router       /products/categories/by-slug/{slug} [get]
+func GetCategoryBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := strings.TrimPrefix(r.URL.Path, "/products/categories/by-slug/")
+	if slug == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var c models.Category
+	err := db.Get(&c, `SELECT `+categoryColumns+` FROM categories WHERE slug = $1`, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		redirectSlug(w, r, categorySlugs, slug, "/products/categories/by-slug/")
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	path, err := categoryPath(db, c.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	c.Path = path
+
+	w.Header().Set("Content-Type", "application/json")
+	setVersionETag(w, c.Version)
 	_ = json.NewEncoder(w).Encode(c)
 }
 
 // UpdateCategory godoc
-// @Summary      Update category
-// @Description  Update an existing category
+// @Summary      Replace category
+// @Description  Replace an existing category. Name and slug are required; the new parent may not be the category itself or one of its descendants.
 // @Tags         categories
 // @Accept       json
 // @Produce      json
-// @Param        id        path  string  true  "Category ID"
-// @Param        category  body  models.Category  true  "Category"
+// @Param        id        path    string  true  "Category ID"
+// @Param        If-Match  header  string  true  "ETag of the version being replaced"
+// @Param        category  body    models.Category  true  "Category"
 // @Success      200  {object}  models.Category
-// @Failure      400  {string}  string  "Invalid request"
+// @Failure      400  {object}  ValidationErrorResponse
 // @Failure      404  {string}  string  "Category not found"
+// @Failure      409  {string}  string  "Slug already exists"
+// @Failure      412  {string}  string  "Category was modified"
+// @Failure      428  {string}  string  "If-Match header required"
 // @Router       /products/categories/{id} [put]
 func UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	writeCategory(w, r, func(_ models.Category, body []byte) (models.Category, error) {
+		var c models.Category
+		err := json.Unmarshal(body, &c)
+		return c, err
+	})
+}
+
+// PatchCategory godoc
+// @Summary      Patch category
+// @Description  Partially update a category with JSON Merge Patch (RFC 7386): only fields present in the body change, null clears a field.
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        id        path    string  true  "Category ID"
+// @Param        If-Match  header  string  true  "ETag of the version being replaced"
+// @Param        patch     body    object  true  "Merge patch"
+// @Success      200  {object}  models.Category
+// @Failure      400  {object}  ValidationErrorResponse
+// @Failure      404  {string}  string  "Category not found"
+// @Failure      409  {string}  string  "Slug already exists"
+// @Failure      412  {string}  string  "Category was modified"
+// @Failure      428  {string}  string  "If-Match header required"
+// @Router       /products/categories/{id} [patch]
+func PatchCategory(w http.ResponseWriter, r *http.Request) {
+	writeCategory(w, r, mergePatch[models.Category])
+}
+
+// writeCategory loads the category named in the path, checks If-Match against
+// its version, lets build derive the new state from it and the request body,
+// then validates, stores and returns it.
+func writeCategory(w http.ResponseWriter, r *http.Request, build func(current models.Category, body []byte) (models.Category, error)) {
 	id := strings.TrimPrefix(r.URL.Path, "/products/categories/")
 	if id == "" {
 		http.Error(w, "ID required", http.StatusBadRequest)
 		return
 	}
 
-	var c models.Category
-	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	c.ID = id
-
-	result, err := db.Exec(`UPDATE categories SET name = $1, slug = $2, parent_id = $3, image = $4 WHERE id = $5`,
-		c.Name, c.Slug, c.ParentID, c.Image, c.ID)
+	tx, err := db.Beginx()
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
+	defer tx.Rollback()
 
-	if rows, _ := result.RowsAffected(); rows == 0 {
+	var current models.Category
+	err = tx.Get(&current, `SELECT `+categoryColumns+` FROM categories WHERE id = $1 FOR UPDATE`, id)
+	if errors.Is(err, sql.ErrNoRows) {
 		http.NotFound(w, r)
 		return
 	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := checkIfMatch(r, current.Version); err != nil {
+		writePreconditionError(w, err)
+		return
+	}
+
+	c, err := build(current, body)
+	if err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	c.ID = current.ID
+	c.Version = current.Version
+	c.Path = nil
+	c.Slug = slugify(c.Slug)
+
+	if details := validateNamedEntity(c.Name, c.Slug); len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+
+	if c.ParentID != nil {
+		// Serialize re-parenting so two concurrent moves cannot form a cycle together.
+		if _, err := tx.Exec(`LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if err := checkCategoryParent(tx, c.ID, *c.ParentID); err != nil {
+			if errors.Is(err, errCategoryCycle) || errors.Is(err, errParentNotFound) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE categories SET name = $1, slug = $2, parent_id = $3, image = $4, updated_at = NOW(), version = version + 1 WHERE id = $5`,
+		c.Name, c.Slug, c.ParentID, c.Image, c.ID); err != nil {
+		if isUniqueViolation(err) {
+			writeConflict(w, err)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := recordSlugChange(tx, categorySlugs, c.ID, current.Slug, c.Slug); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	var stored models.Category
+	if err := tx.Get(&stored, `SELECT `+categoryColumns+` FROM categories WHERE id = $1`, c.ID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if stored.Path, err = categoryPath(tx, stored.ID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	catalogCache.invalidate("categories", c.ID)
 
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(c)
+	setVersionETag(w, stored.Version)
+	_ = json.NewEncoder(w).Encode(stored)
+}
+
+// validateNamedEntity checks the name and slug shared by categories and manufacturers.
+func validateNamedEntity(name, slug string) []ValidationErrorDetail {
+	var details []ValidationErrorDetail
+	if strings.TrimSpace(name) == "" {
+		details = append(details, ValidationErrorDetail{Field: "name", Message: "Name is required"})
+	}
+	if slug == "" {
+		details = append(details, ValidationErrorDetail{Field: "slug", Message: "Slug is required"})
+	}
+	return details
 }
 
 // DeleteCategory godoc
@@ -163,9 +441,12 @@ func UpdateCategory(w http.ResponseWriter, r *http.Request) {
 // @Description  Delete a category
 // @Tags         categories
 // @Produce      json
-// @Param        id   path  string  true  "Category ID"
+// @Param        id        path    string  true  "Category ID"
+// @Param        If-Match  header  string  true  "ETag of the version being deleted"
 // @Success      204
 // @Failure      404  {string}  string  "Category not found"
+// @Failure      412  {string}  string  "Category was modified"
+// @Failure      428  {string}  string  "If-Match header required"
 // @Router       /products/categories/{id} [delete]
 func DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	id := strings.TrimPrefix(r.URL.Path, "/products/categories/")
@@ -174,16 +455,5 @@ func DeleteCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := db.Exec(`DELETE FROM categories WHERE id = $1`, id)
-	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
-	}
-
-	if rows, _ := result.RowsAffected(); rows == 0 {
-		http.NotFound(w, r)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
+	deleteVersioned(w, r, "categories", id)
 }
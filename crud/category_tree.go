@@ -0,0 +1,139 @@
+package crud
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/jmoiron/sqlx"
+
+	"noble-group-services/models"
+)
+
+// maxCategoryDepth bounds recursive walks so corrupted data cannot loop forever.
+const maxCategoryDepth = 32
+
+var (
+	errCategoryCycle  = errors.New("category cannot be moved under itself or its descendants")
+	errParentNotFound = errors.New("parent category not found")
+)
+
+// categoryDescendantsFilter matches products in the category with the slug at
+// placeholder arg or in any of its subcategories.
+func categoryDescendantsFilter(arg string) string {
+	return ` AND p.category_id IN (
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE slug = ` + arg + `
+			UNION
+			SELECT ch.id FROM categories ch JOIN subtree ON ch.parent_id = subtree.id
+		)
+		SELECT id FROM subtree
+	)`
+}
+
+// CategoryTreeHandler handles GET /products/categories/tree
+func CategoryTreeHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		serveCatalog(w, r, GetCategoryTree, categoryTables...)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetCategoryTree godoc
+// @Summary      Get category tree
+// @Description  Get all categories nested under their parents, each with its breadcrumb path
+// @Tags         categories
+// @Produce      json
+// @Param        If-None-Match  header  string  false  "ETag of a cached copy"
+// @Success      200  {array}  models.CategoryNode
+// @Header       200  {string}  ETag           "Strong validator of the listing"
+// @Header       200  {string}  Last-Modified  "Last catalog change"
+// @Header       200  {string}  Cache-Control  "Configured with CATALOG_CACHE_CONTROL"
+// @Success      304  {string}  string  "Not Modified"
+// @Router       /products/categories/tree [get]
+func GetCategoryTree(w http.ResponseWriter, _ *http.Request) {
+	body, _, err := catalogCache.load("category-tree", func() (interface{}, []string, error) {
+		var categories []models.Category
+		err := db.Select(&categories, `SELECT `+categoryColumns+` FROM categories ORDER BY name, id`)
+		return buildCategoryTree(categories), categoryTables, err
+	})
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONBody(w, body)
+}
+
+// buildCategoryTree nests categories under their parents, keeping the input
+// order among siblings. Categories whose parent is missing become roots.
+func buildCategoryTree(categories []models.Category) []*models.CategoryNode {
+	nodes := make(map[string]*models.CategoryNode, len(categories))
+	for _, c := range categories {
+		nodes[c.ID] = &models.CategoryNode{Category: c, Children: []*models.CategoryNode{}}
+	}
+
+	roots := []*models.CategoryNode{}
+	for _, c := range categories {
+		node := nodes[c.ID]
+		if c.ParentID != nil {
+			if parent, ok := nodes[*c.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	var setPaths func(nodes []*models.CategoryNode, parent []models.Breadcrumb)
+	setPaths = func(nodes []*models.CategoryNode, parent []models.Breadcrumb) {
+		for _, node := range nodes {
+			path := make([]models.Breadcrumb, len(parent), len(parent)+1)
+			copy(path, parent)
+			node.Path = append(path, models.Breadcrumb{ID: node.ID, Name: node.Name, Slug: node.Slug})
+			setPaths(node.Children, node.Path)
+		}
+	}
+	setPaths(roots, nil)
+
+	return roots
+}
+
+// categoryPath returns the breadcrumbs from the root down to the category id.
+func categoryPath(q sqlx.Queryer, id string) ([]models.Breadcrumb, error) {
+	var path []models.Breadcrumb
+	err := sqlx.Select(q, &path, `
+		WITH RECURSIVE path AS (
+			SELECT id, name, slug, parent_id, 0 AS depth FROM categories WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.name, c.slug, c.parent_id, path.depth + 1
+			FROM categories c JOIN path ON c.id = path.parent_id
+			WHERE path.depth < $2
+		)
+		SELECT id, name, slug FROM path ORDER BY depth DESC
+	`, id, maxCategoryDepth)
+	return path, err
+}
+
+// checkCategoryParent verifies that parentID exists and that making it the
+// parent of id would not create a cycle.
+func checkCategoryParent(tx *sqlx.Tx, id, parentID string) error {
+	if parentID == id {
+		return errCategoryCycle
+	}
+
+	ancestors, err := categoryPath(tx, parentID)
+	if err != nil {
+		return err
+	}
+	if len(ancestors) == 0 {
+		return errParentNotFound
+	}
+	for _, a := range ancestors {
+		if a.ID == id {
+			return errCategoryCycle
+		}
+	}
+	return nil
+}
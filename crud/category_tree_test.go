@@ -0,0 +1,141 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"noble-group-services/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ================== Category Tree Unit Tests ==================
+
+func TestBuildCategoryTree(t *testing.T) {
+	c1, c2 := "c1", "c2"
+	missing := "missing"
+	categories := []models.Category{
+		{ID: "c1", Name: "Электроника", Slug: "elektronika"},
+		{ID: "c2", Name: "Смартфоны", Slug: "smartfony", ParentID: &c1},
+		{ID: "c3", Name: "Чехлы", Slug: "chehly", ParentID: &c2},
+		{ID: "c4", Name: "Ноутбуки", Slug: "noutbuki", ParentID: &c1},
+		{ID: "c5", Name: "Сироты", Slug: "siroty", ParentID: &missing},
+	}
+
+	roots := buildCategoryTree(categories)
+
+	require.Len(t, roots, 2)
+	assert.Equal(t, "c1", roots[0].ID)
+	assert.Equal(t, "c5", roots[1].ID)
+
+	require.Len(t, roots[0].Children, 2)
+	assert.Equal(t, "c2", roots[0].Children[0].ID)
+	assert.Equal(t, "c4", roots[0].Children[1].ID)
+
+	leaf := roots[0].Children[0].Children[0]
+	assert.Equal(t, "c3", leaf.ID)
+	assert.Empty(t, leaf.Children)
+	assert.Equal(t, []models.Breadcrumb{
+		{ID: "c1", Name: "Электроника", Slug: "elektronika"},
+		{ID: "c2", Name: "Смартфоны", Slug: "smartfony"},
+		{ID: "c3", Name: "Чехлы", Slug: "chehly"},
+	}, leaf.Path)
+	assert.Len(t, roots[0].Children[1].Path, 2)
+}
+
+func TestCategoryTreeHandler_Get(t *testing.T) {
+	setupTestDB(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/products/categories/tree", nil)
+	w := httptest.NewRecorder()
+
+	CategoryTreeHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var roots []models.CategoryNode
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&roots))
+	for _, root := range roots {
+		assert.Len(t, root.Path, 1)
+	}
+}
+
+func TestCategoryItemHandler_UpdateRejectsCycle(t *testing.T) {
+	setupTestDB(t)
+
+	create := func(c models.Category) models.Category {
+		body, _ := json.Marshal(c)
+		req := httptest.NewRequest(http.MethodPost, "/products/categories", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		CategoriesHandler(w, req)
+		require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+
+		var created models.Category
+		json.NewDecoder(w.Body).Decode(&created)
+		return created
+	}
+
+	parent := create(models.Category{Name: "Cycle Parent", Slug: "cycle-parent"})
+	defer db.Exec("DELETE FROM categories WHERE id = $1", parent.ID)
+	child := create(models.Category{Name: "Cycle Child", Slug: "cycle-child", ParentID: &parent.ID})
+	defer db.Exec("DELETE FROM categories WHERE id = $1", child.ID)
+
+	// Parent under its own child
+	parent.ParentID = &child.ID
+	body, _ := json.Marshal(parent)
+	req := httptest.NewRequest(http.MethodPut, "/products/categories/"+parent.ID, bytes.NewBuffer(body))
+	req.Header.Set("If-Match", versionETag(parent.Version))
+	w := httptest.NewRecorder()
+	CategoryItemHandler(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Parent under itself
+	parent.ParentID = &parent.ID
+	body, _ = json.Marshal(parent)
+	req = httptest.NewRequest(http.MethodPut, "/products/categories/"+parent.ID, bytes.NewBuffer(body))
+	req.Header.Set("If-Match", versionETag(parent.Version))
+	w = httptest.NewRecorder()
+	CategoryItemHandler(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Breadcrumbs of the child
+	req = httptest.NewRequest(http.MethodGet, "/products/categories/"+child.ID, nil)
+	w = httptest.NewRecorder()
+	CategoryItemHandler(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var fetched models.Category
+	json.NewDecoder(w.Body).Decode(&fetched)
+	require.Len(t, fetched.Path, 2)
+	assert.Equal(t, parent.ID, fetched.Path[0].ID)
+	assert.Equal(t, child.ID, fetched.Path[1].ID)
+}
+
+func TestProductsHandler_CategoryIncludesDescendants(t *testing.T) {
+	setupTestDB(t)
+
+	// elektronika is the parent of smartfony in the sample data
+	req := httptest.NewRequest(http.MethodGet, "/products?category=elektronika&limit=100", nil)
+	w := httptest.NewRecorder()
+	ProductsHandler(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var products []models.Product
+	json.NewDecoder(w.Body).Decode(&products)
+
+	found := false
+	for _, p := range products {
+		if p.Category.Slug == "smartfony" {
+			found = true
+			break
+		}
+	}
+	if len(products) == 0 {
+		t.Skip("No sample products in database")
+	}
+	assert.True(t, found, "expected products from the smartfony subcategory")
+}
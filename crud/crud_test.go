@@ -27,6 +27,8 @@ func setupTestDB(t *testing.T) {
 		}
 		SetDB(core.DB)
 	}
+	// Tests clean up with raw SQL, which only reaches the cache through NOTIFY.
+	catalogCache.purge()
 }
 
 // ================== Categories Unit Tests ==================
@@ -164,6 +166,7 @@ func TestCategoryItemHandler_Update(t *testing.T) {
 	created.Name = "After Update"
 	updateBody, _ := json.Marshal(created)
 	updateReq := httptest.NewRequest(http.MethodPut, "/products/categories/"+created.ID, bytes.NewBuffer(updateBody))
+	updateReq.Header.Set("If-Match", versionETag(created.Version))
 	updateW := httptest.NewRecorder()
 	CategoryItemHandler(updateW, updateReq)
 
@@ -192,6 +195,7 @@ func TestCategoryItemHandler_Delete(t *testing.T) {
 
 	// Delete
 	deleteReq := httptest.NewRequest(http.MethodDelete, "/products/categories/"+created.ID, nil)
+	deleteReq.Header.Set("If-Match", versionETag(created.Version))
 	deleteW := httptest.NewRecorder()
 	CategoryItemHandler(deleteW, deleteReq)
 
@@ -283,12 +287,14 @@ func TestManufacturerItemHandler_CRUD(t *testing.T) {
 	created.Name = "CRUD Test Updated"
 	updateBody, _ := json.Marshal(created)
 	updateReq := httptest.NewRequest(http.MethodPut, "/products/manufacturers/"+created.ID, bytes.NewBuffer(updateBody))
+	updateReq.Header.Set("If-Match", versionETag(created.Version))
 	updateW := httptest.NewRecorder()
 	ManufacturerItemHandler(updateW, updateReq)
 	assert.Equal(t, http.StatusOK, updateW.Code)
 
 	// Delete
 	deleteReq := httptest.NewRequest(http.MethodDelete, "/products/manufacturers/"+created.ID, nil)
+	deleteReq.Header.Set("If-Match", updateW.Header().Get("ETag"))
 	deleteW := httptest.NewRecorder()
 	ManufacturerItemHandler(deleteW, deleteReq)
 	assert.Equal(t, http.StatusNoContent, deleteW.Code)
@@ -324,6 +330,14 @@ func TestProductsHandler_GetWithFilters(t *testing.T) {
 		{"with inStockOnly", "?inStockOnly=true"},
 		{"with pagination", "?page=1&limit=10"},
 		{"with all filters", "?category=smartfony&manufacturer=apple&search=iphone&inStockOnly=true&page=1&limit=5"},
+		{"sorted by price asc", "?sort=price_asc"},
+		{"sorted by price desc", "?sort=price_desc"},
+		{"sorted by rating", "?sort=rating"},
+		{"sorted by newest", "?sort=newest"},
+		{"sorted by popularity", "?sort=popularity"},
+		{"sorted by discount", "?sort=discount"},
+		{"sorted by relevance", "?search=pro&sort=relevance"},
+		{"relevance without search", "?sort=relevance"},
 	}
 
 	for _, tt := range tests {
@@ -336,6 +350,17 @@ func TestProductsHandler_GetWithFilters(t *testing.T) {
 	}
 }
 
+func TestProductsHandler_GetInvalidSort(t *testing.T) {
+	setupTestDB(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/products?sort=cheapest", nil)
+	w := httptest.NewRecorder()
+
+	ProductsHandler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestProductsHandler_Post_ValidData(t *testing.T) {
 	setupTestDB(t)
 
@@ -389,6 +414,55 @@ func TestProductsHandler_Post_MissingRequiredFields(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestAvailabilityError(t *testing.T) {
+	assert.Empty(t, availabilityError(""))
+	assert.Empty(t, availabilityError(models.AvailabilityBackorder))
+	assert.NotEmpty(t, availabilityError("soon"))
+}
+
+func TestProductsHandler_Post_DerivedAvailability(t *testing.T) {
+	setupTestDB(t)
+
+	var ref models.Product
+	require.NoError(t, db.Get(&ref, "SELECT * FROM products ORDER BY id LIMIT 1"))
+
+	post := func(p models.Product) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(p)
+		w := httptest.NewRecorder()
+		ProductsHandler(w, httptest.NewRequest(http.MethodPost, "/products", bytes.NewBuffer(body)))
+		return w
+	}
+	product := models.Product{
+		Name: "Backorder Product", ManufacturerID: ref.ManufacturerID, CategoryID: ref.CategoryID,
+		Price: 100, SKU: "TEST-BACKORDER-SKU", Availability: "soon", AllowBackorder: true, LowStockThreshold: 2,
+	}
+	w := post(product)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "availability")
+
+	product.Availability, product.LowStockThreshold = "", -1
+	w = post(product)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "lowStockThreshold")
+	product.LowStockThreshold = 2
+
+	// A valid availability is replaced by the one derived from stock
+	product.Availability = models.AvailabilityInStock
+	w = post(product)
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+	var created models.Product
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	defer db.Exec("DELETE FROM products WHERE id = $1", created.ID)
+	assert.Equal(t, models.AvailabilityBackorder, created.Availability)
+	assert.True(t, created.Orderable())
+
+	var availability string
+	require.NoError(t, db.Get(&availability, "UPDATE products SET stock = 2 WHERE id = $1 RETURNING availability", created.ID))
+	assert.Equal(t, models.AvailabilityLowStock, availability)
+	require.NoError(t, db.Get(&availability, "UPDATE products SET stock = 0, allow_backorder = FALSE WHERE id = $1 RETURNING availability", created.ID))
+	assert.Equal(t, models.AvailabilityOutOfStock, availability)
+}
+
 func TestProductItemHandler_Get(t *testing.T) {
 	setupTestDB(t)
 
@@ -421,3 +495,79 @@ func TestProductItemHandler_GetNotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
+
+func TestProductItemHandler_Patch(t *testing.T) {
+	setupTestDB(t)
+
+	var p models.Product
+	err := db.Get(&p, "SELECT * FROM products LIMIT 1")
+	if err != nil {
+		t.Skip("No products in database")
+	}
+	defer db.Exec("UPDATE products SET price = $1 WHERE id = $2", p.Price, p.ID)
+
+	req := httptest.NewRequest(http.MethodPatch, "/products/"+p.ID, bytes.NewBufferString(`{"price": 100}`))
+	req.Header.Set("If-Match", versionETag(p.Version))
+	w := httptest.NewRecorder()
+
+	ProductItemHandler(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+
+	var patched models.Product
+	json.NewDecoder(w.Body).Decode(&patched)
+	assert.Equal(t, 100, patched.Price)
+	assert.Equal(t, p.Name, patched.Name)
+	assert.Equal(t, p.Slug, patched.Slug)
+	assert.Equal(t, p.SKU, patched.SKU)
+}
+
+func TestProductItemHandler_PutMissingFields(t *testing.T) {
+	setupTestDB(t)
+
+	var p models.Product
+	err := db.Get(&p, "SELECT id FROM products LIMIT 1")
+	if err != nil {
+		t.Skip("No products in database")
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/products/"+p.ID, bytes.NewBufferString(`{"price": 100}`))
+	req.Header.Set("If-Match", "*")
+	w := httptest.NewRecorder()
+
+	ProductItemHandler(w, req)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp ValidationErrorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "VALIDATION_ERROR", resp.Error)
+	assert.NotEmpty(t, resp.Details)
+}
+
+func TestManufacturerItemHandler_PatchNullClearsLogo(t *testing.T) {
+	setupTestDB(t)
+
+	logo := "logo.png"
+	manufacturer := models.Manufacturer{Name: "Patch Test", Slug: "patch-test-mfr", Logo: &logo}
+	body, _ := json.Marshal(manufacturer)
+	createReq := httptest.NewRequest(http.MethodPost, "/products/manufacturers", bytes.NewBuffer(body))
+	createW := httptest.NewRecorder()
+	ManufacturersHandler(createW, createReq)
+	require.Equal(t, http.StatusCreated, createW.Code)
+
+	var created models.Manufacturer
+	json.NewDecoder(createW.Body).Decode(&created)
+	defer db.Exec("DELETE FROM manufacturers WHERE id = $1", created.ID)
+
+	req := httptest.NewRequest(http.MethodPatch, "/products/manufacturers/"+created.ID, bytes.NewBufferString(`{"logo": null}`))
+	req.Header.Set("If-Match", versionETag(created.Version))
+	w := httptest.NewRecorder()
+	ManufacturerItemHandler(w, req)
+	require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+
+	var patched models.Manufacturer
+	json.NewDecoder(w.Body).Decode(&patched)
+	assert.Equal(t, "Patch Test", patched.Name)
+	assert.Nil(t, patched.Logo)
+}
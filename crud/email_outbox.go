@@ -0,0 +1,299 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"noble-group-services/models"
+	"noble-group-services/services/emails"
+	"noble-group-services/services/mailer"
+)
+
+const (
+	// emailOutboxBatch bounds the emails sent in one transaction.
+	emailOutboxBatch = 20
+	// emailOutboxInterval is how often the outbox is checked for due
+	// retries and for emails queued by other replicas.
+	emailOutboxInterval = time.Minute
+	// emailRetryBase is the delay after the first failed attempt. It
+	// doubles with every further failure up to emailRetryMax.
+	emailRetryBase = time.Minute
+	emailRetryMax  = 6 * time.Hour
+)
+
+// emailMaxAttempts is the number of failed attempts after which an email
+// is dead, see SetEmailMaxAttempts.
+var emailMaxAttempts = 8
+
+// SetEmailMaxAttempts sets the number of failed attempts after which an
+// email is no longer retried. Non-positive values keep the default of 8.
+func SetEmailMaxAttempts(n int) {
+	if n > 0 {
+		emailMaxAttempts = n
+	}
+}
+
+// emailOutboxWake wakes the outbox worker after an email was queued. It
+// holds at most one pending wake-up.
+var emailOutboxWake = make(chan struct{}, 1)
+
+// wakeEmailOutbox asks the worker to send due emails. Call it after the
+// transaction that queued them commits.
+func wakeEmailOutbox() {
+	select {
+	case emailOutboxWake <- struct{}{}:
+	default:
+	}
+}
+
+// queueEmail adds an email to the outbox. Queue it in the transaction of the
+// change it reports, so that it is sent if and only if the change commits.
+func queueEmail(tx sqlx.Execer, kind, to string, msg emails.Message, orderID *string) error {
+	_, err := tx.Exec(`
+		INSERT INTO email_outbox (id, kind, recipient, subject, text_body, html_body, headers, order_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, uuid.New().String(), kind, to, msg.Subject, msg.Text, msg.HTML, models.EmailHeaders(msg.Headers), orderID)
+	return err
+}
+
+// RunEmailOutbox sends the queued emails whenever one is queued, and every
+// emailOutboxInterval, until ctx is done.
+func RunEmailOutbox(ctx context.Context, m mailer.Mailer) {
+	ticker := time.NewTicker(emailOutboxInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := deliverEmails(ctx, m); err != nil {
+			log.Printf("Email outbox: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-emailOutboxWake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverEmails sends every due email. Failed emails are retried after
+// emailRetryDelay, and are dead after emailMaxAttempts. It returns the
+// number of emails sent.
+func deliverEmails(ctx context.Context, m mailer.Mailer) (int, error) {
+	sent := 0
+	for {
+		n, full, err := deliverEmailBatch(ctx, m)
+		sent += n
+		if err != nil || !full {
+			return sent, err
+		}
+	}
+}
+
+// deliverEmailBatch claims up to emailOutboxBatch due emails, skipping those
+// another replica is sending, and sends them. An email whose outcome could
+// not be recorded is sent again.
+func deliverEmailBatch(ctx context.Context, m mailer.Mailer) (sent int, full bool, err error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	var due []models.OutboxEmail
+	err = tx.Select(&due, `
+		SELECT * FROM email_outbox
+		WHERE status = 'pending' AND next_attempt_at <= NOW()
+		ORDER BY next_attempt_at, id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, emailOutboxBatch)
+	if err != nil {
+		return 0, false, err
+	}
+
+	for _, e := range due {
+		sendErr := m.Send(ctx, mailer.Message{To: []string{e.Recipient}, Subject: e.Subject, Text: e.TextBody, HTML: e.HTMLBody, Headers: e.Headers})
+		if sendErr == nil {
+			_, err = tx.Exec(`
+				UPDATE email_outbox SET status = 'sent', attempts = attempts + 1, last_error = NULL, sent_at = NOW()
+				WHERE id = $1
+			`, e.ID)
+			sent++
+		} else {
+			attempts := e.Attempts + 1
+			status := models.EmailPending
+			if attempts >= emailMaxAttempts {
+				status = models.EmailDead
+				log.Printf("Email %s to %s is dead after %d attempts: %v", e.ID, e.Recipient, attempts, sendErr)
+			}
+			// The delay is added on the database clock next_attempt_at is
+			// compared with
+			_, err = tx.Exec(`
+				UPDATE email_outbox SET status = $2, attempts = $3, last_error = $4,
+					next_attempt_at = NOW() + make_interval(secs => $5)
+				WHERE id = $1
+			`, e.ID, status, attempts, sendErr.Error(), emailRetryDelay(attempts).Seconds())
+		}
+		if err != nil {
+			return 0, false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, err
+	}
+	return sent, len(due) == emailOutboxBatch, nil
+}
+
+// emailRetryDelay is the delay before retrying an email that failed the
+// given number of attempts.
+func emailRetryDelay(attempts int) time.Duration {
+	delay := emailRetryBase
+	for i := 1; i < attempts && delay < emailRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, emailRetryMax)
+}
+
+// EmailsAdminHandler handles GET /admin/emails
+func EmailsAdminHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		GetEmails(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// EmailAdminItemHandler handles GET /admin/emails/{id} and
+// POST /admin/emails/{id}/resend
+func EmailAdminItemHandler(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && !strings.HasSuffix(r.URL.Path, "/resend"):
+		GetEmail(w, r)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/resend"):
+		ResendEmail(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetEmails godoc
+// @Summary List outgoing emails
+// @Description Get queued, sent and dead customer emails, newest first.
+// @Tags admin
+// @Produce json
+// @Param status query string false "Email status" Enums(pending, sent, dead)
+// @Param orderId query string false "Order ID"
+// @Param recipient query string false "Recipient address"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Keyset cursor"
+// @Success 200 {object} PageResponse[models.OutboxEmail]
+// @Failure 400 {string} string "Invalid cursor"
+// @Router /admin/emails [get]
+func GetEmails(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	lq := listQuery{
+		Columns: `id, kind, recipient, subject, text_body, html_body, order_id, status, attempts,
+			next_attempt_at, last_error, created_at, sent_at`,
+		From: `email_outbox`,
+		Sort: "newest",
+		Keys: []sortKey{
+			{Expr: "created_at", Type: "timestamp", Desc: true},
+			{Expr: "id", Type: "text", Desc: true},
+		},
+	}
+	if v := query.Get("status"); v != "" {
+		lq.Where += ` AND status = ` + lq.Args.add(v)
+	}
+	if v := query.Get("orderId"); v != "" {
+		lq.Where += ` AND order_id = ` + lq.Args.add(v)
+	}
+	if v := query.Get("recipient"); v != "" {
+		lq.Where += ` AND LOWER(recipient) = LOWER(` + lq.Args.add(v) + `)`
+	}
+
+	page, err := fetchPage(lq, parsePageParams(query), func(row outboxEmailRow) models.OutboxEmail {
+		return row.OutboxEmail
+	})
+	writePage(w, page, err)
+}
+
+// outboxEmailRow is an outbox listing row together with its keyset sort key.
+type outboxEmailRow struct {
+	models.OutboxEmail
+	SortKey string `db:"sort_key"`
+}
+
+func (r outboxEmailRow) sortKey() string { return r.SortKey }
+
+// GetEmail godoc
+// @Summary Get an outgoing email
+// @Tags admin
+// @Produce json
+// @Param id path string true "Email ID"
+// @Success 200 {object} models.OutboxEmail
+// @Failure 404 {string} string "Email not found"
+// @Router /admin/emails/{id} [get]
+func GetEmail(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/admin/emails/")
+
+	var e models.OutboxEmail
+	err := db.Get(&e, `SELECT * FROM email_outbox WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Email not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e)
+}
+
+// ResendEmail godoc
+// @Summary Resend an email
+// @Description Queue an email for immediate delivery with a fresh set of attempts. Dead emails are retried; sent emails are sent again.
+// @Tags admin
+// @Produce json
+// @Param id path string true "Email ID"
+// @Success 200 {object} models.OutboxEmail
+// @Failure 404 {string} string "Email not found"
+// @Router /admin/emails/{id}/resend [post]
+func ResendEmail(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/admin/emails/"), "/resend")
+
+	var e models.OutboxEmail
+	err := db.Get(&e, `
+		UPDATE email_outbox
+		SET status = 'pending', attempts = 0, next_attempt_at = NOW(), last_error = NULL, sent_at = NULL
+		WHERE id = $1
+		RETURNING *
+	`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Email not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	wakeEmailOutbox()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e)
+}
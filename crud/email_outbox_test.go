@@ -0,0 +1,113 @@
+package crud
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"noble-group-services/models"
+	"noble-group-services/services/emails"
+	"noble-group-services/services/mailer"
+	"noble-group-services/services/mailer/smtptest"
+)
+
+func TestEmailRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Minute, emailRetryDelay(1))
+	assert.Equal(t, 2*time.Minute, emailRetryDelay(2))
+	assert.Equal(t, 64*time.Minute, emailRetryDelay(7))
+	assert.Equal(t, emailRetryMax, emailRetryDelay(10))
+	assert.Equal(t, emailRetryMax, emailRetryDelay(100))
+}
+
+func TestEmailOutbox(t *testing.T) {
+	setupTestDB(t)
+	defer SetEmailMaxAttempts(emailMaxAttempts)
+	SetEmailMaxAttempts(2)
+
+	server := smtptest.NewServer()
+	defer server.Close()
+	sender := &mailer.SMTPMailer{
+		Sender: mailer.Sender{From: mail.Address{Address: "shop@example.com"}},
+		Host:   server.Host, Port: server.Port, TLS: mailer.TLSNone,
+	}
+	ctx := context.Background()
+
+	recipient := "outbox-" + uuid.New().String()[:8] + "@example.com"
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	require.NoError(t, queueEmail(tx, "test", recipient, emails.Message{Subject: "Тест", Text: "Текст", HTML: "<p>Текст</p>"}, nil))
+	require.NoError(t, tx.Commit())
+	defer db.Exec(`DELETE FROM email_outbox WHERE recipient = $1`, recipient)
+
+	var e models.OutboxEmail
+	load := func() {
+		require.NoError(t, db.Get(&e, `SELECT * FROM email_outbox WHERE recipient = $1`, recipient))
+	}
+	// Retries wait for their backoff; the last failed attempt is dead
+	server.SetFailing(true)
+	_, err = deliverEmails(ctx, sender)
+	require.NoError(t, err)
+	load()
+	assert.Equal(t, models.EmailPending, e.Status)
+	assert.Equal(t, 1, e.Attempts)
+	require.NotNil(t, e.LastError)
+	var delayed bool
+	require.NoError(t, db.Get(&delayed, `SELECT next_attempt_at > NOW() + INTERVAL '50 seconds' FROM email_outbox WHERE id = $1`, e.ID))
+	assert.True(t, delayed)
+
+	_, err = deliverEmails(ctx, sender)
+	require.NoError(t, err)
+	load()
+	assert.Equal(t, 1, e.Attempts)
+
+	_, err = db.Exec(`UPDATE email_outbox SET next_attempt_at = NOW() WHERE id = $1`, e.ID)
+	require.NoError(t, err)
+	_, err = deliverEmails(ctx, sender)
+	require.NoError(t, err)
+	load()
+	assert.Equal(t, models.EmailDead, e.Status)
+	assert.Equal(t, 2, e.Attempts)
+
+	w := httptest.NewRecorder()
+	EmailsAdminHandler(w, httptest.NewRequest(http.MethodGet, "/admin/emails?status=dead&recipient="+recipient, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var page PageResponse[models.OutboxEmail]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, e.ID, page.Items[0].ID)
+
+	// Resending a dead email delivers it once the server is back
+	server.SetFailing(false)
+	w = httptest.NewRecorder()
+	EmailAdminItemHandler(w, httptest.NewRequest(http.MethodPost, "/admin/emails/"+e.ID+"/resend", nil))
+	require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+	_, err = deliverEmails(ctx, sender)
+	require.NoError(t, err)
+	load()
+	assert.Equal(t, models.EmailSent, e.Status)
+	assert.Nil(t, e.LastError)
+	assert.NotNil(t, e.SentAt)
+
+	var delivered []smtptest.Message
+	for _, m := range server.Messages() {
+		if m.To[0] == recipient {
+			delivered = append(delivered, m)
+		}
+	}
+	assert.Len(t, delivered, 1)
+
+	w = httptest.NewRecorder()
+	EmailAdminItemHandler(w, httptest.NewRequest(http.MethodGet, "/admin/emails/"+e.ID, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = httptest.NewRecorder()
+	EmailAdminItemHandler(w, httptest.NewRequest(http.MethodPost, "/admin/emails/missing/resend", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
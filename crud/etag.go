@@ -0,0 +1,94 @@
+package crud
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	errPreconditionRequired = errors.New("If-Match header required")
+	errPreconditionFailed   = errors.New("resource was modified; reload and retry")
+)
+
+// versionETag is the strong entity tag of a row at version.
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// setVersionETag sets the ETag header of a single resource response.
+func setVersionETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", versionETag(version))
+}
+
+// checkIfMatch verifies the request's If-Match header against the current
+// version of a row. Writes must name the version they were based on; "*"
+// matches any existing row. Weak tags never match.
+func checkIfMatch(r *http.Request, version int) error {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return errPreconditionRequired
+	}
+	if header == "*" {
+		return nil
+	}
+
+	current := versionETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == current {
+			return nil
+		}
+	}
+	return errPreconditionFailed
+}
+
+// writePreconditionError reports a failed If-Match check as 428 or 412.
+func writePreconditionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errPreconditionRequired) {
+		http.Error(w, err.Error(), http.StatusPreconditionRequired)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusPreconditionFailed)
+}
+
+// deleteVersioned deletes row id of table once the request's If-Match header
+// matches the row's current version. table must be a fixed identifier.
+func deleteVersioned(w http.ResponseWriter, r *http.Request, table, id string) {
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var version int
+	err = tx.Get(&version, `SELECT version FROM `+table+` WHERE id = $1 FOR UPDATE`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := checkIfMatch(r, version); err != nil {
+		writePreconditionError(w, err)
+		return
+	}
+
+	if _, err := tx.Exec(`DELETE FROM `+table+` WHERE id = $1`, id); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	catalogCache.invalidate(table, id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,66 @@
+package crud
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"noble-group-services/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    error
+	}{
+		{"missing", "", errPreconditionRequired},
+		{"current", `"3"`, nil},
+		{"any", "*", nil},
+		{"list", `"2", "3"`, nil},
+		{"stale", `"2"`, errPreconditionFailed},
+		{"weak", `W/"3"`, errPreconditionFailed},
+		{"unquoted", `3`, errPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/products/p1", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			assert.Equal(t, tt.want, checkIfMatch(req, 3))
+		})
+	}
+}
+
+func TestProductItemHandler_Preconditions(t *testing.T) {
+	setupTestDB(t)
+
+	var p models.Product
+	if err := db.Get(&p, "SELECT * FROM products LIMIT 1"); err != nil {
+		t.Skip("No products in database")
+	}
+
+	// GET exposes the version
+	req := httptest.NewRequest(http.MethodGet, "/products/"+p.ID, nil)
+	w := httptest.NewRecorder()
+	ProductItemHandler(w, req)
+	assert.Equal(t, versionETag(p.Version), w.Header().Get("ETag"))
+
+	// Writes without If-Match are refused
+	req = httptest.NewRequest(http.MethodPatch, "/products/"+p.ID, bytes.NewBufferString(`{}`))
+	w = httptest.NewRecorder()
+	ProductItemHandler(w, req)
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+
+	// Writes based on another version are refused
+	req = httptest.NewRequest(http.MethodDelete, "/products/"+p.ID, nil)
+	req.Header.Set("If-Match", versionETag(p.Version+1))
+	w = httptest.NewRecorder()
+	ProductItemHandler(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+}
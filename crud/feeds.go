@@ -0,0 +1,411 @@
+package crud
+
+import (
+	"bufio"
+	"encoding/xml"
+	"hash/fnv"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"noble-group-services/models"
+)
+
+// FeedConfig describes the shop in marketplace feeds.
+type FeedConfig struct {
+	ShopName string
+	Company  string
+	Currency string
+	// KaspiMerchantID and KaspiStoreID identify the merchant and the pickup
+	// point in the Kaspi feed.
+	KaspiMerchantID string
+	KaspiStoreID    string
+	// Dir holds the generated feed files.
+	Dir string
+}
+
+var feedConfig = FeedConfig{
+	ShopName:     "Noble Group",
+	Company:      "Noble Group",
+	Currency:     "KZT",
+	KaspiStoreID: "PP1",
+	Dir:          filepath.Join(os.TempDir(), "noble-feeds"),
+}
+
+// SetFeedConfig overrides the non-empty fields of the feed configuration.
+// It must be called before the server starts.
+func SetFeedConfig(cfg FeedConfig) {
+	for dst, src := range map[*string]string{
+		&feedConfig.ShopName:        cfg.ShopName,
+		&feedConfig.Company:         cfg.Company,
+		&feedConfig.Currency:        cfg.Currency,
+		&feedConfig.KaspiMerchantID: cfg.KaspiMerchantID,
+		&feedConfig.KaspiStoreID:    cfg.KaspiStoreID,
+		&feedConfig.Dir:             cfg.Dir,
+	} {
+		if src != "" {
+			*dst = src
+		}
+	}
+}
+
+// feedData is what a feed is generated from.
+type feedData struct {
+	Date       time.Time
+	Site       StorefrontConfig
+	Categories []models.Category
+	Products   productIterator
+}
+
+// feedFile is a feed generated to disk and kept until the catalog changes.
+// Generation streams products from the database into the file, and requests
+// stream the file, so neither holds the catalog in memory.
+type feedFile struct {
+	name  string
+	write func(w io.Writer, cfg FeedConfig, data feedData) error
+
+	mu      sync.Mutex
+	version int64
+	ready   bool
+}
+
+var (
+	ymlFeed   = &feedFile{name: "yml.xml", write: writeYMLFeed}
+	kaspiFeed = &feedFile{name: "kaspi.xml", write: writeKaspiFeed}
+)
+
+// open returns the feed file for the catalog at version, regenerating it when
+// it was built from an older catalog. The lock is held until the file is open,
+// so a concurrent regeneration cannot replace it in between; readers keep
+// their handle on the previous file after a rename.
+func (f *feedFile) open(version int64) (*os.File, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := filepath.Join(feedConfig.Dir, f.name)
+	if !f.ready || f.version != version {
+		if err := f.generate(path); err != nil {
+			return nil, err
+		}
+		f.version, f.ready = version, true
+	}
+	return os.Open(path)
+}
+
+// generate writes the feed to a temporary file and moves it over path.
+func (f *feedFile) generate(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), f.name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var categories []models.Category
+	if err := db.Select(&categories, `SELECT id, name, slug, parent_id FROM categories ORDER BY id`); err != nil {
+		return err
+	}
+	rows, err := db.Queryx(`SELECT ` + productListColumns + ` FROM ` + productListFrom + ` ORDER BY p.id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	bw := bufio.NewWriter(tmp)
+	data := feedData{Date: time.Now(), Site: storefront, Categories: categories, Products: productRows(rows)}
+	if err := f.write(bw, feedConfig, data); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// serve answers a feed request. serveCatalog has already answered conditional
+// requests; the feed is regenerated here when the catalog moved on.
+func (f *feedFile) serve(w http.ResponseWriter, r *http.Request) {
+	state, err := loadCatalogState(productTables)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	file, err := f.open(state.Version)
+	if err != nil {
+		log.Printf("feed %s: %v", f.name, err)
+		http.Error(w, "Feed generation failed", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	if info, err := file.Stat(); err == nil {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	io.Copy(w, file)
+}
+
+// YMLFeedHandler handles GET /feeds/yml.xml
+func YMLFeedHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		serveCatalog(w, r, GetYMLFeed, productTables...)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// KaspiFeedHandler handles GET /feeds/kaspi.xml
+func KaspiFeedHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		serveCatalog(w, r, GetKaspiFeed, productTables...)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetYMLFeed godoc
+// @Summary Yandex Market feed
+// @Description Product feed in the YML format read by Yandex Market and other marketplaces. The feed is regenerated after the catalog changes.
+// @Tags feeds
+// @Produce xml
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {string} string "YML feed"
+// @Header 200 {string} ETag "Strong validator of the feed"
+// @Header 200 {string} Last-Modified "Last catalog change"
+// @Success 304 {string} string "Not Modified"
+// @Router /feeds/yml.xml [get]
+func GetYMLFeed(w http.ResponseWriter, r *http.Request) {
+	ymlFeed.serve(w, r)
+}
+
+// GetKaspiFeed godoc
+// @Summary Kaspi.kz feed
+// @Description Product feed in the Kaspi.kz price list format. The feed is regenerated after the catalog changes.
+// @Tags feeds
+// @Produce xml
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {string} string "Kaspi feed"
+// @Header 200 {string} ETag "Strong validator of the feed"
+// @Header 200 {string} Last-Modified "Last catalog change"
+// @Success 304 {string} string "Not Modified"
+// @Router /feeds/kaspi.xml [get]
+func GetKaspiFeed(w http.ResponseWriter, r *http.Request) {
+	kaspiFeed.serve(w, r)
+}
+
+// feedAvailable reports whether p can be shipped now, matching the
+// inStockOnly listing filter.
+func feedAvailable(p models.Product) bool {
+	return p.InStock()
+}
+
+// feedCategoryID maps a category id to the number YML expects. Hashing keeps
+// the number stable between regenerations.
+func feedCategoryID(id string) string {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return strconv.FormatUint(h.Sum64()%1e17+1, 10)
+}
+
+type ymlCategory struct {
+	XMLName  xml.Name `xml:"category"`
+	ID       string   `xml:"id,attr"`
+	ParentID string   `xml:"parentId,attr,omitempty"`
+	Name     string   `xml:",chardata"`
+}
+
+type ymlOffer struct {
+	XMLName     xml.Name `xml:"offer"`
+	ID          string   `xml:"id,attr"`
+	Available   bool     `xml:"available,attr"`
+	URL         string   `xml:"url"`
+	Price       int      `xml:"price"`
+	OldPrice    *int     `xml:"oldprice,omitempty"`
+	CurrencyID  string   `xml:"currencyId"`
+	CategoryID  string   `xml:"categoryId"`
+	Pictures    []string `xml:"picture"`
+	Name        string   `xml:"name"`
+	Vendor      string   `xml:"vendor,omitempty"`
+	VendorCode  string   `xml:"vendorCode"`
+	Description string   `xml:"description,omitempty"`
+	Count       int      `xml:"count"`
+}
+
+// writeYMLFeed writes a yml_catalog document.
+func writeYMLFeed(w io.Writer, cfg FeedConfig, data feedData) error {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	catalog := xml.StartElement{Name: xml.Name{Local: "yml_catalog"}, Attr: []xml.Attr{
+		{Name: xml.Name{Local: "date"}, Value: data.Date.Format(time.RFC3339)},
+	}}
+	shop := xml.StartElement{Name: xml.Name{Local: "shop"}}
+	if err := encodeTokens(enc, catalog, shop); err != nil {
+		return err
+	}
+	for _, el := range []struct{ name, value string }{
+		{"name", cfg.ShopName}, {"company", cfg.Company}, {"url", data.Site.BaseURL},
+	} {
+		if err := enc.EncodeElement(el.value, xml.StartElement{Name: xml.Name{Local: el.name}}); err != nil {
+			return err
+		}
+	}
+
+	currencies := struct {
+		XMLName  xml.Name `xml:"currencies"`
+		Currency struct {
+			ID   string `xml:"id,attr"`
+			Rate string `xml:"rate,attr"`
+		} `xml:"currency"`
+	}{}
+	currencies.Currency.ID, currencies.Currency.Rate = cfg.Currency, "1"
+	if err := enc.Encode(currencies); err != nil {
+		return err
+	}
+
+	categories := xml.StartElement{Name: xml.Name{Local: "categories"}}
+	if err := encodeTokens(enc, categories); err != nil {
+		return err
+	}
+	for _, c := range data.Categories {
+		yc := ymlCategory{ID: feedCategoryID(c.ID), Name: c.Name}
+		if c.ParentID != nil {
+			yc.ParentID = feedCategoryID(*c.ParentID)
+		}
+		if err := enc.Encode(yc); err != nil {
+			return err
+		}
+	}
+	if err := encodeTokens(enc, categories.End()); err != nil {
+		return err
+	}
+
+	offers := xml.StartElement{Name: xml.Name{Local: "offers"}}
+	if err := encodeTokens(enc, offers); err != nil {
+		return err
+	}
+	for {
+		p, ok, err := data.Products()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		offer := ymlOffer{
+			ID: p.SKU, Available: feedAvailable(p), URL: data.Site.pageURL(data.Site.ProductPath, p.ID, p.Slug),
+			Price: p.Price, CurrencyID: cfg.Currency, CategoryID: feedCategoryID(p.Category.ID),
+			Name: p.Name, Vendor: p.Manufacturer.Name, VendorCode: p.SKU,
+			Description: p.Description, Count: max(p.Stock, 0),
+		}
+		// Marketplaces reject an old price that is not above the price.
+		if p.OldPrice != nil && *p.OldPrice > p.Price {
+			offer.OldPrice = p.OldPrice
+		}
+		for _, img := range p.Image {
+			offer.Pictures = append(offer.Pictures, data.Site.absoluteURL(img))
+		}
+		if err := enc.Encode(offer); err != nil {
+			return err
+		}
+	}
+	if err := encodeTokens(enc, offers.End(), shop.End(), catalog.End()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+type kaspiOffer struct {
+	XMLName        xml.Name            `xml:"offer"`
+	SKU            string              `xml:"sku,attr"`
+	Model          string              `xml:"model"`
+	Brand          string              `xml:"brand,omitempty"`
+	Availabilities []kaspiAvailability `xml:"availabilities>availability"`
+	Price          int                 `xml:"price"`
+}
+
+type kaspiAvailability struct {
+	Available  string `xml:"available,attr"`
+	StoreID    string `xml:"storeId,attr"`
+	StockCount int    `xml:"stockCount,attr"`
+}
+
+// writeKaspiFeed writes a kaspi_catalog document.
+func writeKaspiFeed(w io.Writer, cfg FeedConfig, data feedData) error {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	catalog := xml.StartElement{Name: xml.Name{Local: "kaspi_catalog"}, Attr: []xml.Attr{
+		{Name: xml.Name{Local: "date"}, Value: data.Date.Format("2006-01-02T15:04")},
+		{Name: xml.Name{Local: "xmlns"}, Value: "kaspiShopping"},
+		{Name: xml.Name{Local: "xmlns:xsi"}, Value: "http://www.w3.org/2001/XMLSchema-instance"},
+		{Name: xml.Name{Local: "xsi:schemaLocation"}, Value: "kaspiShopping http://kaspi.kz/kaspishopping.xsd"},
+	}}
+	if err := encodeTokens(enc, catalog); err != nil {
+		return err
+	}
+	for _, el := range []struct{ name, value string }{
+		{"company", cfg.Company}, {"merchantid", cfg.KaspiMerchantID},
+	} {
+		if err := enc.EncodeElement(el.value, xml.StartElement{Name: xml.Name{Local: el.name}}); err != nil {
+			return err
+		}
+	}
+
+	offers := xml.StartElement{Name: xml.Name{Local: "offers"}}
+	if err := encodeTokens(enc, offers); err != nil {
+		return err
+	}
+	for {
+		p, ok, err := data.Products()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		available := "no"
+		if feedAvailable(p) {
+			available = "yes"
+		}
+		offer := kaspiOffer{
+			SKU: p.SKU, Model: p.Name, Brand: p.Manufacturer.Name, Price: p.Price,
+			Availabilities: []kaspiAvailability{{Available: available, StoreID: cfg.KaspiStoreID, StockCount: max(p.Stock, 0)}},
+		}
+		if err := enc.Encode(offer); err != nil {
+			return err
+		}
+	}
+	if err := encodeTokens(enc, offers.End(), catalog.End()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+func encodeTokens(enc *xml.Encoder, tokens ...xml.Token) error {
+	for _, t := range tokens {
+		if err := enc.EncodeToken(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
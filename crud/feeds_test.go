@@ -0,0 +1,140 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"noble-group-services/models"
+)
+
+func feedTestData() feedData {
+	oldPrice, samePrice := 1500, 900
+	parent := "c1"
+	return feedData{
+		Date: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+		Site: storefront,
+		Categories: []models.Category{
+			{ID: "c1", Name: "Кабели"},
+			{ID: "c2", Name: "Силовые", ParentID: &parent},
+		},
+		Products: exportProducts(
+			models.Product{
+				SKU: "A-1", Name: "Кабель <ВВГ> & Co", Slug: "kabel-vvg", Price: 1200, OldPrice: &oldPrice,
+				Image: models.JSONStringArray{"/img/a.jpg", "https://cdn.example.com/b.jpg"},
+				Stock: 3, Availability: "in_stock",
+				Manufacturer: models.Manufacturer{Name: "KZ Kabel"}, Category: models.Category{ID: "c2"},
+			},
+			models.Product{
+				SKU: "A-2", Name: "Провод", Slug: "provod", Price: 900, OldPrice: &samePrice,
+				Stock: 0, Availability: models.AvailabilityExpected, Category: models.Category{ID: "c1"},
+			},
+		),
+	}
+}
+
+func TestWriteYMLFeed(t *testing.T) {
+	data := feedTestData()
+	data.Site.BaseURL = "https://noble.kz"
+
+	var buf bytes.Buffer
+	require.NoError(t, writeYMLFeed(&buf, feedConfig, data))
+
+	var doc struct {
+		Date string `xml:"date,attr"`
+		Shop struct {
+			URL        string        `xml:"url"`
+			Categories []ymlCategory `xml:"categories>category"`
+			Offers     []ymlOffer    `xml:"offers>offer"`
+		} `xml:"shop"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc), buf.String())
+
+	assert.Equal(t, "2026-10-18T12:00:00Z", doc.Date)
+	require.Len(t, doc.Shop.Categories, 2)
+	assert.Equal(t, doc.Shop.Categories[0].ID, doc.Shop.Categories[1].ParentID)
+
+	require.Len(t, doc.Shop.Offers, 2)
+	offer := doc.Shop.Offers[0]
+	assert.True(t, offer.Available)
+	assert.Equal(t, "Кабель <ВВГ> & Co", offer.Name)
+	assert.Equal(t, "https://noble.kz/products/kabel-vvg", offer.URL)
+	assert.Equal(t, []string{"https://noble.kz/img/a.jpg", "https://cdn.example.com/b.jpg"}, offer.Pictures)
+	assert.Equal(t, 1500, *offer.OldPrice)
+	assert.Equal(t, doc.Shop.Categories[1].ID, offer.CategoryID)
+
+	// Out of stock, and an old price that is not a discount is left out
+	assert.False(t, doc.Shop.Offers[1].Available)
+	assert.Nil(t, doc.Shop.Offers[1].OldPrice)
+}
+
+func TestWriteKaspiFeed(t *testing.T) {
+	cfg := feedConfig
+	cfg.KaspiMerchantID = "Noble"
+
+	var buf bytes.Buffer
+	require.NoError(t, writeKaspiFeed(&buf, cfg, feedTestData()))
+
+	var doc struct {
+		XMLName    xml.Name     `xml:"kaspiShopping kaspi_catalog"`
+		MerchantID string       `xml:"merchantid"`
+		Offers     []kaspiOffer `xml:"offers>offer"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc), buf.String())
+
+	assert.Equal(t, "Noble", doc.MerchantID)
+	require.Len(t, doc.Offers, 2)
+	assert.Equal(t, "A-1", doc.Offers[0].SKU)
+	assert.Equal(t, "KZ Kabel", doc.Offers[0].Brand)
+	assert.Equal(t, []kaspiAvailability{{Available: "yes", StoreID: "PP1", StockCount: 3}}, doc.Offers[0].Availabilities)
+	assert.Equal(t, "no", doc.Offers[1].Availabilities[0].Available)
+}
+
+func TestYMLFeedHandler(t *testing.T) {
+	setupTestDB(t)
+	SetFeedConfig(FeedConfig{Dir: t.TempDir()})
+
+	w := httptest.NewRecorder()
+	YMLFeedHandler(w, httptest.NewRequest(http.MethodGet, "/feeds/yml.xml", nil))
+	require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+	assert.Contains(t, w.Body.String(), "<yml_catalog")
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req := httptest.NewRequest(http.MethodGet, "/feeds/yml.xml", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	YMLFeedHandler(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	// A catalog change regenerates the feed
+	var p models.Product
+	require.NoError(t, db.Get(&p, `SELECT * FROM products ORDER BY id LIMIT 1`))
+	_, err := db.Exec(`UPDATE products SET name = name || ' (feed)' WHERE id = $1`, p.ID)
+	require.NoError(t, err)
+	defer db.Exec(`UPDATE products SET name = $2 WHERE id = $1`, p.ID, p.Name)
+
+	req = httptest.NewRequest(http.MethodGet, "/feeds/yml.xml", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	YMLFeedHandler(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), p.Name+" (feed)")
+}
+
+func TestStorefrontAbsoluteURL(t *testing.T) {
+	defer SetAPIBaseURL(apiBaseURL)
+	require.NoError(t, SetAPIBaseURL("https://api.noble.kz"))
+	s := StorefrontConfig{BaseURL: "https://noble.kz"}
+
+	assert.Equal(t, "https://cdn.noble.kz/a.jpg", s.absoluteURL("https://cdn.noble.kz/a.jpg"))
+	assert.Equal(t, "https://noble.kz/images/a.jpg", s.absoluteURL("/images/a.jpg"))
+	// Uploads stored with relative URLs are served by the API
+	assert.Equal(t, "https://api.noble.kz/media/2026/10/a.jpg", s.absoluteURL("/media/2026/10/a.jpg"))
+}
@@ -0,0 +1,135 @@
+package crud
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// catalogCacheControl is sent with cacheable catalog responses.
+var catalogCacheControl = "public, max-age=60"
+
+// SetCatalogCacheControl sets the Cache-Control header of catalog responses,
+// e.g. "public, max-age=300, stale-while-revalidate=60" behind a CDN.
+func SetCatalogCacheControl(value string) {
+	catalogCacheControl = value
+}
+
+// Tables a catalog response is built from, see models/schema_catalog_versions.sql.
+var (
+	productTables      = []string{"products", "categories", "manufacturers"}
+	categoryTables     = []string{"categories"}
+	manufacturerTables = []string{"manufacturers"}
+)
+
+// productListTables returns the tables a product listing depends on. The
+// popularity order also depends on orders, which are not versioned, so those
+// listings get no validators.
+func productListTables(r *http.Request) []string {
+	if r.URL.Query().Get("sort") == "popularity" {
+		return nil
+	}
+	return productTables
+}
+
+// catalogState sums the change counters of a set of catalog tables.
+type catalogState struct {
+	Version   int64     `db:"version"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func loadCatalogState(tables []string) (catalogState, error) {
+	var state catalogState
+	err := db.Get(&state, `
+		SELECT COALESCE(SUM(version), 0) AS version, COALESCE(MAX(updated_at), 'epoch') AS updated_at
+		FROM catalog_versions WHERE table_name = ANY($1)
+	`, tables)
+	return state, err
+}
+
+// catalogETag is a strong entity tag for the response to r at state. The
+// representation is fully determined by the request and the table contents.
+func catalogETag(r *http.Request, state catalogState) string {
+	sum := sha256.Sum256([]byte(strconv.FormatInt(state.Version, 10) + "\n" + r.URL.Path + "?" + r.URL.RawQuery))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagListMatches reports whether an If-None-Match header names etag, using
+// the weak comparison RFC 9110 requires for If-None-Match.
+func etagListMatches(header, etag string) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// serveCatalog serves a catalog GET through get, adding Cache-Control and,
+// when tables is not empty, ETag and Last-Modified validators. Requests whose
+// If-None-Match or If-Modified-Since still match get 304 without running get.
+//
+// The state is read before get runs, so a concurrent change can only make the
+// validators older than the body, never newer.
+func serveCatalog(w http.ResponseWriter, r *http.Request, get http.HandlerFunc, tables ...string) {
+	w.Header().Set("Cache-Control", catalogCacheControl)
+	if len(tables) == 0 {
+		get(&catalogResponseWriter{ResponseWriter: w}, r)
+		return
+	}
+
+	state, err := loadCatalogState(tables)
+	if err != nil {
+		// Serve without validators rather than fail the request.
+		get(&catalogResponseWriter{ResponseWriter: w}, r)
+		return
+	}
+
+	etag := catalogETag(r, state)
+	lastModified := state.UpdatedAt.UTC().Truncate(time.Second)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etagListMatches(inm, etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	} else if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !lastModified.After(ims) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	get(&catalogResponseWriter{ResponseWriter: w}, r)
+}
+
+// catalogResponseWriter drops the caching headers of error responses so that
+// a CDN never stores them.
+type catalogResponseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (cw *catalogResponseWriter) WriteHeader(code int) {
+	if !cw.wroteHeader && code != http.StatusOK {
+		h := cw.Header()
+		h.Del("ETag")
+		h.Del("Last-Modified")
+		h.Set("Cache-Control", "no-store")
+	}
+	cw.wroteHeader = true
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *catalogResponseWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(b)
+}
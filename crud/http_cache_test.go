@@ -0,0 +1,56 @@
+package crud
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETagListMatches(t *testing.T) {
+	assert.True(t, etagListMatches(`"abc"`, `"abc"`))
+	assert.True(t, etagListMatches(`"x", W/"abc"`, `"abc"`))
+	assert.True(t, etagListMatches(`*`, `"abc"`))
+	assert.False(t, etagListMatches(`"abd"`, `"abc"`))
+}
+
+func TestServeCatalog_ErrorsAreNotCached(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/products?sort=popularity", nil)
+	w := httptest.NewRecorder()
+
+	serveCatalog(w, req, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+}
+
+func TestCategoriesHandler_NotModified(t *testing.T) {
+	setupTestDB(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/products/categories", nil)
+	w := httptest.NewRecorder()
+	CategoriesHandler(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, catalogCacheControl, w.Header().Get("Cache-Control"))
+	assert.NotEmpty(t, w.Header().Get("Last-Modified"))
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req = httptest.NewRequest(http.MethodGet, "/products/categories", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	CategoriesHandler(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	// A different listing has a different tag
+	req = httptest.NewRequest(http.MethodGet, "/v2/products/categories?limit=5", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	CategoriesPageHandler(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
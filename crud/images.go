@@ -0,0 +1,285 @@
+package crud
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	_ "image/gif" // decoders for uploaded originals
+	"image/jpeg"
+	_ "image/png"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+
+	"noble-group-services/models"
+	"noble-group-services/services/storage"
+)
+
+// imageVariants are the generated image sizes, from the smallest. Images are
+// scaled to fit the width and never enlarged.
+var imageVariants = []struct {
+	Name  string
+	Width int
+}{
+	{"thumb", 160},
+	{"card", 480},
+	{"full", 1200},
+}
+
+// imageFormats maps variant formats to their content type. WebP variants are
+// lossless, the only kind a pure-Go encoder writes.
+var imageFormats = map[string]string{
+	"jpeg": "image/jpeg",
+	"webp": "image/webp",
+}
+
+const (
+	imageJPEGQuality = 82
+	// maxImagePixels refuses originals that would take too much memory to
+	// decode.
+	maxImagePixels = 40_000_000
+)
+
+// apiBaseURL prefixes variant URLs and links in emails, see SetAPIBaseURL.
+var apiBaseURL = ""
+
+// SetAPIBaseURL sets the public origin of this API, e.g.
+// "https://api.noble.kz", for absolute variant URLs and the links in emails.
+// It must be an absolute http or https URL. Without it variant URLs are
+// relative to the API and subscription emails are not sent.
+func SetAPIBaseURL(u string) error {
+	if u != "" {
+		parsed, err := url.Parse(u)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("API base URL %q is not an absolute http(s) URL", u)
+		}
+	}
+	apiBaseURL = strings.TrimSuffix(u, "/")
+	return nil
+}
+
+// imageVariantURL is where the variant of the stored original key is served.
+func imageVariantURL(variant, key, format string) string {
+	return apiBaseURL + "/images/" + variant + "/" + key + "." + format
+}
+
+// mediaKey returns the storage key of a media URL.
+func mediaKey(u string) (string, bool) {
+	if mediaStorage == nil {
+		return "", false
+	}
+	key, ok := strings.CutPrefix(u, mediaStorage.URL(""))
+	if !ok || key == "" || strings.HasPrefix(key, "variants/") {
+		return "", false
+	}
+	return key, true
+}
+
+// productImages describes the images of a product with their variants.
+func productImages(urls models.JSONStringArray) []models.ProductImage {
+	images := make([]models.ProductImage, 0, len(urls))
+	for _, u := range urls {
+		img := models.ProductImage{Original: u}
+		if key, ok := mediaKey(u); ok {
+			var jpegSet, webpSet []string
+			for _, v := range imageVariants {
+				variant := models.ImageVariant{
+					Name:  v.Name,
+					Width: v.Width,
+					JPEG:  imageVariantURL(v.Name, key, "jpeg"),
+					WebP:  imageVariantURL(v.Name, key, "webp"),
+				}
+				img.Variants = append(img.Variants, variant)
+				jpegSet = append(jpegSet, variant.JPEG+" "+strconv.Itoa(v.Width)+"w")
+				webpSet = append(webpSet, variant.WebP+" "+strconv.Itoa(v.Width)+"w")
+			}
+			img.SrcSet = map[string]string{
+				"jpeg": strings.Join(jpegSet, ", "),
+				"webp": strings.Join(webpSet, ", "),
+			}
+		}
+		images = append(images, img)
+	}
+	return images
+}
+
+// withImages fills p.Images from p.Image.
+func withImages(p models.Product) models.Product {
+	p.Images = productImages(p.Image)
+	return p
+}
+
+// ImageVariantHandler handles GET /images/{variant}/{key}.{format}
+func ImageVariantHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		GetImageVariant(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetImageVariant godoc
+// @Summary Get an image variant
+// @Description Redirect to a resized copy of an uploaded image, generating and storing it on first use. Variants: thumb (160px), card (480px), full (1200px) wide.
+// @Tags media
+// @Param variant path string true "Variant" Enums(thumb, card, full)
+// @Param key path string true "Storage key of the original"
+// @Param format path string true "Format" Enums(jpeg, webp)
+// @Success 302 {string} string "Redirect to the variant"
+// @Failure 404 {string} string "Image not found"
+// @Failure 422 {string} string "Original is not a readable image"
+// @Router /images/{variant}/{key}.{format} [get]
+func GetImageVariant(w http.ResponseWriter, r *http.Request) {
+	variant, key, format, ok := parseImageVariantPath(r.URL.Path)
+	if !ok || mediaStorage == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	u, err := imageVariantCache.url(r, variant, key, format)
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		http.NotFound(w, r)
+		return
+	case errors.Is(err, errInvalidImage):
+		http.Error(w, "Original is not a readable image", http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		log.Printf("image variant %s: %v", r.URL.Path, err)
+		http.Error(w, "Storage error", http.StatusBadGateway)
+		return
+	}
+
+	// Variants never change, but the storage location may be reconfigured.
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	http.Redirect(w, r, u, http.StatusFound)
+}
+
+// parseImageVariantPath splits "/images/card/2026/10/x.png.webp".
+func parseImageVariantPath(path string) (variant, key, format string, ok bool) {
+	rest, found := strings.CutPrefix(path, "/images/")
+	if !found {
+		return "", "", "", false
+	}
+	variant, rest, found = strings.Cut(rest, "/")
+	if !found || imageVariantWidth(variant) == 0 {
+		return "", "", "", false
+	}
+	i := strings.LastIndexByte(rest, '.')
+	if i <= 0 {
+		return "", "", "", false
+	}
+	key, format = rest[:i], rest[i+1:]
+	if _, known := imageFormats[format]; !known || strings.HasPrefix(key, "variants/") {
+		return "", "", "", false
+	}
+	return variant, key, format, true
+}
+
+func imageVariantWidth(name string) int {
+	for _, v := range imageVariants {
+		if v.Name == name {
+			return v.Width
+		}
+	}
+	return 0
+}
+
+var errInvalidImage = errors.New("invalid image")
+
+// variantCache remembers which variants are already stored, so that only the
+// first request after a restart asks the storage.
+type variantCache struct {
+	stored sync.Map
+	// locks serialize work on the same variant, striped by key hash.
+	locks [64]sync.Mutex
+}
+
+var imageVariantCache = &variantCache{}
+
+// url returns the storage URL of a variant, generating it when missing.
+func (c *variantCache) url(r *http.Request, variant, key, format string) (string, error) {
+	variantKey := "variants/" + variant + "/" + key + "." + format
+	if _, ok := c.stored.Load(variantKey); ok {
+		return mediaStorage.URL(variantKey), nil
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(variantKey))
+	mu := &c.locks[h.Sum32()%uint32(len(c.locks))]
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := c.stored.Load(variantKey); ok {
+		return mediaStorage.URL(variantKey), nil
+	}
+	ctx := r.Context()
+	if exists, err := mediaStorage.Exists(ctx, variantKey); err != nil {
+		return "", err
+	} else if exists {
+		c.stored.Store(variantKey, true)
+		return mediaStorage.URL(variantKey), nil
+	}
+
+	original, err := mediaStorage.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	data, err := resizeImage(original, imageVariantWidth(variant), format)
+	if err != nil {
+		return "", err
+	}
+	u, err := mediaStorage.Put(ctx, variantKey, imageFormats[format], data)
+	if err != nil {
+		return "", err
+	}
+	c.stored.Store(variantKey, true)
+	return u, nil
+}
+
+// resizeImage scales an image down to at most width pixels wide and encodes
+// it as format. Transparent areas become white in JPEG.
+func resizeImage(data []byte, width int, format string) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, errInvalidImage
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errInvalidImage
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > width {
+		h = max(1, h*width/w)
+		w = width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	if format == "jpeg" {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	}
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: imageJPEGQuality})
+	case "webp":
+		err = nativewebp.Encode(&buf, dst, nil)
+	default:
+		err = errors.New("unknown image format " + format)
+	}
+	return buf.Bytes(), err
+}
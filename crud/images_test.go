@@ -0,0 +1,112 @@
+package crud
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+
+	"noble-group-services/models"
+	"noble-group-services/services/storage"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, 0, color.NRGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestParseImageVariantPath(t *testing.T) {
+	variant, key, format, ok := parseImageVariantPath("/images/card/2026/10/x.png.webp")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"card", "2026/10/x.png", "webp"}, []string{variant, key, format})
+
+	for _, path := range []string{
+		"/images/huge/2026/10/x.png.webp", "/images/card/2026/10/x.png.gif",
+		"/images/card/.webp", "/images/card", "/images/card/variants/thumb/x.png.jpeg.webp",
+	} {
+		_, _, _, ok := parseImageVariantPath(path)
+		assert.False(t, ok, path)
+	}
+}
+
+func TestProductImages(t *testing.T) {
+	defer func(s storage.Storage) { mediaStorage = s }(mediaStorage)
+	SetMediaStorage(storage.NewLocalStorage(t.TempDir(), "/media"))
+
+	images := productImages(models.JSONStringArray{"/media/2026/10/a.png", "https://example.com/b.jpg"})
+	require.Len(t, images, 2)
+
+	assert.Equal(t, "/media/2026/10/a.png", images[0].Original)
+	require.Len(t, images[0].Variants, 3)
+	assert.Equal(t, models.ImageVariant{
+		Name: "thumb", Width: 160,
+		JPEG: "/images/thumb/2026/10/a.png.jpeg", WebP: "/images/thumb/2026/10/a.png.webp",
+	}, images[0].Variants[0])
+	assert.Equal(t, "/images/thumb/2026/10/a.png.webp 160w, /images/card/2026/10/a.png.webp 480w, "+
+		"/images/full/2026/10/a.png.webp 1200w", images[0].SrcSet["webp"])
+
+	// Images hosted elsewhere are passed through
+	assert.Equal(t, models.ProductImage{Original: "https://example.com/b.jpg"}, images[1])
+}
+
+func TestResizeImage(t *testing.T) {
+	src := testPNG(t, 640, 320)
+
+	data, err := resizeImage(src, 160, "jpeg")
+	require.NoError(t, err)
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 160, 80), img.Bounds())
+
+	// Smaller images are not enlarged
+	data, err = resizeImage(src, 1200, "webp")
+	require.NoError(t, err)
+	img, err = webp.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 640, 320), img.Bounds())
+
+	_, err = resizeImage([]byte("not an image"), 160, "jpeg")
+	assert.ErrorIs(t, err, errInvalidImage)
+}
+
+func TestImageVariantHandler(t *testing.T) {
+	defer func(s storage.Storage) { mediaStorage = s }(mediaStorage)
+	store := storage.NewLocalStorage(t.TempDir(), "/media")
+	SetMediaStorage(store)
+	imageVariantCache = &variantCache{}
+
+	_, err := store.Put(context.Background(), "2026/10/a.png", "image/png", testPNG(t, 800, 400))
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		ImageVariantHandler(w, httptest.NewRequest(http.MethodGet, "/images/thumb/2026/10/a.png.webp", nil))
+		require.Equal(t, http.StatusFound, w.Code, "Response: %s", w.Body.String())
+		assert.Equal(t, "/media/variants/thumb/2026/10/a.png.webp", w.Header().Get("Location"))
+	}
+
+	data, err := store.Get(context.Background(), "variants/thumb/2026/10/a.png.webp")
+	require.NoError(t, err)
+	cfg, err := webp.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 160, cfg.Width)
+
+	w := httptest.NewRecorder()
+	ImageVariantHandler(w, httptest.NewRequest(http.MethodGet, "/images/card/2026/10/missing.png.jpeg", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,639 @@
+package crud
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"noble-group-services/models"
+)
+
+var errInsufficientStock = errors.New("insufficient stock")
+
+// WarehousesHandler handles GET, POST /admin/warehouses
+func WarehousesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		GetWarehouses(w, r)
+	case http.MethodPost:
+		CreateWarehouse(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// WarehouseItemHandler handles PUT /admin/warehouses/{id}
+func WarehouseItemHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPut:
+		UpdateWarehouse(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetWarehouses godoc
+// @Summary List warehouses
+// @Description Get all warehouses ordered by code.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.Warehouse
+// @Router /admin/warehouses [get]
+func GetWarehouses(w http.ResponseWriter, r *http.Request) {
+	warehouses := []models.Warehouse{}
+	if err := db.Select(&warehouses, `SELECT * FROM warehouses ORDER BY code`); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(warehouses)
+}
+
+// CreateWarehouse godoc
+// @Summary Create a warehouse
+// @Description Create a warehouse. New warehouses are active unless active is false.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param warehouse body models.Warehouse true "Warehouse"
+// @Success 201 {object} models.Warehouse
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 409 {string} string "Code already exists"
+// @Router /admin/warehouses [post]
+func CreateWarehouse(w http.ResponseWriter, r *http.Request) {
+	wh := models.Warehouse{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&wh); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	wh.ID = uuid.New().String()
+	wh.Code, wh.Name, wh.Address = strings.TrimSpace(wh.Code), strings.TrimSpace(wh.Name), strings.TrimSpace(wh.Address)
+	if details := validateWarehouse(wh); len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+
+	err := db.Get(&wh, `
+		INSERT INTO warehouses (id, code, name, address, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING *
+	`, wh.ID, wh.Code, wh.Name, wh.Address, wh.Active)
+	if err != nil {
+		if isUniqueViolation(err) {
+			writeConflict(w, err)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(wh)
+}
+
+// UpdateWarehouse godoc
+// @Summary Replace a warehouse
+// @Description Replace the code, name, address and active flag of a warehouse. Inactive warehouses keep their stock but accept no movements.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Warehouse ID"
+// @Param warehouse body models.Warehouse true "Warehouse"
+// @Success 200 {object} models.Warehouse
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 404 {string} string "Warehouse not found"
+// @Failure 409 {string} string "Code already exists"
+// @Router /admin/warehouses/{id} [put]
+func UpdateWarehouse(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/admin/warehouses/")
+
+	var wh models.Warehouse
+	if err := json.NewDecoder(r.Body).Decode(&wh); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	wh.Code, wh.Name, wh.Address = strings.TrimSpace(wh.Code), strings.TrimSpace(wh.Name), strings.TrimSpace(wh.Address)
+	if details := validateWarehouse(wh); len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+
+	err := db.Get(&wh, `
+		UPDATE warehouses SET code=$1, name=$2, address=$3, active=$4, updated_at=NOW()
+		WHERE id=$5
+		RETURNING *
+	`, wh.Code, wh.Name, wh.Address, wh.Active, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Warehouse not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		if isUniqueViolation(err) {
+			writeConflict(w, err)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wh)
+}
+
+func validateWarehouse(wh models.Warehouse) []ValidationErrorDetail {
+	var details []ValidationErrorDetail
+	if wh.Code == "" {
+		details = append(details, ValidationErrorDetail{Field: "code", Message: "Code is required"})
+	}
+	if wh.Name == "" {
+		details = append(details, ValidationErrorDetail{Field: "name", Message: "Name is required"})
+	}
+	return details
+}
+
+// stockLevelRow is a stock level listing row together with its keyset sort key.
+type stockLevelRow struct {
+	models.StockLevel
+	SortKey string `db:"sort_key"`
+}
+
+func (r stockLevelRow) sortKey() string { return r.SortKey }
+
+// stockMovementRow is a stock movement listing row together with its keyset
+// sort key.
+type stockMovementRow struct {
+	models.StockMovement
+	SortKey string `db:"sort_key"`
+}
+
+func (r stockMovementRow) sortKey() string { return r.SortKey }
+
+// StockLevelsHandler handles GET /admin/stock
+func StockLevelsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		GetStockLevels(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetStockLevels godoc
+// @Summary List stock levels
+// @Description Get the quantities held per warehouse, product and variant, wrapped in a pagination envelope.
+// @Tags admin
+// @Produce json
+// @Param warehouseId query string false "Warehouse ID"
+// @Param productId query string false "Product ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Keyset cursor"
+// @Success 200 {object} PageResponse[models.StockLevel]
+// @Failure 400 {string} string "Invalid cursor"
+// @Router /admin/stock [get]
+func GetStockLevels(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	lq := listQuery{
+		Columns: `warehouse_id, product_id, variant_id, quantity, updated_at`,
+		From:    `stock_levels`,
+		Sort:    "item",
+		Keys: []sortKey{
+			{Expr: "warehouse_id", Type: "text"},
+			{Expr: "product_id", Type: "text"},
+			{Expr: "COALESCE(variant_id, '')", Type: "text"},
+		},
+	}
+	if warehouseID := query.Get("warehouseId"); warehouseID != "" {
+		lq.Where += ` AND warehouse_id = ` + lq.Args.add(warehouseID)
+	}
+	if productID := query.Get("productId"); productID != "" {
+		lq.Where += ` AND product_id = ` + lq.Args.add(productID)
+	}
+
+	page, err := fetchPage(lq, parsePageParams(query), func(row stockLevelRow) models.StockLevel {
+		return row.StockLevel
+	})
+	writePage(w, page, err)
+}
+
+// StockMovementsHandler handles GET, POST /admin/stock/movements
+func StockMovementsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		GetStockMovements(w, r)
+	case http.MethodPost:
+		PostStockMovement(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetStockMovements godoc
+// @Summary List stock movements
+// @Description Get the stock ledger, newest first, wrapped in a pagination envelope.
+// @Tags admin
+// @Produce json
+// @Param type query string false "Movement type" Enums(receipt, sale, return, adjustment, transfer)
+// @Param warehouseId query string false "Warehouse ID"
+// @Param productId query string false "Product ID"
+// @Param orderId query string false "Order ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Keyset cursor"
+// @Success 200 {object} PageResponse[models.StockMovement]
+// @Failure 400 {string} string "Invalid cursor"
+// @Router /admin/stock/movements [get]
+func GetStockMovements(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	lq := listQuery{
+		Columns: `id, type, warehouse_id, product_id, variant_id, quantity, transfer_id, order_id, reference, comment, created_at`,
+		From:    `stock_movements`,
+		Sort:    "newest",
+		Keys: []sortKey{
+			{Expr: "created_at", Type: "timestamp", Desc: true},
+			{Expr: "id", Type: "text", Desc: true},
+		},
+	}
+	for _, f := range [][2]string{{"type", "type"}, {"warehouseId", "warehouse_id"}, {"productId", "product_id"}, {"orderId", "order_id"}} {
+		if v := query.Get(f[0]); v != "" {
+			lq.Where += ` AND ` + f[1] + ` = ` + lq.Args.add(v)
+		}
+	}
+
+	page, err := fetchPage(lq, parsePageParams(query), func(row stockMovementRow) models.StockMovement {
+		return row.StockMovement
+	})
+	writePage(w, page, err)
+}
+
+// PostStockMovement godoc
+// @Summary Post a stock movement
+// @Description Append a movement to the stock ledger and apply it to the warehouse level. The stock of the product, or variant, becomes the sum of its warehouse levels. A transfer is recorded as two movements sharing a transferId.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param movement body models.StockMovementRequest true "Movement"
+// @Success 201 {array} models.StockMovement
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 409 {string} string "Insufficient stock"
+// @Router /admin/stock/movements [post]
+func PostStockMovement(w http.ResponseWriter, r *http.Request) {
+	var req models.StockMovementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if details := validateStockMovement(req); len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	details, err := checkStockMovementRefs(tx, req)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+
+	movements, err := postStockMovement(tx, req)
+	if errors.Is(err, errInsufficientStock) {
+		http.Error(w, "Insufficient stock", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	catalogCache.invalidate("products", req.ProductID)
+	wakeSubscriptionNotifier()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movements)
+}
+
+// validateStockMovement checks the fields of a movement request.
+func validateStockMovement(req models.StockMovementRequest) []ValidationErrorDetail {
+	var details []ValidationErrorDetail
+
+	switch req.Type {
+	case models.MovementReceipt, models.MovementSale, models.MovementReturn, models.MovementAdjustment, models.MovementTransfer:
+	default:
+		details = append(details, ValidationErrorDetail{Field: "type", Message: "Type must be receipt, sale, return, adjustment or transfer"})
+	}
+	if req.WarehouseID == "" {
+		details = append(details, ValidationErrorDetail{Field: "warehouseId", Message: "WarehouseID is required"})
+	}
+	if req.ProductID == "" {
+		details = append(details, ValidationErrorDetail{Field: "productId", Message: "ProductID is required"})
+	}
+	switch {
+	case req.Type == models.MovementAdjustment && req.Quantity == 0:
+		details = append(details, ValidationErrorDetail{Field: "quantity", Message: "Quantity cannot be zero"})
+	case req.Type != models.MovementAdjustment && req.Quantity <= 0:
+		details = append(details, ValidationErrorDetail{Field: "quantity", Message: "Quantity must be positive"})
+	}
+	switch {
+	case req.Type == models.MovementTransfer && (req.ToWarehouseID == "" || req.ToWarehouseID == req.WarehouseID):
+		details = append(details, ValidationErrorDetail{Field: "toWarehouseId", Message: "Transfers need a different destination warehouse"})
+	case req.Type != models.MovementTransfer && req.ToWarehouseID != "":
+		details = append(details, ValidationErrorDetail{Field: "toWarehouseId", Message: "Only transfers have a destination warehouse"})
+	}
+
+	return details
+}
+
+// checkStockMovementRefs locks the product of a movement and checks that it,
+// its variant and its warehouses exist. Products with variants move stock of
+// one of them.
+func checkStockMovementRefs(tx *sqlx.Tx, req models.StockMovementRequest) ([]ValidationErrorDetail, error) {
+	var details []ValidationErrorDetail
+
+	var productID string
+	err := tx.Get(&productID, `SELECT id FROM products WHERE id = $1 FOR UPDATE`, req.ProductID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return []ValidationErrorDetail{{Field: "productId", Message: "Product not found"}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.VariantID != nil {
+		var exists bool
+		err = tx.Get(&exists, `SELECT EXISTS (SELECT 1 FROM product_variants WHERE id = $1 AND product_id = $2)`, *req.VariantID, req.ProductID)
+		if !exists {
+			details = append(details, ValidationErrorDetail{Field: "variantId", Message: "Variant not found"})
+		}
+	} else {
+		var hasVariants bool
+		err = tx.Get(&hasVariants, `SELECT EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1)`, req.ProductID)
+		if hasVariants {
+			details = append(details, ValidationErrorDetail{Field: "variantId", Message: "VariantID is required for products with variants"})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]string{req.WarehouseID: "warehouseId"}
+	if req.ToWarehouseID != "" {
+		fields[req.ToWarehouseID] = "toWarehouseId"
+	}
+	var warehouses []models.Warehouse
+	if err := tx.Select(&warehouses, `SELECT * FROM warehouses WHERE id = ANY($1)`, []string{req.WarehouseID, req.ToWarehouseID}); err != nil {
+		return nil, err
+	}
+	for _, wh := range warehouses {
+		if !wh.Active {
+			details = append(details, ValidationErrorDetail{Field: fields[wh.ID], Message: "Warehouse " + wh.Code + " is inactive"})
+		}
+		delete(fields, wh.ID)
+	}
+	for _, field := range fields {
+		details = append(details, ValidationErrorDetail{Field: field, Message: "Warehouse not found"})
+	}
+
+	return details, nil
+}
+
+// postStockMovement records a checked movement request and applies it to the
+// warehouse levels and the product stock. It fails with
+// errInsufficientStock when a level would become negative.
+func postStockMovement(tx *sqlx.Tx, req models.StockMovementRequest) ([]models.StockMovement, error) {
+	base := models.StockMovement{
+		Type:      req.Type,
+		ProductID: req.ProductID,
+		VariantID: req.VariantID,
+		OrderID:   req.OrderID,
+		Reference: req.Reference,
+		Comment:   req.Comment,
+		CreatedAt: time.Now(),
+	}
+
+	var movements []models.StockMovement
+	switch req.Type {
+	case models.MovementTransfer:
+		transferID := uuid.New().String()
+		base.TransferID = &transferID
+		out, in := base, base
+		out.WarehouseID, out.Quantity = req.WarehouseID, -req.Quantity
+		in.WarehouseID, in.Quantity = req.ToWarehouseID, req.Quantity
+		movements = append(movements, out, in)
+	case models.MovementSale:
+		base.WarehouseID, base.Quantity = req.WarehouseID, -req.Quantity
+		movements = append(movements, base)
+	default:
+		base.WarehouseID, base.Quantity = req.WarehouseID, req.Quantity
+		movements = append(movements, base)
+	}
+
+	for i := range movements {
+		m := &movements[i]
+		m.ID = uuid.New().String()
+		_, err := tx.Exec(`
+			INSERT INTO stock_levels (warehouse_id, product_id, variant_id, quantity, updated_at)
+			VALUES ($1, $2, $3, $4, NOW())
+			ON CONFLICT (warehouse_id, product_id, COALESCE(variant_id, ''))
+			DO UPDATE SET quantity = stock_levels.quantity + EXCLUDED.quantity, updated_at = NOW()
+		`, m.WarehouseID, m.ProductID, m.VariantID, m.Quantity)
+		if isCheckViolation(err) {
+			return nil, errInsufficientStock
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.NamedExec(`
+			INSERT INTO stock_movements (id, type, warehouse_id, product_id, variant_id, quantity, transfer_id, order_id, reference, comment, created_at)
+			VALUES (:id, :type, :warehouse_id, :product_id, :variant_id, :quantity, :transfer_id, :order_id, :reference, :comment, :created_at)
+		`, m)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return movements, syncStock(tx, req.ProductID)
+}
+
+// syncStock derives the stock of a product and of its variants from their
+// warehouse levels, for those that have any, and then the product totals of
+// its variants, see syncVariantTotals. Stock without levels is left as set.
+func syncStock(tx *sqlx.Tx, productID string) error {
+	_, err := tx.Exec(`
+		UPDATE product_variants v SET stock = s.quantity
+		FROM (
+			SELECT variant_id, SUM(quantity) AS quantity
+			FROM stock_levels WHERE product_id = $1 AND variant_id IS NOT NULL
+			GROUP BY variant_id
+		) s
+		WHERE v.id = s.variant_id
+	`, productID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE products p SET stock = s.quantity
+		FROM (SELECT SUM(quantity) AS quantity FROM stock_levels WHERE product_id = $1 AND variant_id IS NULL) s
+		WHERE p.id = $1 AND s.quantity IS NOT NULL
+	`, productID)
+	if err != nil {
+		return err
+	}
+	return syncVariantTotals(tx, productID)
+}
+
+// StockReconciliation compares the stock ledger with the stored levels and
+// the product stock derived from them.
+type StockReconciliation struct {
+	// Balanced is set when every row below has no difference.
+	Balanced bool                  `json:"balanced"`
+	Levels   []LevelReconciliation `json:"levels"`
+	Items    []ItemReconciliation  `json:"items"`
+}
+
+// LevelReconciliation compares a warehouse level with the sum of its
+// movements.
+type LevelReconciliation struct {
+	WarehouseID string  `db:"warehouse_id" json:"warehouseId"`
+	ProductID   string  `db:"product_id" json:"productId"`
+	VariantID   *string `db:"variant_id" json:"variantId,omitempty"`
+	Movements   int     `db:"movements" json:"movements"`
+	Level       int     `db:"level" json:"level"`
+	// Difference is Level minus Movements.
+	Difference int `db:"difference" json:"difference"`
+}
+
+// ItemReconciliation compares the stock of a product or variant with the sum
+// of its warehouse levels.
+type ItemReconciliation struct {
+	ProductID string  `db:"product_id" json:"productId"`
+	VariantID *string `db:"variant_id" json:"variantId,omitempty"`
+	Stock     int     `db:"stock" json:"stock"`
+	Levels    int     `db:"levels" json:"levels"`
+	// Difference is Stock minus Levels.
+	Difference int `db:"difference" json:"difference"`
+}
+
+// StockReconciliationHandler handles GET /admin/stock/reconciliation
+func StockReconciliationHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		GetStockReconciliation(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetStockReconciliation godoc
+// @Summary Reconcile stock
+// @Description Compare every warehouse level with the sum of its ledger movements, and the stock of every product and variant that has levels with their sum.
+// @Tags admin
+// @Produce json
+// @Param productId query string false "Product ID"
+// @Param warehouseId query string false "Warehouse ID, for levels"
+// @Param mismatchesOnly query bool false "Only rows with a difference"
+// @Success 200 {object} StockReconciliation
+// @Router /admin/stock/reconciliation [get]
+func GetStockReconciliation(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var levelArgs, itemArgs queryArgs
+	levelWhere, itemWhere := ``, ``
+	if productID := query.Get("productId"); productID != "" {
+		levelWhere += ` AND product_id = ` + levelArgs.add(productID)
+		itemWhere += ` AND product_id = ` + itemArgs.add(productID)
+	}
+	if warehouseID := query.Get("warehouseId"); warehouseID != "" {
+		levelWhere += ` AND warehouse_id = ` + levelArgs.add(warehouseID)
+	}
+	if query.Get("mismatchesOnly") == "true" {
+		levelWhere += ` AND difference <> 0`
+		itemWhere += ` AND difference <> 0`
+	}
+
+	report := StockReconciliation{Levels: []LevelReconciliation{}, Items: []ItemReconciliation{}}
+	err := db.Select(&report.Levels, `
+		SELECT * FROM (
+			SELECT
+				COALESCE(l.warehouse_id, m.warehouse_id) AS warehouse_id,
+				COALESCE(l.product_id, m.product_id) AS product_id,
+				COALESCE(l.variant_id, m.variant_id) AS variant_id,
+				COALESCE(m.quantity, 0) AS movements,
+				COALESCE(l.quantity, 0) AS level,
+				COALESCE(l.quantity, 0) - COALESCE(m.quantity, 0) AS difference
+			FROM stock_levels l
+			FULL JOIN (
+				SELECT warehouse_id, product_id, variant_id, SUM(quantity) AS quantity
+				FROM stock_movements
+				GROUP BY warehouse_id, product_id, variant_id
+			) m ON m.warehouse_id = l.warehouse_id AND m.product_id = l.product_id
+				AND COALESCE(m.variant_id, '') = COALESCE(l.variant_id, '')
+		) t
+		WHERE true`+levelWhere+`
+		ORDER BY warehouse_id, product_id, variant_id NULLS FIRST
+	`, levelArgs...)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	err = db.Select(&report.Items, `
+		SELECT * FROM (
+			SELECT p.id AS product_id, NULL::varchar AS variant_id, p.stock, s.quantity AS levels, p.stock - s.quantity AS difference
+			FROM products p
+			JOIN (
+				SELECT product_id, SUM(quantity) AS quantity FROM stock_levels
+				WHERE variant_id IS NULL GROUP BY product_id
+			) s ON s.product_id = p.id
+			UNION ALL
+			SELECT v.product_id, v.id, v.stock, s.quantity, v.stock - s.quantity
+			FROM product_variants v
+			JOIN (
+				SELECT variant_id, SUM(quantity) AS quantity FROM stock_levels
+				WHERE variant_id IS NOT NULL GROUP BY variant_id
+			) s ON s.variant_id = v.id
+		) t
+		WHERE true`+itemWhere+`
+		ORDER BY product_id, variant_id NULLS FIRST
+	`, itemArgs...)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	report.Balanced = true
+	for _, l := range report.Levels {
+		report.Balanced = report.Balanced && l.Difference == 0
+	}
+	for _, item := range report.Items {
+		report.Balanced = report.Balanced && item.Difference == 0
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
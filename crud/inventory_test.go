@@ -0,0 +1,144 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"noble-group-services/models"
+)
+
+func TestValidateStockMovement(t *testing.T) {
+	valid := []models.StockMovementRequest{
+		{Type: models.MovementReceipt, WarehouseID: "w1", ProductID: "p1", Quantity: 5},
+		{Type: models.MovementAdjustment, WarehouseID: "w1", ProductID: "p1", Quantity: -2},
+		{Type: models.MovementTransfer, WarehouseID: "w1", ToWarehouseID: "w2", ProductID: "p1", Quantity: 1},
+	}
+	for _, req := range valid {
+		assert.Empty(t, validateStockMovement(req), req.Type)
+	}
+
+	tests := []struct {
+		req    models.StockMovementRequest
+		fields []string
+	}{
+		{models.StockMovementRequest{Type: "loss"}, []string{"type", "warehouseId", "productId", "quantity"}},
+		{models.StockMovementRequest{Type: models.MovementSale, WarehouseID: "w1", ProductID: "p1", Quantity: -1}, []string{"quantity"}},
+		{models.StockMovementRequest{Type: models.MovementAdjustment, WarehouseID: "w1", ProductID: "p1"}, []string{"quantity"}},
+		{models.StockMovementRequest{Type: models.MovementTransfer, WarehouseID: "w1", ToWarehouseID: "w1", ProductID: "p1", Quantity: 1}, []string{"toWarehouseId"}},
+		{models.StockMovementRequest{Type: models.MovementReceipt, WarehouseID: "w1", ToWarehouseID: "w2", ProductID: "p1", Quantity: 1}, []string{"toWarehouseId"}},
+	}
+	for _, tt := range tests {
+		var fields []string
+		for _, d := range validateStockMovement(tt.req) {
+			fields = append(fields, d.Field)
+		}
+		assert.Equal(t, tt.fields, fields, "%+v", tt.req)
+	}
+}
+
+func TestStockMovements(t *testing.T) {
+	setupTestDB(t)
+
+	var ref models.Product
+	require.NoError(t, db.Get(&ref, `SELECT * FROM products ORDER BY id LIMIT 1`))
+
+	createWarehouse := func(code string) models.Warehouse {
+		body, _ := json.Marshal(models.Warehouse{Code: code, Name: "Склад " + code})
+		w := httptest.NewRecorder()
+		WarehousesHandler(w, httptest.NewRequest(http.MethodPost, "/admin/warehouses", bytes.NewReader(body)))
+		require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+		var wh models.Warehouse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &wh))
+		assert.True(t, wh.Active)
+		return wh
+	}
+	suffix := uuid.New().String()[:8]
+	almaty, astana := createWarehouse("ALA-"+suffix), createWarehouse("AST-"+suffix)
+	defer db.Exec(`DELETE FROM warehouses WHERE id = ANY($1)`, []string{almaty.ID, astana.ID})
+
+	// The ledger is append-only; deleting the product removes its movements
+	body, _ := json.Marshal(models.Product{
+		Name: "Stock Test " + suffix, ManufacturerID: ref.ManufacturerID, CategoryID: ref.CategoryID,
+		SKU: "ST-" + suffix, Price: 1, Availability: "in_stock",
+	})
+	w := httptest.NewRecorder()
+	ProductsHandler(w, httptest.NewRequest(http.MethodPost, "/products", bytes.NewReader(body)))
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+	var p models.Product
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	defer db.Exec(`DELETE FROM products WHERE id = $1`, p.ID)
+
+	post := func(req models.StockMovementRequest) *httptest.ResponseRecorder {
+		req.ProductID = p.ID
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		StockMovementsHandler(w, httptest.NewRequest(http.MethodPost, "/admin/stock/movements", bytes.NewReader(body)))
+		return w
+	}
+	stock := func() int {
+		var stock int
+		require.NoError(t, db.Get(&stock, `SELECT stock FROM products WHERE id = $1`, p.ID))
+		return stock
+	}
+
+	w = post(models.StockMovementRequest{Type: models.MovementReceipt, WarehouseID: almaty.ID, Quantity: 10})
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+	w = post(models.StockMovementRequest{Type: models.MovementTransfer, WarehouseID: almaty.ID, ToWarehouseID: astana.ID, Quantity: 4})
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+	var transfer []models.StockMovement
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &transfer))
+	require.Len(t, transfer, 2)
+	assert.Equal(t, -4, transfer[0].Quantity)
+	assert.Equal(t, *transfer[0].TransferID, *transfer[1].TransferID)
+
+	require.Equal(t, http.StatusCreated, post(models.StockMovementRequest{Type: models.MovementSale, WarehouseID: astana.ID, Quantity: 3}).Code)
+	assert.Equal(t, http.StatusConflict, post(models.StockMovementRequest{Type: models.MovementSale, WarehouseID: astana.ID, Quantity: 2}).Code)
+	assert.Equal(t, http.StatusBadRequest, post(models.StockMovementRequest{Type: models.MovementReceipt, WarehouseID: "missing", Quantity: 1}).Code)
+	assert.Equal(t, 7, stock())
+
+	// Updating the product cannot overwrite stock held in warehouses
+	_, err := db.Exec(`UPDATE products SET stock = 100 WHERE id = $1`, p.ID)
+	require.NoError(t, err)
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	require.NoError(t, syncStock(tx, p.ID))
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, 7, stock())
+
+	// Deactivated warehouses accept no movements
+	astana.Active = false
+	body, _ = json.Marshal(astana)
+	w = httptest.NewRecorder()
+	WarehouseItemHandler(w, httptest.NewRequest(http.MethodPut, "/admin/warehouses/"+astana.ID, bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+	assert.Equal(t, http.StatusBadRequest, post(models.StockMovementRequest{Type: models.MovementReturn, WarehouseID: astana.ID, Quantity: 1}).Code)
+
+	w = httptest.NewRecorder()
+	StockMovementsHandler(w, httptest.NewRequest(http.MethodGet, "/admin/stock/movements?productId="+p.ID+"&warehouseId="+astana.ID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var movements PageResponse[models.StockMovement]
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &movements))
+	require.Len(t, movements.Items, 2)
+	assert.Equal(t, models.MovementSale, movements.Items[0].Type)
+
+	w = httptest.NewRecorder()
+	StockReconciliationHandler(w, httptest.NewRequest(http.MethodGet, "/admin/stock/reconciliation?productId="+p.ID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var report StockReconciliation
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.True(t, report.Balanced)
+	assert.Len(t, report.Levels, 2)
+	require.Len(t, report.Items, 1)
+	assert.Equal(t, 7, report.Items[0].Levels)
+
+	// Movements cannot be rewritten
+	_, err = db.Exec(`UPDATE stock_movements SET quantity = 1 WHERE product_id = $1`, p.ID)
+	assert.Error(t, err)
+}
@@ -1,7 +1,10 @@
 package crud
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strings"
 
@@ -10,11 +13,14 @@ import (
 	"noble-group-services/models"
 )
 
+// manufacturerColumns are the columns of models.Manufacturer.
+const manufacturerColumns = `id, name, slug, logo, version, updated_at`
+
 // ManufacturersHandler handles GET /manufacturers and POST /manufacturers
 func ManufacturersHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		GetManufacturers(w, r)
+		serveCatalog(w, r, GetManufacturers, manufacturerTables...)
 	case http.MethodPost:
 		CreateManufacturer(w, r)
 	default:
@@ -22,13 +28,15 @@ func ManufacturersHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// ManufacturerItemHandler handles GET, PUT, DELETE /manufacturers/{id}
+// ManufacturerItemHandler handles GET, PUT, PATCH, DELETE /manufacturers/{id}
 func ManufacturerItemHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		GetManufacturer(w, r)
 	case http.MethodPut:
 		UpdateManufacturer(w, r)
+	case http.MethodPatch:
+		PatchManufacturer(w, r)
 	case http.MethodDelete:
 		DeleteManufacturer(w, r)
 	default:
@@ -36,34 +44,100 @@ func ManufacturerItemHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ManufacturerBySlugHandler handles GET /products/manufacturers/by-slug/{slug}
+func ManufacturerBySlugHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		GetManufacturerBySlug(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// ManufacturersPageHandler handles GET /v2/products/manufacturers
+func ManufacturersPageHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		serveCatalog(w, r, GetManufacturersPage, manufacturerTables...)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 // GetManufacturers godoc
 // @Summary Get all manufacturers
 // @Description Get a list of all manufacturers
 // @Tags manufacturers
 // @Produce json
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {array} models.Manufacturer
+// @Header 200 {string} ETag "Strong validator of the listing"
+// @Header 200 {string} Last-Modified "Last catalog change"
+// @Header 200 {string} Cache-Control "Configured with CATALOG_CACHE_CONTROL"
+// @Success 304 {string} string "Not Modified"
 // @Router /products/manufacturers [get]
 func GetManufacturers(w http.ResponseWriter, r *http.Request) {
-	var manufacturers []models.Manufacturer
-	err := db.Select(&manufacturers, `SELECT id, name, slug, logo FROM manufacturers ORDER BY name`)
+	body, _, err := catalogCache.load("manufacturers", func() (interface{}, []string, error) {
+		var manufacturers []models.Manufacturer
+		err := db.Select(&manufacturers, `SELECT `+manufacturerColumns+` FROM manufacturers ORDER BY name`)
+		return manufacturers, manufacturerTables, err
+	})
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(manufacturers)
+	writeJSONBody(w, body)
 }
 
+// GetManufacturersPage godoc
+// @Summary Get a page of manufacturers
+// @Description Get manufacturers wrapped in a pagination envelope. Pass either page or the nextCursor of a previous response.
+// @Tags manufacturers
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Keyset cursor"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} PageResponse[models.Manufacturer]
+// @Header 200 {string} ETag "Strong validator of the listing"
+// @Header 200 {string} Last-Modified "Last catalog change"
+// @Header 200 {string} Cache-Control "Configured with CATALOG_CACHE_CONTROL"
+// @Success 304 {string} string "Not Modified"
+// @Failure 400 {string} string "Invalid cursor"
+// @Router /v2/products/manufacturers [get]
+func GetManufacturersPage(w http.ResponseWriter, r *http.Request) {
+	lq := listQuery{
+		Columns: manufacturerColumns,
+		From:    `manufacturers`,
+		Sort:    "name",
+		Keys:    []sortKey{{Expr: "name", Type: "text"}, {Expr: "id", Type: "text"}},
+	}
+
+	page, err := fetchPage(lq, parsePageParams(r.URL.Query()), func(row manufacturerRow) models.Manufacturer {
+		return row.Manufacturer
+	})
+	writePage(w, page, err)
+}
+
+// manufacturerRow is a manufacturer listing row together with its keyset sort key.
+type manufacturerRow struct {
+	models.Manufacturer
+	SortKey string `db:"sort_key"`
+}
+
+func (r manufacturerRow) sortKey() string { return r.SortKey }
+
 // CreateManufacturer godoc
 // @Summary Create a manufacturer
-// @Description Create a new manufacturer
+// @Description Create a new manufacturer. When slug is omitted it is generated from the name, transliterating Cyrillic.
 // @Tags manufacturers
 // @Accept json
 // @Produce json
 // @Param manufacturer body models.Manufacturer true "Manufacturer"
 // @Success 201 {object} models.Manufacturer
 // @Failure 400 {string} string "Invalid request"
+// @Failure 409 {string} string "Slug already exists"
 // @Router /products/manufacturers [post]
 func CreateManufacturer(w http.ResponseWriter, r *http.Request) {
 	var m models.Manufacturer
@@ -72,32 +146,62 @@ func CreateManufacturer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if m.Name == "" || m.Slug == "" {
-		http.Error(w, "Name and Slug are required", http.StatusBadRequest)
+	if m.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
 		return
 	}
 
 	m.ID = uuid.New().String()
 
-	_, err := db.Exec(`INSERT INTO manufacturers (id, name, slug, logo) VALUES ($1, $2, $3, $4)`,
-		m.ID, m.Name, m.Slug, m.Logo)
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	m.Slug, err = resolveSlug(tx, manufacturerSlugs, m.Slug, m.Name)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	err = tx.QueryRowx(`INSERT INTO manufacturers (id, name, slug, logo) VALUES ($1, $2, $3, $4) RETURNING version, updated_at`,
+		m.ID, m.Name, m.Slug, m.Logo).Scan(&m.Version, &m.UpdatedAt)
 	if err != nil {
+		if isUniqueViolation(err) {
+			writeConflict(w, err)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := claimSlug(tx, manufacturerSlugs, m.Slug); err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	catalogCache.invalidate("manufacturers", m.ID)
+
 	w.Header().Set("Content-Type", "application/json")
+	setVersionETag(w, m.Version)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(m)
 }
 
 // GetManufacturer godoc
 // @Summary Get manufacturer by ID
-// @Description Get details of a specific manufacturer
+// @Description Get details of a specific manufacturer. The ETag header carries the manufacturer version expected in If-Match by writes.
 // @Tags manufacturers
 // @Produce json
 // @Param id path string true "Manufacturer ID"
 // @Success 200 {object} models.Manufacturer
+// @Header 200 {string} ETag "Manufacturer version"
 // @Failure 404 {string} string "Manufacturer not found"
 // @Router /products/manufacturers/{id} [get]
 func GetManufacturer(w http.ResponseWriter, r *http.Request) {
@@ -108,52 +212,180 @@ func GetManufacturer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var m models.Manufacturer
-	err := db.Get(&m, `SELECT id, name, slug, logo FROM manufacturers WHERE id = $1`, id)
+	err := db.Get(&m, `SELECT `+manufacturerColumns+` FROM manufacturers WHERE id = $1`, id)
 	if err != nil {
 		http.NotFound(w, r)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	setVersionETag(w, m.Version)
+	json.NewEncoder(w).Encode(m)
+}
+
+// GetManufacturerBySlug godoc
+// @Summary Get manufacturer by slug
+// @Description Get details of a specific manufacturer by its slug. Former slugs redirect to the current one.
+// @Tags manufacturers
+// @Produce json
+// @Param slug path string true "Manufacturer Slug"
+// @Success 200 {object} models.Manufacturer
+// @Header 200 {string} ETag "Manufacturer version"
+// @Success 301 {string} string "Moved to the current slug"
+// @Failure 404 {string} string "Manufacturer not found"
+// @Router /products/manufacturers/by-slug/{slug} [get]
+func GetManufacturerBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := strings.TrimPrefix(r.URL.Path, "/products/manufacturers/by-slug/")
+	if slug == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var m models.Manufacturer
+	err := db.Get(&m, `SELECT `+manufacturerColumns+` FROM manufacturers WHERE slug = $1`, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		redirectSlug(w, r, manufacturerSlugs, slug, "/products/manufacturers/by-slug/")
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	setVersionETag(w, m.Version)
 	json.NewEncoder(w).Encode(m)
 }
 
 // UpdateManufacturer godoc
-// @Summary Update manufacturer
-// @Description Update an existing manufacturer
+// @Summary Replace manufacturer
+// @Description Replace an existing manufacturer. Name and slug are required.
 // @Tags manufacturers
 // @Accept json
 // @Produce json
 // @Param id path string true "Manufacturer ID"
+// @Param If-Match header string true "ETag of the version being replaced"
 // @Param manufacturer body models.Manufacturer true "Manufacturer"
 // @Success 200 {object} models.Manufacturer
-// @Failure 400 {string} string "Invalid request"
+// @Failure 400 {object} ValidationErrorResponse
 // @Failure 404 {string} string "Manufacturer not found"
+// @Failure 409 {string} string "Slug already exists"
+// @Failure 412 {string} string "Manufacturer was modified"
+// @Failure 428 {string} string "If-Match header required"
 // @Router /products/manufacturers/{id} [put]
 func UpdateManufacturer(w http.ResponseWriter, r *http.Request) {
+	writeManufacturer(w, r, func(_ models.Manufacturer, body []byte) (models.Manufacturer, error) {
+		var m models.Manufacturer
+		err := json.Unmarshal(body, &m)
+		return m, err
+	})
+}
+
+// PatchManufacturer godoc
+// @Summary Patch manufacturer
+// @Description Partially update a manufacturer with JSON Merge Patch (RFC 7386): only fields present in the body change, null clears a field.
+// @Tags manufacturers
+// @Accept json
+// @Produce json
+// @Param id path string true "Manufacturer ID"
+// @Param If-Match header string true "ETag of the version being replaced"
+// @Param patch body object true "Merge patch"
+// @Success 200 {object} models.Manufacturer
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 404 {string} string "Manufacturer not found"
+// @Failure 409 {string} string "Slug already exists"
+// @Failure 412 {string} string "Manufacturer was modified"
+// @Failure 428 {string} string "If-Match header required"
+// @Router /products/manufacturers/{id} [patch]
+func PatchManufacturer(w http.ResponseWriter, r *http.Request) {
+	writeManufacturer(w, r, mergePatch[models.Manufacturer])
+}
+
+// writeManufacturer loads the manufacturer named in the path, checks If-Match
+// against its version, lets build derive the new state from it and the request
+// body, then validates, stores and returns it.
+func writeManufacturer(w http.ResponseWriter, r *http.Request, build func(current models.Manufacturer, body []byte) (models.Manufacturer, error)) {
 	id := strings.TrimPrefix(r.URL.Path, "/products/manufacturers/")
 	if id == "" {
 		http.Error(w, "ID required", http.StatusBadRequest)
 		return
 	}
 
-	var m models.Manufacturer
-	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	m.ID = id // Ensure ID matches path
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
 
-	_, err := db.Exec(`UPDATE manufacturers SET name = $1, slug = $2, logo = $3 WHERE id = $4`,
+	var current models.Manufacturer
+	err = tx.Get(&current, `SELECT `+manufacturerColumns+` FROM manufacturers WHERE id = $1 FOR UPDATE`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := checkIfMatch(r, current.Version); err != nil {
+		writePreconditionError(w, err)
+		return
+	}
+
+	m, err := build(current, body)
+	if err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	m.ID = current.ID
+	m.Version = current.Version
+	m.Slug = slugify(m.Slug)
+
+	if details := validateNamedEntity(m.Name, m.Slug); len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+
+	_, err = tx.Exec(`UPDATE manufacturers SET name = $1, slug = $2, logo = $3, updated_at = NOW(), version = version + 1 WHERE id = $4`,
 		m.Name, m.Slug, m.Logo, m.ID)
 	if err != nil {
+		if isUniqueViolation(err) {
+			writeConflict(w, err)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := recordSlugChange(tx, manufacturerSlugs, m.ID, current.Slug, m.Slug); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	var stored models.Manufacturer
+	if err := tx.Get(&stored, `SELECT `+manufacturerColumns+` FROM manufacturers WHERE id = $1`, m.ID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
+	catalogCache.invalidate("manufacturers", m.ID)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(m)
+	setVersionETag(w, stored.Version)
+	json.NewEncoder(w).Encode(stored)
 }
 
 // DeleteManufacturer godoc
@@ -162,8 +394,11 @@ func UpdateManufacturer(w http.ResponseWriter, r *http.Request) {
 // @Tags manufacturers
 // @Produce json
 // @Param id path string true "Manufacturer ID"
+// @Param If-Match header string true "ETag of the version being deleted"
 // @Success 204 {string} string "No Content"
 // @Failure 404 {string} string "Manufacturer not found"
+// @Failure 412 {string} string "Manufacturer was modified"
+// @Failure 428 {string} string "If-Match header required"
 // @Router /products/manufacturers/{id} [delete]
 func DeleteManufacturer(w http.ResponseWriter, r *http.Request) {
 	id := strings.TrimPrefix(r.URL.Path, "/products/manufacturers/")
@@ -172,17 +407,5 @@ func DeleteManufacturer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := db.Exec(`DELETE FROM manufacturers WHERE id = $1`, id)
-	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
-	}
-
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		http.NotFound(w, r)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
+	deleteVersioned(w, r, "manufacturers", id)
 }
@@ -0,0 +1,137 @@
+package crud
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"noble-group-services/services/storage"
+)
+
+// mediaStorage keeps uploaded files, see SetMediaStorage.
+var mediaStorage storage.Storage
+
+// maxMediaSize bounds uploaded media files.
+var maxMediaSize int64 = 10 << 20
+
+// mediaTypes are the accepted upload types, by sniffed content type, with the
+// extension stored files get. SVG is left out as it can carry scripts.
+var mediaTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// SetMediaStorage sets where uploaded media is stored.
+func SetMediaStorage(s storage.Storage) {
+	mediaStorage = s
+}
+
+// SetMediaMaxSize sets the largest accepted upload in bytes.
+func SetMediaMaxSize(n int64) {
+	if n > 0 {
+		maxMediaSize = n
+	}
+}
+
+// MediaUpload describes a stored file. URL can be used as is in
+// Product.Image, Category.Image and Manufacturer.Logo.
+type MediaUpload struct {
+	URL         string `json:"url"`
+	Key         string `json:"key"`
+	ContentType string `json:"contentType"`
+	Size        int    `json:"size"`
+}
+
+// MediaHandler handles POST /admin/media
+func MediaHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		UploadMedia(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// MediaFilesHandler handles GET /media/ for storage that serves its own files.
+func MediaFilesHandler(w http.ResponseWriter, r *http.Request) {
+	h, ok := mediaStorage.(http.Handler)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.ServeHTTP(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// UploadMedia godoc
+// @Summary Upload media
+// @Description Store an image sent as the "file" form field and return its URL. The type is detected from the content, not the file name; JPEG, PNG, GIF and WebP are accepted.
+// @Tags admin
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Image"
+// @Success 201 {object} MediaUpload
+// @Failure 400 {string} string "Invalid upload"
+// @Failure 413 {string} string "File too large"
+// @Failure 415 {string} string "Unsupported media type"
+// @Router /admin/media [post]
+func UploadMedia(w http.ResponseWriter, r *http.Request) {
+	if mediaStorage == nil {
+		http.Error(w, "Media storage is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Leave room for the multipart framing around the file.
+	r.Body = http.MaxBytesReader(w, r.Body, maxMediaSize+64<<10)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "File too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid upload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxMediaSize+1))
+	if err != nil {
+		http.Error(w, "Invalid upload: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if int64(len(data)) > maxMediaSize {
+		http.Error(w, "File too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := mediaTypes[contentType]
+	if !ok {
+		http.Error(w, "Unsupported media type: "+contentType, http.StatusUnsupportedMediaType)
+		return
+	}
+
+	key := time.Now().UTC().Format("2006/01/") + uuid.New().String() + ext
+	url, err := mediaStorage.Put(r.Context(), key, contentType, data)
+	if err != nil {
+		log.Printf("media upload: %v", err)
+		http.Error(w, "Storage error", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(MediaUpload{URL: url, Key: key, ContentType: contentType, Size: len(data)})
+}
@@ -0,0 +1,68 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"noble-group-services/services/storage"
+)
+
+func postMedia(t *testing.T, filename string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	part.Write(data)
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/media", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	MediaHandler(w, req)
+	return w
+}
+
+func TestMediaHandler(t *testing.T) {
+	defer func(s storage.Storage, size int64) { mediaStorage, maxMediaSize = s, size }(mediaStorage, maxMediaSize)
+	SetMediaStorage(storage.NewLocalStorage(t.TempDir(), "/media"))
+
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 4, 4))))
+
+	// The type comes from the content, not the name
+	w := postMedia(t, "photo.jpg", img.Bytes())
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+	var upload MediaUpload
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &upload))
+	assert.Equal(t, "image/png", upload.ContentType)
+	assert.True(t, strings.HasPrefix(upload.URL, "/media/") && strings.HasSuffix(upload.URL, ".png"), upload.URL)
+	assert.Equal(t, img.Len(), upload.Size)
+
+	// The returned URL serves the file
+	w = httptest.NewRecorder()
+	MediaFilesHandler(w, httptest.NewRequest(http.MethodGet, upload.URL, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, img.Bytes(), w.Body.Bytes())
+
+	w = postMedia(t, "logo.png", []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	SetMediaMaxSize(int64(img.Len() - 1))
+	w = postMedia(t, "photo.png", img.Bytes())
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	w = postMedia(t, "huge.png", bytes.Repeat([]byte{0}, 200<<10))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
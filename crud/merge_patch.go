@@ -0,0 +1,77 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+var errInvalidPatch = errors.New("patch must be a JSON object")
+
+// mergePatch applies an RFC 7386 JSON Merge Patch to the JSON form of current
+// and decodes the result into a fresh value: members set to null in the patch
+// are removed and end up as zero values.
+func mergePatch[T any](current T, patch []byte) (T, error) {
+	var result T
+
+	patchDoc, err := decodeJSONValue(patch)
+	if err != nil {
+		return result, errInvalidPatch
+	}
+	if _, ok := patchDoc.(map[string]interface{}); !ok {
+		return result, errInvalidPatch
+	}
+
+	raw, err := json.Marshal(current)
+	if err != nil {
+		return result, err
+	}
+	doc, err := decodeJSONValue(raw)
+	if err != nil {
+		return result, err
+	}
+
+	raw, err = json.Marshal(mergeValue(doc, patchDoc))
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(raw, &result)
+	return result, err
+}
+
+// mergeValue implements the MergePatch function of RFC 7386.
+func mergeValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// decodeJSONValue decodes a single JSON document, keeping numbers exact.
+func decodeJSONValue(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after JSON document")
+	}
+	return v, nil
+}
@@ -0,0 +1,43 @@
+package crud
+
+import (
+	"testing"
+
+	"noble-group-services/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	logo := "logo.png"
+	current := models.Manufacturer{ID: "m1", Name: "Samsung", Slug: "samsung", Logo: &logo}
+
+	patched, err := mergePatch(current, []byte(`{"name": "Samsung Electronics", "logo": null}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, models.Manufacturer{ID: "m1", Name: "Samsung Electronics", Slug: "samsung"}, patched)
+}
+
+func TestMergeValue_Nested(t *testing.T) {
+	target := map[string]interface{}{
+		"a": map[string]interface{}{"b": "c", "d": "e"},
+		"f": []interface{}{"g"},
+	}
+	patch := map[string]interface{}{
+		"a": map[string]interface{}{"b": nil, "x": "y"},
+		"f": []interface{}{"h"},
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{"d": "e", "x": "y"},
+		"f": []interface{}{"h"},
+	}, mergeValue(target, patch))
+}
+
+func TestMergePatch_Invalid(t *testing.T) {
+	for _, patch := range []string{``, `[]`, `"name"`, `{"name": "a"} {}`, `{`} {
+		_, err := mergePatch(models.Manufacturer{}, []byte(patch))
+		assert.ErrorIs(t, err, errInvalidPatch, "patch %q", patch)
+	}
+}
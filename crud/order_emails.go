@@ -0,0 +1,70 @@
+package crud
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"noble-group-services/models"
+	"noble-group-services/services/emails"
+)
+
+// orderEmailItems loads the lines of an order as its emails list them.
+func orderEmailItems(q sqlx.Queryer, orderID string) ([]emails.Item, error) {
+	var rows []struct {
+		Name     string                `db:"name"`
+		SKU      string                `db:"sku"`
+		Options  models.VariantOptions `db:"options"`
+		Quantity int                   `db:"quantity"`
+		Price    int                   `db:"price"`
+	}
+	err := sqlx.Select(q, &rows, `
+		SELECT p.name, COALESCE(v.sku, p.sku) AS sku, COALESCE(v.options, '{}') AS options, oi.quantity, oi.price
+		FROM order_items oi
+		JOIN products p ON p.id = oi.product_id
+		LEFT JOIN product_variants v ON v.id = oi.variant_id
+		WHERE oi.order_id = $1
+		ORDER BY p.name, oi.id
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]emails.Item, len(rows))
+	for i, row := range rows {
+		items[i] = emails.Item{Name: row.Name, SKU: row.SKU, Options: variantLabel(row.Options), Quantity: row.Quantity, Price: row.Price}
+	}
+	return items, nil
+}
+
+// variantLabel describes variant options as "Мощность: 5 кВт, Фазы: 3",
+// ordered by option name.
+func variantLabel(options models.VariantOptions) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + options[name]
+	}
+	return strings.Join(parts, ", ")
+}
+
+// queueOrderEmail renders the order email kind and queues it for the
+// customer in tx. Call wakeEmailOutbox after tx commits.
+func queueOrderEmail(tx sqlx.Execer, kind string, o emails.Order) error {
+	o.ShopName = feedConfig.ShopName
+	o.ShopURL = storefront.BaseURL
+	msg, err := emails.Render(kind, o)
+	if err != nil {
+		// The order must not fail over its email
+		log.Printf("Order %s: rendering %s email: %v", o.OrderNumber, kind, err)
+		return nil
+	}
+	return queueEmail(tx, kind, o.CustomerEmail, msg, &o.ID)
+}
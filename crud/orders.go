@@ -1,11 +1,14 @@
 package crud
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -13,9 +16,13 @@ import (
 	"github.com/google/uuid"
 
 	"noble-group-services/models"
-	"noble-group-services/services/smtp"
+	"noble-group-services/services/emails"
 )
 
+// orderColumns are the columns of models.Order.
+const orderColumns = `id, order_number, customer_name, customer_phone, customer_email, address,
+	customer_type, company_name, bin, comment, total, status, created_at, locale, tracking_number`
+
 // ValidationErrorDetail represents a single field validation error.
 type ValidationErrorDetail struct {
 	Field   string `json:"field"`
@@ -28,6 +35,16 @@ type ValidationErrorResponse struct {
 	Details []ValidationErrorDetail `json:"details"`
 }
 
+// writeValidationErrors responds with 400 and a VALIDATION_ERROR body.
+func writeValidationErrors(w http.ResponseWriter, details []ValidationErrorDetail) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(ValidationErrorResponse{
+		Error:   "VALIDATION_ERROR",
+		Details: details,
+	})
+}
+
 // OrdersHandler handles POST /orders
 func OrdersHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -38,9 +55,21 @@ func OrdersHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// OrderItemHandler handles DELETE /orders/{id}
+// OrdersPageHandler handles GET /v2/orders
+func OrdersPageHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		GetOrdersPage(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// OrderItemHandler handles PATCH, DELETE /orders/{id}
 func OrderItemHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
+	case http.MethodPatch:
+		UpdateOrderStatus(w, r)
 	case http.MethodDelete:
 		DeleteOrder(w, r)
 	default:
@@ -106,12 +135,7 @@ func CreateOrder(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(validationErrors) > 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ValidationErrorResponse{
-			Error:   "VALIDATION_ERROR",
-			Details: validationErrors,
-		})
+		writeValidationErrors(w, validationErrors)
 		return
 	}
 
@@ -129,10 +153,19 @@ func CreateOrder(w http.ResponseWriter, r *http.Request) {
 				// Skip invalid products or handle error? Let's skip for now or return error
 				continue
 			}
+			// Products with variants are ordered as one of them
+			variant, needed, err := loadCartVariant(product.ID, reqItem.VariantID)
+			if err != nil || (needed && variant == nil) {
+				continue
+			}
+			if variant != nil {
+				product = applyVariant(product, *variant)
+			}
 			finalTotal += product.Price * reqItem.Quantity
 			orderItems = append(orderItems, models.CartItem{
 				Product:  product,
 				Quantity: reqItem.Quantity,
+				Variant:  variant,
 			})
 		}
 	} else {
@@ -158,8 +191,22 @@ func CreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	orderID := uuid.New().String()
-	orderNumber := fmt.Sprintf("ORD-%d-%06d", time.Now().Year(), rand.Intn(1000000))
+	order := models.Order{
+		ID:            uuid.New().String(),
+		OrderNumber:   fmt.Sprintf("ORD-%d-%06d", time.Now().Year(), rand.Intn(1000000)),
+		CustomerName:  form.Name,
+		CustomerPhone: form.Phone,
+		CustomerEmail: form.Email,
+		Address:       form.Address,
+		CustomerType:  form.CustomerType,
+		CompanyName:   form.CompanyName,
+		BIN:           form.BIN,
+		Comment:       form.Comment,
+		Total:         finalTotal,
+		Status:        models.OrderNew,
+		CreatedAt:     time.Now(),
+		Locale:        emails.MatchLocale(form.Locale, r.Header.Get("Accept-Language")),
+	}
 
 	// Start transaction
 	tx, err := db.Beginx()
@@ -170,15 +217,11 @@ func CreateOrder(w http.ResponseWriter, r *http.Request) {
 	defer tx.Rollback()
 
 	// Insert Order
-	_, err = tx.Exec(`
-		INSERT INTO orders (
-			id, order_number, customer_name, customer_phone, customer_email, address,
-			customer_type, company_name, bin, comment, total, status, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-	`,
-		orderID, orderNumber, form.Name, form.Phone, form.Email, form.Address,
-		form.CustomerType, form.CompanyName, form.BIN, form.Comment, finalTotal, "new", time.Now(),
-	)
+	_, err = tx.NamedExec(`
+		INSERT INTO orders (`+orderColumns+`)
+		VALUES (:id, :order_number, :customer_name, :customer_phone, :customer_email, :address,
+			:customer_type, :company_name, :bin, :comment, :total, :status, :created_at, :locale, :tracking_number)
+	`, order)
 	if err != nil {
 		http.Error(w, "Failed to create order", http.StatusInternalServerError)
 		return
@@ -187,35 +230,44 @@ func CreateOrder(w http.ResponseWriter, r *http.Request) {
 	// Insert Order Items
 	for _, item := range orderItems {
 		itemID := uuid.New().String()
+		var variantID *string
+		if item.Variant != nil {
+			variantID = &item.Variant.ID
+		}
 		_, err = tx.Exec(`
-			INSERT INTO order_items (id, order_id, product_id, quantity, price)
-			VALUES ($1, $2, $3, $4, $5)
-		`, itemID, orderID, item.Product.ID, item.Quantity, item.Product.Price)
+			INSERT INTO order_items (id, order_id, product_id, variant_id, quantity, price)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, itemID, order.ID, item.Product.ID, variantID, item.Quantity, item.Product.Price)
 		if err != nil {
 			http.Error(w, "Failed to create order items", http.StatusInternalServerError)
 			return
 		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		http.Error(w, "Failed to commit order", http.StatusInternalServerError)
+	items, err := orderEmailItems(tx, order.ID)
+	if err == nil {
+		err = queueOrderEmail(tx, emails.OrderPlaced, emails.Order{Order: order, Items: items})
+	}
+	if err == nil {
+		err = queueStaffEmails(tx, order, items)
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
-	// Send Email if Company is true
-	if form.Company {
-		go func() {
-			smtpService := smtp.NewSmtpService()
-			subject := fmt.Sprintf("Новый заказ %s", orderNumber)
-			body := fmt.Sprintf("Здравствуйте, %s!\n\nВаш заказ %s успешно оформлен.\nСумма: %d\n\nСпасибо за покупку!", form.Name, orderNumber, finalTotal)
-			_ = smtpService.SendEmail(form.Email, subject, body)
-		}()
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit order", http.StatusInternalServerError)
+		return
 	}
+	catalogCache.invalidate("order_items", "")
+	wakeEmailOutbox()
+	notifyStaff(order, items)
 
 	response := map[string]interface{}{
 		"success":     true,
-		"orderId":     orderID,
-		"orderNumber": orderNumber,
+		"orderId":     order.ID,
+		"orderNumber": order.OrderNumber,
 		"total":       finalTotal,
 	}
 
@@ -224,6 +276,128 @@ func CreateOrder(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// GetOrdersPage godoc
+// @Summary List orders
+// @Description Get orders, newest first, wrapped in a pagination envelope. Pass either page or the nextCursor of a previous response.
+// @Tags orders
+// @Produce json
+// @Param status query string false "Order status"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Keyset cursor"
+// @Success 200 {object} PageResponse[models.Order]
+// @Failure 400 {string} string "Invalid cursor"
+// @Router /v2/orders [get]
+func GetOrdersPage(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	lq := listQuery{
+		Columns: orderColumns,
+		From:    `orders`,
+		Sort:    "newest",
+		Keys: []sortKey{
+			{Expr: "created_at", Type: "timestamp", Desc: true},
+			{Expr: "id", Type: "text", Desc: true},
+		},
+	}
+	if status := query.Get("status"); status != "" {
+		lq.Where += ` AND status = ` + lq.Args.add(status)
+	}
+
+	page, err := fetchPage(lq, parsePageParams(query), func(row orderRow) models.Order {
+		return row.Order
+	})
+	writePage(w, page, err)
+}
+
+// orderRow is an order listing row together with its keyset sort key.
+type orderRow struct {
+	models.Order
+	SortKey string `db:"sort_key"`
+}
+
+func (r orderRow) sortKey() string { return r.SortKey }
+
+// UpdateOrderStatus godoc
+// @Summary Change order status
+// @Description Set the status of an order, and optionally its tracking number. The customer is emailed when the status changes; the email for shipped orders includes the tracking number.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param update body models.OrderStatusUpdate true "Status"
+// @Success 200 {object} models.Order
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 404 {string} string "Order not found"
+// @Router /orders/{id} [patch]
+func UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/orders/")
+
+	var req models.OrderStatusUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.TrackingNumber != nil {
+		tracking := strings.TrimSpace(*req.TrackingNumber)
+		req.TrackingNumber = &tracking
+	}
+	if !slices.Contains(models.OrderStatuses, req.Status) {
+		writeValidationErrors(w, []ValidationErrorDetail{{Field: "status", Message: "Status must be one of " + strings.Join(models.OrderStatuses, ", ")}})
+		return
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var previous string
+	err = tx.Get(&previous, `SELECT status FROM orders WHERE id = $1 FOR UPDATE`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Order not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	var order models.Order
+	err = tx.Get(&order, `
+		UPDATE orders SET status = $2, tracking_number = COALESCE($3, tracking_number)
+		WHERE id = $1
+		RETURNING `+orderColumns, id, req.Status, req.TrackingNumber)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if order.Status != previous {
+		kind := emails.OrderStatusChanged
+		if order.Status == models.OrderShipped {
+			kind = emails.OrderShipped
+		}
+		items, err := orderEmailItems(tx, id)
+		if err == nil {
+			err = queueOrderEmail(tx, kind, emails.Order{Order: order, Items: items, PreviousStatus: previous})
+		}
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	wakeEmailOutbox()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(order)
+}
+
 // DeleteOrder godoc
 // @Summary Delete order
 // @Description Delete an order by ID
@@ -252,6 +426,7 @@ func DeleteOrder(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	catalogCache.invalidate("order_items", "")
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,226 @@
+package crud
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// PageResponse is the listing envelope returned by the /v2 endpoints.
+type PageResponse[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int    `json:"total"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// queryArgs collects positional arguments for a dynamically built query.
+type queryArgs []interface{}
+
+// add appends v and returns its $n placeholder.
+func (a *queryArgs) add(v interface{}) string {
+	*a = append(*a, v)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// sortKey is one column of a listing's ORDER BY clause. Type is the Postgres
+// type used to cast cursor values back when resuming a keyset scan.
+type sortKey struct {
+	Expr string
+	Type string
+	Desc bool
+}
+
+// orderByClause renders keys as an ORDER BY clause.
+func orderByClause(keys []sortKey) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k.Expr
+		if k.Desc {
+			parts[i] += " DESC"
+		}
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// sortKeyColumn selects the sort name followed by the values of keys as a JSON
+// array. The value of the last row on a page becomes the next cursor.
+// sort must be a fixed identifier, never client input.
+func sortKeyColumn(sort string, keys []sortKey) string {
+	exprs := make([]string, len(keys)+1)
+	exprs[0] = "'" + sort + "'"
+	for i, k := range keys {
+		exprs[i+1] = k.Expr
+	}
+	return "json_build_array(" + strings.Join(exprs, ", ") + ")::text AS sort_key"
+}
+
+// keysetCondition matches rows strictly after values in the order defined by keys.
+func keysetCondition(keys []sortKey, values []string, args *queryArgs) string {
+	placeholders := make([]string, len(keys))
+	for i, k := range keys {
+		placeholders[i] = args.add(values[i]) + "::text"
+		if k.Type != "text" {
+			placeholders[i] += "::" + k.Type
+		}
+	}
+
+	ors := make([]string, len(keys))
+	for i, k := range keys {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, keys[j].Expr+" = "+placeholders[j])
+		}
+		op := " > "
+		if k.Desc {
+			op = " < "
+		}
+		ands = append(ands, k.Expr+op+placeholders[i])
+		ors[i] = "(" + strings.Join(ands, " AND ") + ")"
+	}
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+// encodeCursor turns a sort_key column value into an opaque cursor.
+func encodeCursor(sortKey string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(sortKey))
+}
+
+// decodeCursor returns the keyset values stored in cursor as strings. Cursors
+// issued for a different sort order are rejected.
+func decodeCursor(cursor string, sort string, keys int) ([]string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil || len(items) != keys+1 {
+		return nil, errInvalidCursor
+	}
+
+	var cursorSort string
+	if err := json.Unmarshal(items[0], &cursorSort); err != nil || cursorSort != sort {
+		return nil, errInvalidCursor
+	}
+	items = items[1:]
+
+	values := make([]string, len(items))
+	for i, item := range items {
+		if len(item) > 0 && item[0] == '"' {
+			if err := json.Unmarshal(item, &values[i]); err != nil {
+				return nil, errInvalidCursor
+			}
+			continue
+		}
+		if string(item) == "null" {
+			return nil, errInvalidCursor
+		}
+		values[i] = string(item)
+	}
+	return values, nil
+}
+
+// pageParams holds the page/limit/cursor parameters of a listing request.
+type pageParams struct {
+	Page   int
+	Limit  int
+	Cursor string
+}
+
+func parsePageParams(query url.Values) pageParams {
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit < 1 || limit > maxPageLimit {
+		limit = defaultPageLimit
+	}
+	return pageParams{Page: page, Limit: limit, Cursor: query.Get("cursor")}
+}
+
+// listQuery describes a paginated listing: Columns and From form the base
+// SELECT, Where holds filter conditions (each starting with " AND") bound to Args,
+// and Keys is the ORDER BY of the sort named Sort.
+type listQuery struct {
+	Columns string
+	From    string
+	Where   string
+	Args    queryArgs
+	Sort    string
+	Keys    []sortKey
+}
+
+// keyedRow is a scanned listing row carrying its sort_key column.
+type keyedRow interface {
+	sortKey() string
+}
+
+// fetchPage runs lq in either page or cursor mode and maps the rows with item.
+// When a cursor is supplied, Page is omitted from the response.
+func fetchPage[R keyedRow, T any](lq listQuery, pp pageParams, item func(R) T) (*PageResponse[T], error) {
+	var total int
+	if err := db.Get(&total, `SELECT COUNT(*) FROM `+lq.From+` WHERE true`+lq.Where, lq.Args...); err != nil {
+		return nil, err
+	}
+
+	args := append(queryArgs{}, lq.Args...)
+	q := `SELECT ` + lq.Columns + `, ` + sortKeyColumn(lq.Sort, lq.Keys) + ` FROM ` + lq.From + ` WHERE true` + lq.Where
+
+	resp := &PageResponse[T]{Total: total, Limit: pp.Limit}
+	if pp.Cursor != "" {
+		values, err := decodeCursor(pp.Cursor, lq.Sort, len(lq.Keys))
+		if err != nil {
+			return nil, err
+		}
+		q += ` AND ` + keysetCondition(lq.Keys, values, &args)
+		q += orderByClause(lq.Keys) + ` LIMIT ` + args.add(pp.Limit+1)
+	} else {
+		resp.Page = pp.Page
+		q += orderByClause(lq.Keys) + ` LIMIT ` + args.add(pp.Limit+1) + ` OFFSET ` + args.add((pp.Page-1)*pp.Limit)
+	}
+
+	var rows []R
+	if err := db.Select(&rows, q, args...); err != nil {
+		return nil, err
+	}
+
+	if len(rows) > pp.Limit {
+		rows = rows[:pp.Limit]
+		resp.NextCursor = encodeCursor(rows[len(rows)-1].sortKey())
+	}
+
+	resp.Items = make([]T, len(rows))
+	for i, row := range rows {
+		resp.Items[i] = item(row)
+	}
+	return resp, nil
+}
+
+// writePage encodes a listing envelope, reporting bad cursors as 400.
+func writePage[T any](w http.ResponseWriter, page *PageResponse[T], err error) {
+	if errors.Is(err, errInvalidCursor) {
+		http.Error(w, "Invalid cursor", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(page)
+}
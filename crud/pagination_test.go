@@ -0,0 +1,166 @@
+package crud
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"noble-group-services/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ================== Pagination Unit Tests ==================
+
+func TestDecodeCursor_RoundTrip(t *testing.T) {
+	cursor := encodeCursor(`["price_asc", "Galaxy \"S24\"", 139900, "p2"]`)
+
+	values, err := decodeCursor(cursor, "price_asc", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`Galaxy "S24"`, "139900", "p2"}, values)
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"not json", encodeCursor("name")},
+		{"wrong key count", encodeCursor(`["name", "a"]`)},
+		{"other sort", encodeCursor(`["price_asc", 100, "p1"]`)},
+		{"null key", encodeCursor(`["name", null, "p1"]`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(tt.cursor, "name", 2)
+			assert.ErrorIs(t, err, errInvalidCursor)
+		})
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	keys := []sortKey{
+		{Expr: "p.price", Type: "integer", Desc: true},
+		{Expr: "p.id", Type: "text"},
+	}
+	var args queryArgs
+	args.add("filter")
+
+	cond := keysetCondition(keys, []string{"100", "p1"}, &args)
+
+	assert.Equal(t, "((p.price < $2::text::integer) OR (p.price = $2::text::integer AND p.id > $3::text))", cond)
+	assert.Equal(t, queryArgs{"filter", "100", "p1"}, args)
+}
+
+func TestProductSortKeys(t *testing.T) {
+	for _, sort := range []string{"name", "price_asc", "price_desc", "rating", "newest", "popularity", "discount", "relevance"} {
+		t.Run(sort, func(t *testing.T) {
+			keys, ok := productSortKeys(sort, "$1")
+			require.True(t, ok)
+			assert.Equal(t, "p.id", keys[len(keys)-1].Expr, "sort must end with the id tie-breaker")
+		})
+	}
+
+	_, ok := productSortKeys("cheapest", "")
+	assert.False(t, ok)
+}
+
+func TestProductsPageHandler_SortedCursor(t *testing.T) {
+	setupTestDB(t)
+
+	var prices []int
+	cursor := ""
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/v2/products?sort=price_desc&limit=1&cursor="+cursor, nil)
+		w := httptest.NewRecorder()
+		ProductsPageHandler(w, req)
+		require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+
+		var page PageResponse[models.Product]
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&page))
+		for _, p := range page.Items {
+			prices = append(prices, p.Price)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	assert.IsNonIncreasing(t, prices)
+}
+
+func TestProductsPageHandler_Get(t *testing.T) {
+	setupTestDB(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/products?limit=1", nil)
+	w := httptest.NewRecorder()
+
+	ProductsPageHandler(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var page PageResponse[models.Product]
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&page))
+	assert.Equal(t, 1, page.Page)
+	assert.Equal(t, 1, page.Limit)
+	assert.LessOrEqual(t, len(page.Items), 1)
+	if page.Total < 2 {
+		t.Skip("Not enough products to follow a cursor")
+	}
+	require.NotEmpty(t, page.NextCursor)
+
+	// Follow the cursor
+	req = httptest.NewRequest(http.MethodGet, "/v2/products?limit=1&cursor="+page.NextCursor, nil)
+	w = httptest.NewRecorder()
+	ProductsPageHandler(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var next PageResponse[models.Product]
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&next))
+	require.Len(t, next.Items, 1)
+	assert.Zero(t, next.Page)
+	assert.NotEqual(t, page.Items[0].ID, next.Items[0].ID)
+}
+
+func TestProductsPageHandler_InvalidCursor(t *testing.T) {
+	setupTestDB(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/products?cursor=garbage", nil)
+	w := httptest.NewRecorder()
+
+	ProductsPageHandler(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListPageHandlers_Get(t *testing.T) {
+	setupTestDB(t)
+
+	tests := []struct {
+		name    string
+		path    string
+		handler http.HandlerFunc
+	}{
+		{"categories", "/v2/products/categories", CategoriesPageHandler},
+		{"manufacturers", "/v2/products/manufacturers", ManufacturersPageHandler},
+		{"orders", "/v2/orders?status=new", OrdersPageHandler},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			tt.handler(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var page PageResponse[json.RawMessage]
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&page))
+			assert.Equal(t, defaultPageLimit, page.Limit)
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package crud
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/xuri/excelize/v2"
+
+	"noble-group-services/models"
+)
+
+// exportFlushRows is how many rows are buffered before a flush to the client.
+const exportFlushRows = 500
+
+// productExportLine is a product in a JSONL export. Keys match the product
+// sheet columns so the file imports back unchanged.
+type productExportLine struct {
+	SKU          string                 `json:"sku"`
+	Name         string                 `json:"name"`
+	Slug         string                 `json:"slug"`
+	Price        int                    `json:"price"`
+	OldPrice     *int                   `json:"oldPrice"`
+	Description  string                 `json:"description"`
+	Features     models.JSONStringArray `json:"features"`
+	Image        models.JSONStringArray `json:"image"`
+	Stock        int                    `json:"stock"`
+	Availability string                 `json:"availability"`
+	Manufacturer string                 `json:"manufacturer"`
+	Category     string                 `json:"category"`
+
+	LowStockThreshold int     `json:"lowStockThreshold"`
+	AllowBackorder    bool    `json:"allowBackorder"`
+	RestockDate       *string `json:"restockDate"`
+}
+
+// ProductExportHandler handles GET /admin/products/export
+func ProductExportHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ExportProducts(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// ExportProducts godoc
+// @Summary Export products
+// @Description Stream every product matching the GET /products filters as a sheet that POST /admin/products/import reads back. Columns: sku, name, slug, price, oldPrice, description, features, image, stock, availability, lowStockThreshold, allowBackorder, restockDate, manufacturer, category. manufacturer and category hold slugs; features and image hold JSON arrays. In CSV and XLSX, text cells starting with =, +, - or @ are prefixed with ' so that spreadsheet programs do not run them as formulas; the import removes it.
+// @Tags admin
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
+// @Param format query string false "File format" Enums(csv, xlsx, jsonl) default(csv)
+// @Param category query string false "Category Slug, includes subcategories"
+// @Param manufacturer query string false "Manufacturer Slug"
+// @Param search query string false "Search term"
+// @Param inStockOnly query bool false "Only in_stock and low_stock products"
+// @Param sort query string false "Sort order" Enums(name, price_asc, price_desc, rating, newest, popularity, discount, relevance)
+// @Success 200 {file} file "Product sheet"
+// @Failure 400 {string} string "Invalid format or sort"
+// @Router /admin/products/export [get]
+func ExportProducts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	var contentType string
+	switch format {
+	case "csv":
+		contentType = csvContentType + "; charset=utf-8"
+	case "xlsx":
+		contentType = xlsxContentType
+	case "jsonl":
+		contentType = jsonlContentType
+	default:
+		http.Error(w, "Invalid format", http.StatusBadRequest)
+		return
+	}
+
+	lq, err := productListQuery(query)
+	if err != nil {
+		writeListQueryError(w, err)
+		return
+	}
+
+	rows, err := db.Queryx(`SELECT `+lq.Columns+` FROM `+lq.From+` WHERE true`+lq.Where+orderByClause(lq.Keys), lq.Args...)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	filename := "products-" + time.Now().Format("20060102") + "." + format
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+
+	next := productRows(rows)
+
+	switch format {
+	case "csv":
+		err = exportCSV(w, next)
+	case "xlsx":
+		err = exportXLSX(w, next)
+	case "jsonl":
+		err = exportJSONL(w, next)
+	}
+	// The status line is already sent; cutting the stream short is all that
+	// is left, and the client sees a truncated file.
+	if err != nil {
+		log.Printf("product export: %v", err)
+	}
+}
+
+// productIterator yields products one at a time. ok is false once there are
+// no more.
+type productIterator func() (p models.Product, ok bool, err error)
+
+// productRows iterates over rows selecting productListColumns.
+func productRows(rows *sqlx.Rows) productIterator {
+	return func() (models.Product, bool, error) {
+		var p models.Product
+		if !rows.Next() {
+			return p, false, rows.Err()
+		}
+		err := rows.StructScan(&p)
+		return p, err == nil, err
+	}
+}
+
+// productSheetRecord returns the cells of p in productSheetColumns order.
+func productSheetRecord(p models.Product) []string {
+	oldPrice := ""
+	if p.OldPrice != nil {
+		oldPrice = strconv.Itoa(*p.OldPrice)
+	}
+	return []string{
+		sheetText(p.SKU), sheetText(p.Name), sheetText(p.Slug), strconv.Itoa(p.Price), oldPrice, sheetText(p.Description),
+		listCell(p.Features), listCell(p.Image), strconv.Itoa(p.Stock), p.Availability,
+		strconv.Itoa(p.LowStockThreshold), strconv.FormatBool(p.AllowBackorder), dateCell(p.RestockDate),
+		sheetText(p.Manufacturer.Slug), sheetText(p.Category.Slug),
+	}
+}
+
+// sheetFormulaPrefixes are the first characters that make spreadsheet
+// programs run a cell as a formula, and the ' that marks a cell as text.
+const sheetFormulaPrefixes = "=+-@\t\r'"
+
+// sheetText guards a text cell against formula injection by prefixing cells
+// that start with one of sheetFormulaPrefixes with '. readSheet removes the
+// prefix again.
+func sheetText(s string) string {
+	if s != "" && strings.IndexByte(sheetFormulaPrefixes, s[0]) >= 0 {
+		return "'" + s
+	}
+	return s
+}
+
+// dateCell writes a date as 2006-01-02, empty when there is none.
+func dateCell(d *time.Time) string {
+	if d == nil {
+		return ""
+	}
+	return d.Format(time.DateOnly)
+}
+
+// listCell writes a list as a JSON array cell, empty when there is nothing.
+func listCell(list models.JSONStringArray) string {
+	if len(list) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+func productExportRecord(p models.Product) productExportLine {
+	line := productExportLine{
+		SKU: p.SKU, Name: p.Name, Slug: p.Slug, Price: p.Price, OldPrice: p.OldPrice,
+		Description: p.Description, Features: p.Features, Image: p.Image, Stock: p.Stock,
+		Availability: p.Availability, Manufacturer: p.Manufacturer.Slug, Category: p.Category.Slug,
+		LowStockThreshold: p.LowStockThreshold, AllowBackorder: p.AllowBackorder,
+	}
+	if p.RestockDate != nil {
+		d := dateCell(p.RestockDate)
+		line.RestockDate = &d
+	}
+	if line.Features == nil {
+		line.Features = models.JSONStringArray{}
+	}
+	if line.Image == nil {
+		line.Image = models.JSONStringArray{}
+	}
+	return line
+}
+
+// flushExport sends buffered output to the client when w supports it.
+func flushExport(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// exportCSV writes a comma separated sheet with a UTF-8 BOM, which spreadsheet
+// programs need to detect the encoding of Cyrillic names.
+func exportCSV(w http.ResponseWriter, next productIterator) error {
+	if _, err := w.Write([]byte("\xef\xbb\xbf")); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(productSheetColumns); err != nil {
+		return err
+	}
+	for n := 1; ; n++ {
+		p, ok, err := next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		if err := cw.Write(productSheetRecord(p)); err != nil {
+			return err
+		}
+		if n%exportFlushRows == 0 {
+			cw.Flush()
+			flushExport(w)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// exportJSONL writes one productExportLine per line.
+func exportJSONL(w http.ResponseWriter, next productIterator) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	for n := 1; ; n++ {
+		p, ok, err := next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		if err := enc.Encode(productExportRecord(p)); err != nil {
+			return err
+		}
+		if n%exportFlushRows == 0 {
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+			flushExport(w)
+		}
+	}
+	return bw.Flush()
+}
+
+// exportXLSX writes a single worksheet. An XLSX file is a zip archive that
+// can only be sent once complete; the stream writer keeps memory bounded by
+// spilling rows to a temporary file while the sheet is built.
+func exportXLSX(w http.ResponseWriter, next productIterator) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sw, err := f.NewStreamWriter("Sheet1")
+	if err != nil {
+		return err
+	}
+	header := make([]interface{}, len(productSheetColumns))
+	for i, c := range productSheetColumns {
+		header[i] = c
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	for n := 2; ; n++ {
+		p, ok, err := next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		record := productSheetRecord(p)
+		cells := make([]interface{}, len(record))
+		for i, v := range record {
+			cells[i] = v
+		}
+		// Numbers stay numeric so the sheet sorts and sums in Excel.
+		cells[3] = p.Price
+		if p.OldPrice != nil {
+			cells[4] = *p.OldPrice
+		}
+		cells[8] = p.Stock
+
+		cell, err := excelize.CoordinatesToCellName(1, n)
+		if err != nil {
+			return err
+		}
+		if err := sw.SetRow(cell, cells); err != nil {
+			return err
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	_, err = f.WriteTo(w)
+	return err
+}
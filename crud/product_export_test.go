@@ -0,0 +1,166 @@
+package crud
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"noble-group-services/models"
+)
+
+// exportProducts feeds ps to an export writer the way ExportProducts feeds
+// database rows.
+func exportProducts(ps ...models.Product) productIterator {
+	return func() (models.Product, bool, error) {
+		if len(ps) == 0 {
+			return models.Product{}, false, nil
+		}
+		p := ps[0]
+		ps = ps[1:]
+		return p, true, nil
+	}
+}
+
+func exportTestProduct() models.Product {
+	oldPrice := 1500
+	restock := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	return models.Product{
+		SKU: "A-1", Name: "=Кабель, 2 м", Slug: "kabel-2-m", Price: 1200, OldPrice: &oldPrice,
+		Description: "Медный \"ПВС\"\nв бухте", Features: models.JSONStringArray{"2 м", "a|b"},
+		Stock: 7, Availability: "in_stock", LowStockThreshold: 2, RestockDate: &restock,
+		Manufacturer: models.Manufacturer{Slug: "kz-kabel"}, Category: models.Category{Slug: "cables"},
+	}
+}
+
+// sheetValues maps the columns of an exported sheet to the cells of its only
+// data row.
+func sheetValues(t *testing.T, rows [][]string) map[string]string {
+	t.Helper()
+	require.Len(t, rows, 2)
+	cols, ignored := productSheetHeader(rows[0])
+	require.Empty(t, ignored)
+	values := make(map[string]string, len(cols))
+	for c, i := range cols {
+		if i < len(rows[1]) {
+			values[c] = rows[1][i]
+		}
+	}
+	return values
+}
+
+func TestExportProducts_RoundTrip(t *testing.T) {
+	want := map[string]string{
+		"sku": "A-1", "name": "=Кабель, 2 м", "slug": "kabel-2-m", "price": "1200", "oldPrice": "1500",
+		"description": "Медный \"ПВС\"\nв бухте", "features": `["2 м","a|b"]`, "image": "",
+		"stock": "7", "availability": "in_stock", "lowStockThreshold": "2", "allowBackorder": "false",
+		"restockDate": "2026-11-02", "manufacturer": "kz-kabel", "category": "cables",
+	}
+
+	for _, format := range []string{"csv", "xlsx", "jsonl"} {
+		t.Run(format, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			var err error
+			switch format {
+			case "csv":
+				err = exportCSV(w, exportProducts(exportTestProduct()))
+			case "xlsx":
+				err = exportXLSX(w, exportProducts(exportTestProduct()))
+			case "jsonl":
+				err = exportJSONL(w, exportProducts(exportTestProduct()))
+			}
+			require.NoError(t, err)
+
+			rows, err := readSheet(w.Body, sheetFormat("products."+format, ""))
+			require.NoError(t, err)
+			values := sheetValues(t, rows)
+			if format == "jsonl" {
+				// Empty lists are exported as [] rather than an empty cell.
+				assert.Equal(t, "[]", values["image"])
+				values["image"] = ""
+			}
+			assert.Equal(t, want, values)
+
+			features, err := parseImportList(values["features"])
+			require.NoError(t, err)
+			assert.Equal(t, models.JSONStringArray{"2 м", "a|b"}, features)
+		})
+	}
+}
+
+func TestSheetText(t *testing.T) {
+	for _, s := range []string{"", "Кабель", "a=b", "=1+1", "+7 700", "-5", "@SUM(A1)", "'quoted", "'=x", "\t=x"} {
+		guarded := sheetText(s)
+		if s != "" && strings.ContainsRune("=+-@'\t", rune(s[0])) {
+			assert.Equal(t, "'"+s, guarded)
+		} else {
+			assert.Equal(t, s, guarded)
+		}
+		assert.Equal(t, s, unguardSheetText(guarded), s)
+	}
+	// A ' typed before other text is kept
+	assert.Equal(t, "'quoted", unguardSheetText("'quoted"))
+
+	w := httptest.NewRecorder()
+	require.NoError(t, exportCSV(w, exportProducts(exportTestProduct())))
+	assert.Contains(t, w.Body.String(), `,"'=Кабель, 2 м",`)
+}
+
+func TestReadJSONLSheet(t *testing.T) {
+	rows, err := readJSONLSheet(strings.NewReader(`{"price": 900, "sku": "A-1", "oldPrice": null, "color": "red"}` + "\n\n" +
+		`{"sku": "A-2", "features": ["x"]}` + "\n"))
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"sku", "price", "oldPrice", "features", "color"},
+		{"A-1", "900", "", "", "red"},
+		{"A-2", "", "", `["x"]`, ""},
+	}, rows)
+
+	_, err = readJSONLSheet(strings.NewReader(""))
+	assert.ErrorIs(t, err, errEmptyImport)
+	_, err = readJSONLSheet(strings.NewReader(`{"sku": "A-1"}` + "\nnot json\n"))
+	assert.Error(t, err)
+}
+
+func TestProductExportHandler(t *testing.T) {
+	setupTestDB(t)
+
+	var p models.Product
+	require.NoError(t, db.Get(&p, productDetailSelect+` ORDER BY p.name LIMIT 1`))
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/products/export?format=jsonl&manufacturer="+p.Manufacturer.Slug, nil)
+	w := httptest.NewRecorder()
+	ProductExportHandler(w, req)
+	require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+	assert.Equal(t, jsonlContentType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".jsonl")
+
+	rows, err := readJSONLSheet(w.Body)
+	require.NoError(t, err)
+	cols, _ := productSheetHeader(rows[0])
+	var found bool
+	for _, row := range rows[1:] {
+		assert.Equal(t, p.Manufacturer.Slug, row[cols["manufacturer"]])
+		found = found || row[cols["sku"]] == p.SKU
+	}
+	assert.True(t, found, "exported rows should include %s", p.SKU)
+
+	// Exported sheets import back without changes
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+	defer tx.Rollback()
+	report, err := importProducts(tx, rows, false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Errors)
+	assert.Zero(t, report.Created)
+	assert.Equal(t, len(rows)-1, report.Updated)
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/products/export?format=pdf", nil)
+	w = httptest.NewRecorder()
+	ProductExportHandler(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
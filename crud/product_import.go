@@ -0,0 +1,746 @@
+package crud
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/xuri/excelize/v2"
+
+	"noble-group-services/models"
+)
+
+// maxImportSize bounds uploaded import files.
+const maxImportSize = 20 << 20
+
+const (
+	csvContentType   = "text/csv"
+	xlsxContentType  = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	jsonlContentType = "application/x-ndjson"
+)
+
+var (
+	errUnsupportedImportFormat = errors.New("unsupported file format, expected CSV, XLSX or JSONL")
+	errEmptyImport             = errors.New("file has no header row")
+	errMissingSKUColumn        = errors.New("sku column is required")
+)
+
+// productSheetColumns are the columns of a product sheet, in export order.
+// Headers match case-insensitively, ignoring spaces, "_" and "-".
+// manufacturer and category hold an ID, a slug or a name.
+var productSheetColumns = []string{
+	"sku", "name", "slug", "price", "oldPrice", "description", "features", "image",
+	"stock", "availability", "lowStockThreshold", "allowBackorder", "restockDate",
+	"manufacturer", "category",
+}
+
+// productSheetAliases maps alternative headers to product sheet columns.
+var productSheetAliases = map[string]string{
+	"images":         "image",
+	"manufacturerid": "manufacturer",
+	"categoryid":     "category",
+}
+
+// ProductImportReport summarizes an import. When Errors is not empty nothing
+// was stored.
+type ProductImportReport struct {
+	DryRun               bool                 `json:"dryRun"`
+	Rows                 int                  `json:"rows"`
+	Created              int                  `json:"created"`
+	Updated              int                  `json:"updated"`
+	CreatedManufacturers []string             `json:"createdManufacturers,omitempty"`
+	CreatedCategories    []string             `json:"createdCategories,omitempty"`
+	IgnoredColumns       []string             `json:"ignoredColumns,omitempty"`
+	Errors               []ProductImportError `json:"errors"`
+}
+
+// ProductImportError is a problem with one sheet row. Row counts from 1 for the
+// header, like spreadsheet row numbers.
+type ProductImportError struct {
+	Row     int    `json:"row"`
+	SKU     string `json:"sku,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ProductImportHandler handles POST /admin/products/import
+func ProductImportHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		ImportProducts(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// ImportProducts godoc
+// @Summary Import products
+// @Description Upsert products by SKU from a CSV, XLSX or JSONL sheet, sent as the "file" form field or as the raw body. The first row, or the keys of each JSONL object, names the columns: sku, name, slug, price, oldPrice, description, features, image, stock, availability, lowStockThreshold, allowBackorder, restockDate, manufacturer, category; other columns are ignored. availability is derived from stock and only checked. restockDate is written as 2006-01-02 or 02.01.2006. manufacturer and category match an ID, a slug or a name; manufacturerId and categoryId are accepted as their headers. features and image hold a JSON array or "|"-separated values. Columns missing from the sheet keep their stored values. All rows are applied in one transaction: if any row fails nothing is stored and the report lists every error.
+// @Tags admin
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV, XLSX or JSONL file"
+// @Param dryRun query bool false "Validate and report without storing"
+// @Param createMissing query bool false "Create unknown manufacturers and categories"
+// @Success 200 {object} ProductImportReport
+// @Failure 400 {string} string "Invalid file"
+// @Failure 422 {object} ProductImportReport
+// @Router /admin/products/import [post]
+func ImportProducts(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	rows, err := readImportSheet(r)
+	if err != nil {
+		http.Error(w, "Invalid file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	dryRun := query.Get("dryRun") == "true"
+	createMissing := query.Get("createMissing") == "true"
+
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	report, err := importProducts(tx, rows, createMissing)
+	if errors.Is(err, errEmptyImport) || errors.Is(err, errMissingSKUColumn) {
+		http.Error(w, "Invalid file: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	report.DryRun = dryRun
+
+	status := http.StatusOK
+	if len(report.Errors) > 0 {
+		status = http.StatusUnprocessableEntity
+	} else if !dryRun {
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		catalogCache.invalidate("products", "")
+		wakeSubscriptionNotifier()
+		if len(report.CreatedManufacturers) > 0 {
+			catalogCache.invalidate("manufacturers", "")
+		}
+		if len(report.CreatedCategories) > 0 {
+			catalogCache.invalidate("categories", "")
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// readImportSheet reads the rows of the uploaded sheet, taken from the "file"
+// form field of a multipart request or from the request body.
+func readImportSheet(r *http.Request) ([][]string, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return readSheet(r.Body, sheetFormat("", mediaType))
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	partType, _, _ := mime.ParseMediaType(header.Header.Get("Content-Type"))
+	return readSheet(file, sheetFormat(header.Filename, partType))
+}
+
+// sheetFormat picks "csv", "xlsx" or "jsonl" from a file name or media type.
+func sheetFormat(filename, mediaType string) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return "csv"
+	case ".xlsx":
+		return "xlsx"
+	case ".jsonl", ".ndjson":
+		return "jsonl"
+	}
+	switch mediaType {
+	case csvContentType, "application/csv":
+		return "csv"
+	case xlsxContentType:
+		return "xlsx"
+	case jsonlContentType, "application/jsonl", "application/x-jsonlines":
+		return "jsonl"
+	}
+	return ""
+}
+
+func readSheet(r io.Reader, format string) ([][]string, error) {
+	var rows [][]string
+	var err error
+	switch format {
+	case "csv":
+		rows, err = readCSVSheet(r)
+	case "xlsx":
+		rows, err = readXLSXSheet(r)
+	case "jsonl":
+		return readJSONLSheet(r)
+	default:
+		return nil, errUnsupportedImportFormat
+	}
+	// Spreadsheet cells may carry the formula guard of sheetText
+	for _, row := range rows {
+		for i, c := range row {
+			row[i] = unguardSheetText(c)
+		}
+	}
+	return rows, err
+}
+
+// unguardSheetText removes the ' that sheetText, or a spreadsheet user,
+// put before a cell starting with one of sheetFormulaPrefixes.
+func unguardSheetText(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.IndexByte(sheetFormulaPrefixes, s[1]) >= 0 {
+		return s[1:]
+	}
+	return s
+}
+
+// readCSVSheet reads comma or semicolon separated rows; spreadsheet programs
+// in locales with a decimal comma export the latter.
+func readCSVSheet(r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	header := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		header = data[:i]
+	}
+
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.FieldsPerRecord = -1
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		cr.Comma = ';'
+	}
+	return cr.ReadAll()
+}
+
+// readXLSXSheet reads the rows of the first worksheet.
+func readXLSXSheet(r io.Reader) ([][]string, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errEmptyImport
+	}
+	return f.GetRows(sheets[0])
+}
+
+// readJSONLSheet turns one JSON object per line into sheet rows. The header
+// lists the product sheet columns found in any line, in export order, followed
+// by the other keys. Strings are taken as is, null as an empty cell and other
+// values, such as numbers and arrays, as their JSON text.
+func readJSONLSheet(r io.Reader) ([][]string, error) {
+	var lines []map[string]json.RawMessage
+	seen := make(map[string]bool)
+	var extra []string
+
+	dec := json.NewDecoder(r)
+	for {
+		var line map[string]json.RawMessage
+		err := dec.Decode(&line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", len(lines)+1, err)
+		}
+		for k := range line {
+			if !seen[k] {
+				seen[k] = true
+				extra = append(extra, k)
+			}
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return nil, errEmptyImport
+	}
+
+	var header []string
+	for _, c := range productSheetColumns {
+		if seen[c] {
+			header = append(header, c)
+		}
+	}
+	sort.Strings(extra)
+	for _, k := range extra {
+		if !slices.Contains(productSheetColumns, k) {
+			header = append(header, k)
+		}
+	}
+
+	rows := make([][]string, 0, len(lines)+1)
+	rows = append(rows, header)
+	for _, line := range lines {
+		row := make([]string, len(header))
+		for i, k := range header {
+			row[i] = jsonCell(line[k])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// jsonCell is the sheet cell text of a JSON value.
+func jsonCell(v json.RawMessage) string {
+	v = bytes.TrimSpace(v)
+	if len(v) == 0 || string(v) == "null" {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(v, &s) == nil {
+		return s
+	}
+	return string(v)
+}
+
+// normalizeHeader folds a column header for matching.
+func normalizeHeader(h string) string {
+	h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(h)
+}
+
+// productSheetHeader maps product sheet columns to their index in header and
+// lists the headers it does not know.
+func productSheetHeader(header []string) (map[string]int, []string) {
+	known := make(map[string]string, len(productSheetColumns)+len(productSheetAliases))
+	for _, c := range productSheetColumns {
+		known[normalizeHeader(c)] = c
+	}
+	for alias, c := range productSheetAliases {
+		known[alias] = c
+	}
+
+	cols := make(map[string]int)
+	var ignored []string
+	for i, h := range header {
+		c, ok := known[normalizeHeader(h)]
+		if _, dup := cols[c]; !ok || dup {
+			if strings.TrimSpace(h) != "" {
+				ignored = append(ignored, h)
+			}
+			continue
+		}
+		cols[c] = i
+	}
+	return cols, ignored
+}
+
+// importProducts upserts the product rows of a sheet inside tx. Each row runs
+// in a savepoint so that a failing row is reported and the following rows are
+// still checked. tx must be rolled back when the report has errors.
+func importProducts(tx *sqlx.Tx, rows [][]string, createMissing bool) (*ProductImportReport, error) {
+	if len(rows) == 0 {
+		return nil, errEmptyImport
+	}
+	cols, ignored := productSheetHeader(rows[0])
+	if _, ok := cols["sku"]; !ok {
+		return nil, errMissingSKUColumn
+	}
+
+	report := &ProductImportReport{IgnoredColumns: ignored, Errors: []ProductImportError{}}
+	refs := &importRefs{tx: tx, createMissing: createMissing, cache: map[string]string{}}
+	seen := make(map[string]int)
+
+	for i, cells := range rows[1:] {
+		row := productSheetRow{num: i + 2, cols: cols, cells: cells}
+		if row.blank() {
+			continue
+		}
+		report.Rows++
+
+		sku, _ := row.get("sku")
+		if sku == "" {
+			report.Errors = append(report.Errors, ProductImportError{Row: row.num, Field: "sku", Message: "SKU is required"})
+			continue
+		}
+		if first, ok := seen[sku]; ok {
+			report.Errors = append(report.Errors, ProductImportError{
+				Row: row.num, SKU: sku, Field: "sku", Message: fmt.Sprintf("Duplicate SKU, first used on row %d", first),
+			})
+			continue
+		}
+		seen[sku] = row.num
+
+		if _, err := tx.Exec(`SAVEPOINT import_row`); err != nil {
+			return nil, err
+		}
+		created, rowErrs, err := importProductRow(tx, refs, row, sku)
+		if err != nil {
+			return nil, err
+		}
+		if len(rowErrs) > 0 {
+			if _, err := tx.Exec(`ROLLBACK TO SAVEPOINT import_row`); err != nil {
+				return nil, err
+			}
+			refs.discard()
+			report.Errors = append(report.Errors, rowErrs...)
+			continue
+		}
+		if _, err := tx.Exec(`RELEASE SAVEPOINT import_row`); err != nil {
+			return nil, err
+		}
+
+		report.CreatedManufacturers = append(report.CreatedManufacturers, refs.pendingNames[manufacturerSlugs.Type]...)
+		report.CreatedCategories = append(report.CreatedCategories, refs.pendingNames[categorySlugs.Type]...)
+		refs.keep()
+		if created {
+			report.Created++
+		} else {
+			report.Updated++
+		}
+	}
+
+	return report, nil
+}
+
+// productSheetRow is one data row of a product sheet.
+type productSheetRow struct {
+	num   int
+	cols  map[string]int
+	cells []string
+}
+
+// get returns the trimmed cell of column c and whether the sheet has c.
+func (r productSheetRow) get(c string) (string, bool) {
+	i, ok := r.cols[c]
+	if !ok {
+		return "", false
+	}
+	if i >= len(r.cells) {
+		return "", true
+	}
+	return strings.TrimSpace(r.cells[i]), true
+}
+
+func (r productSheetRow) blank() bool {
+	for _, c := range r.cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// importProductRow creates or updates the product with sku from row. Problems
+// with the row are returned as rowErrs; err is reserved for failures that
+// abort the whole import.
+func importProductRow(tx *sqlx.Tx, refs *importRefs, row productSheetRow, sku string) (created bool, rowErrs []ProductImportError, err error) {
+	fail := func(field, message string) {
+		rowErrs = append(rowErrs, ProductImportError{Row: row.num, SKU: sku, Field: field, Message: message})
+	}
+
+	var p models.Product
+	err = tx.Get(&p, `SELECT * FROM products WHERE sku = $1 FOR UPDATE`, sku)
+	created = errors.Is(err, sql.ErrNoRows)
+	if err != nil && !created {
+		return false, nil, err
+	}
+	if created {
+		now := time.Now()
+		p = models.Product{
+			ID:        uuid.New().String(),
+			SKU:       sku,
+			Features:  models.JSONStringArray{},
+			Image:     models.JSONStringArray{},
+			CreatedAt: now,
+			UpdatedAt: now,
+			Version:   1,
+		}
+	}
+	oldSlug := p.Slug
+
+	if v, ok := row.get("name"); ok {
+		p.Name = v
+	}
+	if v, ok := row.get("slug"); ok {
+		p.Slug = v
+	}
+	if v, ok := row.get("description"); ok {
+		p.Description = v
+	}
+	// Availability is derived; the column is only checked
+	if v, ok := row.get("availability"); ok {
+		p.Availability = v
+	}
+	for _, c := range []struct {
+		name string
+		dst  *int
+	}{{"price", &p.Price}, {"stock", &p.Stock}, {"lowStockThreshold", &p.LowStockThreshold}} {
+		if v, ok := row.get(c.name); ok {
+			n, perr := parseImportInt(v)
+			if perr != nil {
+				fail(c.name, perr.Error())
+				continue
+			}
+			*c.dst = n
+		}
+	}
+	if v, ok := row.get("oldPrice"); ok {
+		p.OldPrice = nil
+		if v != "" {
+			n, perr := parseImportInt(v)
+			if perr != nil {
+				fail("oldPrice", perr.Error())
+			} else {
+				p.OldPrice = &n
+			}
+		}
+	}
+	if v, ok := row.get("allowBackorder"); ok {
+		b, perr := parseImportBool(v)
+		if perr != nil {
+			fail("allowBackorder", perr.Error())
+		} else {
+			p.AllowBackorder = b
+		}
+	}
+	if v, ok := row.get("restockDate"); ok {
+		d, perr := parseImportDate(v)
+		if perr != nil {
+			fail("restockDate", perr.Error())
+		} else {
+			p.RestockDate = d
+		}
+	}
+	for _, c := range []struct {
+		name string
+		dst  *models.JSONStringArray
+	}{{"features", &p.Features}, {"image", &p.Image}} {
+		if v, ok := row.get(c.name); ok {
+			list, perr := parseImportList(v)
+			if perr != nil {
+				fail(c.name, perr.Error())
+				continue
+			}
+			*c.dst = list
+		}
+	}
+
+	for _, ref := range []struct {
+		field string
+		e     slugEntity
+		dst   *string
+	}{{"manufacturer", manufacturerSlugs, &p.ManufacturerID}, {"category", categorySlugs, &p.CategoryID}} {
+		v, ok := row.get(ref.field)
+		if !ok || v == "" {
+			continue
+		}
+		id, rerr := refs.resolve(ref.e, v)
+		if errors.Is(rerr, errImportRefNotFound) {
+			fail(ref.field, fmt.Sprintf("Unknown %s: %s", ref.e.Type, v))
+			continue
+		}
+		if rerr != nil {
+			return false, nil, rerr
+		}
+		*ref.dst = id
+	}
+
+	if created {
+		if p.Slug, err = resolveSlug(tx, productSlugs, p.Slug, p.Name); err != nil {
+			return false, nil, err
+		}
+	} else {
+		p.Slug = slugify(p.Slug)
+	}
+
+	for _, d := range validateProduct(p) {
+		fail(d.Field, d.Message)
+	}
+	if len(rowErrs) > 0 {
+		return created, rowErrs, nil
+	}
+
+	if created {
+		err = insertProductRow(tx, &p)
+	} else {
+		err = updateProductRow(tx, p)
+	}
+	if isUniqueViolation(err) {
+		fail("slug", conflictMessage(err))
+		return created, rowErrs, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+
+	if created {
+		err = claimSlug(tx, productSlugs, p.Slug)
+	} else if err = recordSlugChange(tx, productSlugs, p.ID, oldSlug, p.Slug); err == nil {
+		// Products with variants or warehouse levels keep the derived
+		// price and stock.
+		err = syncStock(tx, p.ID)
+	}
+	return created, nil, err
+}
+
+// parseImportInt parses a whole number cell, allowing digit group separators
+// and a zero fraction as spreadsheets format them ("1 299 000", "1299000.00").
+func parseImportInt(v string) (int, error) {
+	v = strings.NewReplacer(" ", "", "\u00a0", "", "\u202f", "").Replace(v)
+	if v == "" {
+		return 0, errors.New("Value is required")
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(strings.Replace(v, ",", ".", 1), 64)
+	if err != nil || f != float64(int(f)) {
+		return 0, fmt.Errorf("Not a whole number: %s", v)
+	}
+	return int(f), nil
+}
+
+// parseImportBool parses a yes/no cell. Empty cells are false.
+func parseImportBool(v string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "false", "0", "no", "нет":
+		return false, nil
+	case "true", "1", "yes", "да":
+		return true, nil
+	}
+	return false, fmt.Errorf("Not a yes/no value: %s", v)
+}
+
+// parseImportDate parses a date cell written as 2006-01-02 or 02.01.2006.
+// Empty cells have no date.
+func parseImportDate(v string) (*time.Time, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.DateOnly, "02.01.2006"} {
+		if d, err := time.Parse(layout, v); err == nil {
+			return &d, nil
+		}
+	}
+	return nil, fmt.Errorf("Not a date: %s", v)
+}
+
+// parseImportList parses a JSON array cell, or values separated by "|".
+func parseImportList(v string) (models.JSONStringArray, error) {
+	list := models.JSONStringArray{}
+	if strings.HasPrefix(v, "[") {
+		if err := json.Unmarshal([]byte(v), &list); err != nil {
+			return nil, errors.New("Invalid JSON array")
+		}
+		return list, nil
+	}
+	for _, item := range strings.Split(v, "|") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list, nil
+}
+
+var errImportRefNotFound = errors.New("not found")
+
+// importRefs resolves manufacturer and category cells to ids, creating
+// missing ones when allowed. Rows created for the current sheet row stay
+// pending until keep or discard follows the row's savepoint.
+type importRefs struct {
+	tx            *sqlx.Tx
+	createMissing bool
+	cache         map[string]string
+	pending       []string
+	pendingNames  map[string][]string
+}
+
+// resolve returns the id of the e row whose id, slug or name
+// (case-insensitively) is value. ID matches win, then slug matches.
+func (ir *importRefs) resolve(e slugEntity, value string) (string, error) {
+	key := e.Type + ":" + strings.ToLower(value)
+	if id, ok := ir.cache[key]; ok {
+		return id, nil
+	}
+
+	var id string
+	err := ir.tx.Get(&id, `
+		SELECT id FROM `+e.Table+` WHERE id = $1 OR slug = $1 OR LOWER(name) = LOWER($1)
+		ORDER BY id = $1 DESC, slug = $1 DESC, id LIMIT 1
+	`, value)
+	if errors.Is(err, sql.ErrNoRows) {
+		if !ir.createMissing {
+			return "", errImportRefNotFound
+		}
+		id, err = ir.create(e, value)
+		if err != nil {
+			return "", err
+		}
+		ir.pending = append(ir.pending, key)
+		if ir.pendingNames == nil {
+			ir.pendingNames = make(map[string][]string)
+		}
+		ir.pendingNames[e.Type] = append(ir.pendingNames[e.Type], value)
+	} else if err != nil {
+		return "", err
+	}
+
+	ir.cache[key] = id
+	return id, nil
+}
+
+// create inserts a e row named name, at the root for categories.
+func (ir *importRefs) create(e slugEntity, name string) (string, error) {
+	slug, err := resolveSlug(ir.tx, e, "", name)
+	if err != nil {
+		return "", err
+	}
+	id := uuid.New().String()
+	if _, err := ir.tx.Exec(`INSERT INTO `+e.Table+` (id, name, slug) VALUES ($1, $2, $3)`, id, name, slug); err != nil {
+		return "", err
+	}
+	return id, claimSlug(ir.tx, e, slug)
+}
+
+// keep makes the rows created for the current sheet row reusable.
+func (ir *importRefs) keep() {
+	ir.pending = nil
+	ir.pendingNames = nil
+}
+
+// discard forgets rows created for a sheet row that was rolled back.
+func (ir *importRefs) discard() {
+	for _, key := range ir.pending {
+		delete(ir.cache, key)
+	}
+	ir.keep()
+}
@@ -0,0 +1,176 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"noble-group-services/models"
+)
+
+func TestReadCSVSheet_Semicolons(t *testing.T) {
+	rows, err := readCSVSheet(strings.NewReader("\xef\xbb\xbfsku;name;price\nA-1;\"Кабель; 2 м\";1 500\n"))
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"sku", "name", "price"}, {"A-1", "Кабель; 2 м", "1 500"}}, rows)
+}
+
+func TestReadXLSXSheet(t *testing.T) {
+	f := excelize.NewFile()
+	require.NoError(t, f.SetSheetRow("Sheet1", "A1", &[]interface{}{"SKU", "Price"}))
+	require.NoError(t, f.SetSheetRow("Sheet1", "A2", &[]interface{}{"A-1", 1500}))
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+
+	rows, err := readSheet(&buf, sheetFormat("catalog.XLSX", ""))
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"SKU", "Price"}, {"A-1", "1500"}}, rows)
+}
+
+func TestProductSheetHeader(t *testing.T) {
+	cols, ignored := productSheetHeader([]string{"SKU", "Old Price", "old_price", "images", "Rating", ""})
+
+	assert.Equal(t, map[string]int{"sku": 0, "oldPrice": 1, "image": 3}, cols)
+	assert.Equal(t, []string{"old_price", "Rating"}, ignored)
+}
+
+func TestParseImportInt(t *testing.T) {
+	for in, want := range map[string]int{"1500": 1500, "1 299 000": 1299000, "1 500": 1500, "1500.00": 1500, "1500,0": 1500} {
+		n, err := parseImportInt(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, n, in)
+	}
+	for _, in := range []string{"", "12.5", "abc"} {
+		_, err := parseImportInt(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestParseImportList(t *testing.T) {
+	list, err := parseImportList(`["a|b", "c"]`)
+	require.NoError(t, err)
+	assert.Equal(t, models.JSONStringArray{"a|b", "c"}, list)
+
+	list, err = parseImportList("a | b||")
+	require.NoError(t, err)
+	assert.Equal(t, models.JSONStringArray{"a", "b"}, list)
+
+	list, err = parseImportList("")
+	require.NoError(t, err)
+	assert.Equal(t, models.JSONStringArray{}, list)
+}
+
+func TestParseImportBool(t *testing.T) {
+	for _, v := range []string{"true", "1", " Да "} {
+		b, err := parseImportBool(v)
+		require.NoError(t, err, v)
+		assert.True(t, b, v)
+	}
+	b, err := parseImportBool("")
+	require.NoError(t, err)
+	assert.False(t, b)
+	_, err = parseImportBool("maybe")
+	assert.Error(t, err)
+}
+
+func TestParseImportDate(t *testing.T) {
+	want := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	for _, v := range []string{"2026-11-02", "02.11.2026"} {
+		d, err := parseImportDate(v)
+		require.NoError(t, err, v)
+		assert.Equal(t, want, *d, v)
+	}
+	d, err := parseImportDate(" ")
+	require.NoError(t, err)
+	assert.Nil(t, d)
+	_, err = parseImportDate("next week")
+	assert.Error(t, err)
+}
+
+func postProductImport(t *testing.T, query, csv string) (*httptest.ResponseRecorder, ProductImportReport) {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", "catalog.csv")
+	require.NoError(t, err)
+	part.Write([]byte(csv))
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/products/import"+query, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	ProductImportHandler(w, req)
+
+	var report ProductImportReport
+	json.Unmarshal(w.Body.Bytes(), &report)
+	return w, report
+}
+
+func TestProductImportHandler(t *testing.T) {
+	setupTestDB(t)
+	defer db.Exec("DELETE FROM products WHERE sku LIKE 'IMPORT-TEST-%'")
+	defer db.Exec("DELETE FROM manufacturers WHERE slug = 'import-test-brand'")
+
+	var c models.Category
+	require.NoError(t, db.Get(&c, "SELECT id, slug FROM categories LIMIT 1"))
+
+	sheet := "sku,name,price,oldPrice,manufacturer,category,features\n" +
+		"IMPORT-TEST-1,Импорт тест,1000,,Import Test Brand," + c.Slug + ",a|b\n"
+
+	// Dry run reports without storing
+	w, report := postProductImport(t, "?dryRun=true&createMissing=true", sheet)
+	require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 1, report.Created)
+	var count int
+	require.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM products WHERE sku = 'IMPORT-TEST-1'"))
+	assert.Zero(t, count)
+
+	// Unknown manufacturers are errors unless createMissing is set
+	w, report = postProductImport(t, "", sheet)
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, ProductImportError{Row: 2, SKU: "IMPORT-TEST-1", Field: "manufacturer", Message: "Unknown manufacturer: Import Test Brand"}, report.Errors[0])
+
+	w, report = postProductImport(t, "?createMissing=true", sheet)
+	require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+	assert.Equal(t, []string{"Import Test Brand"}, report.CreatedManufacturers)
+
+	var p models.Product
+	require.NoError(t, db.Get(&p, "SELECT * FROM products WHERE sku = 'IMPORT-TEST-1'"))
+	assert.Equal(t, "import-test", p.Slug)
+	assert.Equal(t, models.JSONStringArray{"a", "b"}, p.Features)
+
+	// Second import updates by SKU, keeping columns it does not mention
+	w, report = postProductImport(t, "", "sku,price\nIMPORT-TEST-1,900\nIMPORT-TEST-1,800\n")
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, 3, report.Errors[0].Row)
+
+	w, report = postProductImport(t, "", "sku,price\nIMPORT-TEST-1,900\n")
+	require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+	assert.Equal(t, 1, report.Updated)
+
+	var updated models.Product
+	require.NoError(t, db.Get(&updated, "SELECT * FROM products WHERE sku = 'IMPORT-TEST-1'"))
+	assert.Equal(t, 900, updated.Price)
+	assert.Equal(t, p.Name, updated.Name)
+	assert.Equal(t, p.Version+1, updated.Version)
+
+	// ID columns, as in API responses, match by ID
+	var other models.Category
+	require.NoError(t, db.Get(&other, "SELECT id, slug FROM categories WHERE id <> $1 LIMIT 1", c.ID))
+	w, report = postProductImport(t, "", "sku,categoryId\nIMPORT-TEST-1,"+other.ID+"\n")
+	require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+	assert.Empty(t, report.CreatedCategories)
+	require.NoError(t, db.Get(&updated, "SELECT * FROM products WHERE sku = 'IMPORT-TEST-1'"))
+	assert.Equal(t, other.ID, updated.CategoryID)
+}
@@ -0,0 +1,55 @@
+package crud
+
+import "errors"
+
+const defaultProductSort = "name"
+
+var errInvalidSort = errors.New("invalid sort")
+
+// popularityExpr is the number of units ordered across all orders.
+const popularityExpr = `COALESCE((SELECT SUM(oi.quantity) FROM order_items oi WHERE oi.product_id = p.id), 0)`
+
+// discountExpr is the discount off old_price in whole percent.
+const discountExpr = `(CASE WHEN p.old_price > p.price THEN (p.old_price - p.price) * 100 / p.old_price ELSE 0 END)`
+
+// productSortKeys returns the ORDER BY keys for a product sort. Every order
+// ends with p.id so rows with equal values keep a stable position and keyset
+// pagination never skips or repeats them. searchArg is the placeholder of the
+// lowercased search term; without it "relevance" falls back to name order.
+func productSortKeys(sort string, searchArg string) ([]sortKey, bool) {
+	id := sortKey{Expr: "p.id", Type: "text"}
+
+	switch sort {
+	case "name":
+		return []sortKey{{Expr: "p.name", Type: "text"}, id}, true
+	case "price_asc":
+		return []sortKey{{Expr: "p.price", Type: "integer"}, id}, true
+	case "price_desc":
+		return []sortKey{{Expr: "p.price", Type: "integer", Desc: true}, id}, true
+	case "rating":
+		return []sortKey{
+			{Expr: "p.rating", Type: "real", Desc: true},
+			{Expr: "p.reviews_count", Type: "integer", Desc: true},
+			id,
+		}, true
+	case "newest":
+		return []sortKey{{Expr: "p.created_at", Type: "timestamp", Desc: true}, id}, true
+	case "popularity":
+		return []sortKey{{Expr: popularityExpr, Type: "bigint", Desc: true}, id}, true
+	case "discount":
+		return []sortKey{{Expr: discountExpr, Type: "integer", Desc: true}, id}, true
+	case "relevance":
+		if searchArg == "" {
+			return productSortKeys(defaultProductSort, "")
+		}
+		// Exact name match first, then names starting with the term, then the rest.
+		relevance := `(CASE WHEN LOWER(p.name) = ` + searchArg + ` THEN 2` +
+			` WHEN LOWER(p.name) LIKE ` + searchArg + ` || '%' THEN 1 ELSE 0 END)`
+		return []sortKey{
+			{Expr: relevance, Type: "integer", Desc: true},
+			{Expr: "p.name", Type: "text"},
+			id,
+		}, true
+	}
+	return nil, false
+}
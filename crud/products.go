@@ -1,10 +1,15 @@
 package crud
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
-	"strconv"
+	"net/url"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -19,7 +24,7 @@ var db *sqlx.DB // будет проинициализировано в main.go
 func ProductsHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		GetProducts(w, r)
+		serveCatalog(w, r, GetProducts, productListTables(r)...)
 	case http.MethodPost:
 		CreateProduct(w, r)
 	default:
@@ -27,13 +32,31 @@ func ProductsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// ProductItemHandler handles GET, PUT, DELETE /products/{id}
+// ProductItemHandler handles GET, PUT, PATCH, DELETE /products/{id}
 func ProductItemHandler(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/reviews") {
+		ProductReviewsHandler(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/subscriptions") {
+		ProductSubscriptionsHandler(w, r)
+		return
+	}
+	if _, _, ok := parseVariantPath(r.URL.Path); ok {
+		ProductVariantsHandler(w, r)
+		return
+	}
+	if _, attributeID, ok := parseAttributePath(r.URL.Path, "/products/"); ok && attributeID == "" {
+		ProductAttributesHandler(w, r)
+		return
+	}
 	switch r.Method {
 	case http.MethodGet:
 		GetProduct(w, r)
 	case http.MethodPut:
 		UpdateProduct(w, r)
+	case http.MethodPatch:
+		PatchProduct(w, r)
 	case http.MethodDelete:
 		DeleteProduct(w, r)
 	default:
@@ -41,95 +64,219 @@ func ProductItemHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ProductBySlugHandler handles GET /products/by-slug/{slug}
+func ProductBySlugHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		GetProductBySlug(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// ProductsPageHandler handles GET /v2/products
+func ProductsPageHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		serveCatalog(w, r, GetProductsPage, productListTables(r)...)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 // GetProducts godoc
 // @Summary Get list of products
 // @Description Get a list of products with optional filtering
 // @Tags products
 // @Produce json
-// @Param category query string false "Category Slug"
+// @Param category query string false "Category Slug, includes subcategories"
 // @Param manufacturer query string false "Manufacturer Slug"
 // @Param search query string false "Search term"
-// @Param inStockOnly query bool false "Only in stock"
+// @Param inStockOnly query bool false "Only in_stock and low_stock products"
+// @Param option.{name} query string false "Only products with a variant having this value of option {name}; repeat for any of several values"
+// @Param attr.{code} query string false "Only products with this value of enum or boolean attribute {code}; repeat for any of several values"
+// @Param attr.{code}.min query number false "Only products with number attribute {code} at least this"
+// @Param attr.{code}.max query number false "Only products with number attribute {code} at most this"
+// @Param sort query string false "Sort order" Enums(name, price_asc, price_desc, rating, newest, popularity, discount, relevance)
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(20)
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {array} models.Product
+// @Header 200 {string} ETag "Strong validator of the listing"
+// @Header 200 {string} Last-Modified "Last catalog change"
+// @Header 200 {string} Cache-Control "Configured with CATALOG_CACHE_CONTROL"
+// @Success 304 {string} string "Not Modified"
+// @Failure 400 {string} string "Invalid sort or filter"
 // @Router /products [get]
 func GetProducts(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
+	pp := parsePageParams(query)
 
-	categorySlug := query.Get("category")
-	manufacturerSlug := query.Get("manufacturer")
-	search := strings.ToLower(query.Get("search"))
-	inStockOnly := query.Get("inStockOnly") == "true"
+	lq, err := productListQuery(query)
+	if err != nil {
+		writeListQueryError(w, err)
+		return
+	}
 
-	page, _ := strconv.Atoi(query.Get("page"))
-	if page < 1 {
-		page = 1
+	args := lq.Args
+	q := `SELECT ` + lq.Columns + ` FROM ` + lq.From + ` WHERE true` + lq.Where +
+		orderByClause(lq.Keys) + ` LIMIT ` + args.add(pp.Limit) + ` OFFSET ` + args.add((pp.Page-1)*pp.Limit)
+
+	body, _, err := catalogCache.load(r.URL.RequestURI(), func() (interface{}, []string, error) {
+		var products []models.Product
+		err := db.Select(&products, q, args...)
+		for i := range products {
+			products[i] = withImages(products[i])
+		}
+		return products, productListTags(query), err
+	})
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONBody(w, body)
+}
+
+// GetProductsPage godoc
+// @Summary Get a page of products
+// @Description Get products wrapped in a pagination envelope. Pass either page or the nextCursor of a previous response.
+// @Tags products
+// @Produce json
+// @Param category query string false "Category Slug, includes subcategories"
+// @Param manufacturer query string false "Manufacturer Slug"
+// @Param search query string false "Search term"
+// @Param inStockOnly query bool false "Only in_stock and low_stock products"
+// @Param option.{name} query string false "Only products with a variant having this value of option {name}; repeat for any of several values"
+// @Param attr.{code} query string false "Only products with this value of enum or boolean attribute {code}; repeat for any of several values"
+// @Param attr.{code}.min query number false "Only products with number attribute {code} at least this"
+// @Param attr.{code}.max query number false "Only products with number attribute {code} at most this"
+// @Param sort query string false "Sort order" Enums(name, price_asc, price_desc, rating, newest, popularity, discount, relevance)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Keyset cursor"
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} PageResponse[models.Product]
+// @Header 200 {string} ETag "Strong validator of the listing"
+// @Header 200 {string} Last-Modified "Last catalog change"
+// @Header 200 {string} Cache-Control "Configured with CATALOG_CACHE_CONTROL"
+// @Success 304 {string} string "Not Modified"
+// @Failure 400 {string} string "Invalid sort, filter or cursor"
+// @Router /v2/products [get]
+func GetProductsPage(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	lq, err := productListQuery(query)
+	if err != nil {
+		writeListQueryError(w, err)
+		return
 	}
-	limit, _ := strconv.Atoi(query.Get("limit"))
-	if limit < 1 || limit > 100 {
-		limit = 20
+
+	body, _, err := catalogCache.load(r.URL.RequestURI(), func() (interface{}, []string, error) {
+		page, err := fetchPage(lq, parsePageParams(query), func(row productRow) models.Product {
+			return withImages(row.Product)
+		})
+		return page, productListTags(query), err
+	})
+	if errors.Is(err, errInvalidCursor) {
+		http.Error(w, "Invalid cursor", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
 	}
-	offset := (page - 1) * limit
 
-	var products []models.Product
+	writeJSONBody(w, body)
+}
 
-	q := `
-		SELECT 
-			p.id, p.name, p.slug, p.price, p.old_price, p.description, p.features, p.image, 
-			p.stock, p.rating, p.reviews_count, p.sku, p.availability,
-			m.id AS "manufacturer.id", m.name AS "manufacturer.name", m.slug AS "manufacturer.slug", m.logo AS "manufacturer.logo",
-			c.id AS "category.id", c.name AS "category.name", c.slug AS "category.slug"
-		FROM products p
-		LEFT JOIN manufacturers m ON p.manufacturer_id = m.id
-		LEFT JOIN categories c ON p.category_id = c.id
-		WHERE true
-	`
+// productListTags are the cache tags of a product listing: the joined tables,
+// plus order items when the listing is ordered by popularity.
+func productListTags(query url.Values) []string {
+	if query.Get("sort") == "popularity" {
+		return append([]string{"order_items"}, productTables...)
+	}
+	return productTables
+}
 
-	args := []interface{}{}
-	argID := 1
+// productDetailTags are the cache tags of a single product response.
+func productDetailTags(p models.Product) []string {
+	return []string{"products:" + p.ID, "manufacturers:" + p.ManufacturerID, "categories:" + p.CategoryID}
+}
 
-	if categorySlug != "" {
-		q += ` AND c.slug = $` + strconv.Itoa(argID)
-		args = append(args, categorySlug)
-		argID++
+const productListColumns = `
+	p.id, p.name, p.slug, p.price, p.old_price, p.description, p.features, p.image, 
+	p.stock, p.rating, p.reviews_count, p.sku, p.availability, p.created_at, p.updated_at, p.version, p.options,
+	p.low_stock_threshold, p.allow_backorder, p.restock_date,
+	m.id AS "manufacturer.id", m.name AS "manufacturer.name", m.slug AS "manufacturer.slug", m.logo AS "manufacturer.logo",
+	c.id AS "category.id", c.name AS "category.name", c.slug AS "category.slug"`
+
+const productListFrom = `
+	products p
+	LEFT JOIN manufacturers m ON p.manufacturer_id = m.id
+	LEFT JOIN categories c ON p.category_id = c.id`
+
+// productRow is a product listing row together with its keyset sort key.
+type productRow struct {
+	models.Product
+	SortKey string `db:"sort_key"`
+}
+
+func (r productRow) sortKey() string { return r.SortKey }
+
+// productListQuery builds the listing shared by GET /products and
+// GET /v2/products from the filter and sort parameters.
+func productListQuery(query url.Values) (listQuery, error) {
+	lq := listQuery{
+		Columns: productListColumns,
+		From:    productListFrom,
 	}
-	if manufacturerSlug != "" {
-		q += ` AND m.slug = $` + strconv.Itoa(argID)
-		args = append(args, manufacturerSlug)
-		argID++
+
+	if categorySlug := query.Get("category"); categorySlug != "" {
+		lq.Where += categoryDescendantsFilter(lq.Args.add(categorySlug))
 	}
-	if search != "" {
-		q += ` AND LOWER(p.name) LIKE $` + strconv.Itoa(argID)
-		args = append(args, "%"+search+"%")
-		argID++
+	if manufacturerSlug := query.Get("manufacturer"); manufacturerSlug != "" {
+		lq.Where += ` AND m.slug = ` + lq.Args.add(manufacturerSlug)
 	}
+	var searchArg string
+	if search := strings.ToLower(query.Get("search")); search != "" {
+		searchArg = lq.Args.add(search)
+		lq.Where += ` AND LOWER(p.name) LIKE '%' || ` + searchArg + ` || '%'`
+	}
+	inStockOnly := query.Get("inStockOnly") == "true"
 	if inStockOnly {
-		q += ` AND p.stock > 0 AND p.availability = 'in_stock'`
+		lq.Where += ` AND p.availability IN ('in_stock', 'low_stock')`
+	}
+	if names, values := optionFilters(query); len(names) > 0 {
+		optionFilter(&lq, names, values, inStockOnly)
+	}
+	if err := attributeFilter(&lq, query); err != nil {
+		return lq, err
 	}
 
-	q += ` ORDER BY p.name LIMIT $` + strconv.Itoa(argID) + ` OFFSET $` + strconv.Itoa(argID+1)
-	args = append(args, limit, offset)
-
-	err := db.Select(&products, q, args...)
-	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
+	sort := query.Get("sort")
+	if sort == "" {
+		sort = defaultProductSort
+	}
+	keys, ok := productSortKeys(sort, searchArg)
+	if !ok {
+		return lq, errInvalidSort
 	}
+	lq.Sort, lq.Keys = sort, keys
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(products)
+	return lq, nil
 }
 
 // CreateProduct godoc
 // @Summary Create a product
-// @Description Create a new product
+// @Description Create a new product. When slug is omitted it is generated from the name, transliterating Cyrillic.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param product body models.Product true "Product"
 // @Success 201 {object} models.Product
 // @Failure 400 {string} string "Invalid request"
+// @Failure 409 {string} string "Slug or SKU already exists"
 // @Router /products [post]
 func CreateProduct(w http.ResponseWriter, r *http.Request) {
 	var p models.Product
@@ -138,12 +285,16 @@ func CreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if p.Name == "" || p.Slug == "" || p.ManufacturerID == "" || p.CategoryID == "" {
-		http.Error(w, "Name, Slug, ManufacturerID, and CategoryID are required", http.StatusBadRequest)
+	if p.Name == "" || p.ManufacturerID == "" || p.CategoryID == "" {
+		http.Error(w, "Name, ManufacturerID, and CategoryID are required", http.StatusBadRequest)
 		return
 	}
 
 	p.ID = uuid.New().String()
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+	p.Version = 1
+	p.Rating, p.ReviewsCount = 0, 0
 	if p.Features == nil {
 		p.Features = models.JSONStringArray{}
 	}
@@ -151,31 +302,57 @@ func CreateProduct(w http.ResponseWriter, r *http.Request) {
 		p.Image = models.JSONStringArray{}
 	}
 
-	_, err := db.Exec(`
-		INSERT INTO products (
-			id, name, slug, manufacturer_id, category_id, price, old_price, 
-			description, features, image, stock, rating, reviews_count, sku, availability
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
-	`, p.ID, p.Name, p.Slug, p.ManufacturerID, p.CategoryID, p.Price, p.OldPrice,
-		p.Description, p.Features, p.Image, p.Stock, p.Rating, p.ReviewsCount, p.SKU, p.Availability)
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
 
+	p.Slug, err = resolveSlug(tx, productSlugs, p.Slug, p.Name)
 	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if details := validateProduct(p); len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+
+	if err := insertProductRow(tx, &p); err != nil {
+		if isUniqueViolation(err) {
+			writeConflict(w, err)
+			return
+		}
 		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if err := claimSlug(tx, productSlugs, p.Slug); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	catalogCache.invalidate("products", p.ID)
+
 	w.Header().Set("Content-Type", "application/json")
+	setVersionETag(w, p.Version)
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(p)
+	json.NewEncoder(w).Encode(withImages(p))
 }
 
 // GetProduct godoc
 // @Summary Get product by ID
-// @Description Get details of a specific product
+// @Description Get details of a specific product. The ETag header carries the product version expected in If-Match by writes.
 // @Tags products
 // @Produce json
 // @Param id path string true "Product ID"
 // @Success 200 {object} models.Product
+// @Header 200 {string} ETag "Product version"
 // @Failure 404 {string} string "Product not found"
 // @Router /products/{id} [get]
 func GetProduct(w http.ResponseWriter, r *http.Request) {
@@ -185,53 +362,175 @@ func GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var product models.Product
-	err := db.Get(&product, `
-		SELECT 
-			p.*, 
-			m.id AS "manufacturer.id", m.name AS "manufacturer.name", m.slug AS "manufacturer.slug", m.logo AS "manufacturer.logo",
-			c.id AS "category.id", c.name AS "category.name", c.slug AS "category.slug"
-		FROM products p
-		LEFT JOIN manufacturers m ON p.manufacturer_id = m.id
-		LEFT JOIN categories c ON p.category_id = c.id
-		WHERE p.id = $1
-	`, id)
+	body, v, err := catalogCache.load("product:"+id, func() (interface{}, []string, error) {
+		var product models.Product
+		err := db.Get(&product, productDetailSelect+` WHERE p.id = $1`, id)
+		if err == nil {
+			product, err = withDetails(db, product)
+		}
+		return withImages(product), productDetailTags(product), err
+	})
 
 	if err != nil {
 		http.NotFound(w, r)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(product)
+	setVersionETag(w, v.(models.Product).Version)
+	writeJSONBody(w, body)
 }
 
+// GetProductBySlug godoc
+// @Summary Get product by slug
+// @Description Get details of a specific product by its slug. Former slugs redirect to the current one.
+// @Tags products
+// @Produce json
+// @Param slug path string true "Product Slug"
+// @Success 200 {object} models.Product
+// @Header 200 {string} ETag "Product version"
+// @Success 301 {string} string "Moved to the current slug"
+// @Failure 404 {string} string "Product not found"
+// @Router /products/by-slug/{slug} [get]
+func GetProductBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := strings.TrimPrefix(r.URL.Path, "/products/by-slug/")
+	if slug == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, v, err := catalogCache.load("product-slug:"+slug, func() (interface{}, []string, error) {
+		var product models.Product
+		err := db.Get(&product, productDetailSelect+` WHERE p.slug = $1`, slug)
+		if err == nil {
+			product, err = withDetails(db, product)
+		}
+		return withImages(product), productDetailTags(product), err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		redirectSlug(w, r, productSlugs, slug, "/products/by-slug/")
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	setVersionETag(w, v.(models.Product).Version)
+	writeJSONBody(w, body)
+}
+
+// withDetails fills the variants and attributes of a single product
+// response.
+func withDetails(q sqlx.Queryer, p models.Product) (models.Product, error) {
+	p, err := withVariants(q, p)
+	if err == nil {
+		p.Attributes, err = productAttributes(q, p)
+	}
+	return p, err
+}
+
+const productDetailSelect = `
+	SELECT 
+		p.*, 
+		m.id AS "manufacturer.id", m.name AS "manufacturer.name", m.slug AS "manufacturer.slug", m.logo AS "manufacturer.logo",
+		c.id AS "category.id", c.name AS "category.name", c.slug AS "category.slug"
+	FROM products p
+	LEFT JOIN manufacturers m ON p.manufacturer_id = m.id
+	LEFT JOIN categories c ON p.category_id = c.id`
+
 // UpdateProduct godoc
-// @Summary Update product
-// @Description Update an existing product
+// @Summary Replace product
+// @Description Replace an existing product. Every writable field is overwritten; name, slug, manufacturerId and categoryId are required.
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param If-Match header string true "ETag of the version being replaced"
 // @Param product body models.Product true "Product"
 // @Success 200 {object} models.Product
-// @Failure 400 {string} string "Invalid request"
+// @Failure 400 {object} ValidationErrorResponse
 // @Failure 404 {string} string "Product not found"
+// @Failure 409 {string} string "Slug or SKU already exists"
+// @Failure 412 {string} string "Product was modified"
+// @Failure 428 {string} string "If-Match header required"
 // @Router /products/{id} [put]
 func UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	writeProduct(w, r, func(_ models.Product, body []byte) (models.Product, error) {
+		var p models.Product
+		err := json.Unmarshal(body, &p)
+		return p, err
+	})
+}
+
+// PatchProduct godoc
+// @Summary Patch product
+// @Description Partially update a product with JSON Merge Patch (RFC 7386): only fields present in the body change, null clears a field.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param If-Match header string true "ETag of the version being replaced"
+// @Param patch body object true "Merge patch"
+// @Success 200 {object} models.Product
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 404 {string} string "Product not found"
+// @Failure 409 {string} string "Slug or SKU already exists"
+// @Failure 412 {string} string "Product was modified"
+// @Failure 428 {string} string "If-Match header required"
+// @Router /products/{id} [patch]
+func PatchProduct(w http.ResponseWriter, r *http.Request) {
+	writeProduct(w, r, mergePatch[models.Product])
+}
+
+// writeProduct loads the product named in the path, checks If-Match against
+// its version, lets build derive the new state from it and the request body,
+// then validates, stores and returns it.
+func writeProduct(w http.ResponseWriter, r *http.Request, build func(current models.Product, body []byte) (models.Product, error)) {
 	id := strings.TrimPrefix(r.URL.Path, "/products/")
 	if id == "" {
 		http.Error(w, "ID required", http.StatusBadRequest)
 		return
 	}
 
-	var p models.Product
-	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var current models.Product
+	err = tx.Get(&current, `SELECT * FROM products WHERE id = $1 FOR UPDATE`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := checkIfMatch(r, current.Version); err != nil {
+		writePreconditionError(w, err)
+		return
+	}
+
+	p, err := build(current, body)
+	if err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	p.ID = id
+	p.ID = current.ID
+	p.CreatedAt = current.CreatedAt
+	p.Version = current.Version
+	p.Slug = slugify(p.Slug)
 	if p.Features == nil {
 		p.Features = models.JSONStringArray{}
 	}
@@ -239,22 +538,136 @@ func UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		p.Image = models.JSONStringArray{}
 	}
 
-	_, err := db.Exec(`
-		UPDATE products SET 
-			name=$1, slug=$2, manufacturer_id=$3, category_id=$4, price=$5, old_price=$6, 
-			description=$7, features=$8, image=$9, stock=$10, rating=$11, reviews_count=$12, 
-			sku=$13, availability=$14
-		WHERE id=$15
-	`, p.Name, p.Slug, p.ManufacturerID, p.CategoryID, p.Price, p.OldPrice,
-		p.Description, p.Features, p.Image, p.Stock, p.Rating, p.ReviewsCount, p.SKU, p.Availability, p.ID)
-
+	if details := validateProduct(p); len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+	details, err := checkVariantsMatch(tx, p.ID, p.Options)
 	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+
+	if err := updateProductRow(tx, p); err != nil {
+		if isUniqueViolation(err) {
+			writeConflict(w, err)
+			return
+		}
 		http.Error(w, "Database error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if err := recordSlugChange(tx, productSlugs, p.ID, current.Slug, p.Slug); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := syncStock(tx, p.ID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	var stored models.Product
+	err = tx.Get(&stored, productDetailSelect+` WHERE p.id = $1`, p.ID)
+	if err == nil {
+		stored, err = withDetails(tx, stored)
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	catalogCache.invalidate("products", p.ID)
+	wakeSubscriptionNotifier()
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(p)
+	setVersionETag(w, stored.Version)
+	json.NewEncoder(w).Encode(withImages(stored))
+}
+
+// insertProductRow stores the new product p. Rating and ReviewsCount start at
+// zero and only change with approved reviews, see updateProductRating.
+// Availability is derived by the database and read back into p.
+func insertProductRow(q sqlx.Queryer, p *models.Product) error {
+	return sqlx.Get(q, &p.Availability, `
+		INSERT INTO products (
+			id, name, slug, manufacturer_id, category_id, price, old_price, 
+			description, features, image, stock, sku, created_at, updated_at, version, options,
+			low_stock_threshold, allow_backorder, restock_date
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		RETURNING availability
+	`, p.ID, p.Name, p.Slug, p.ManufacturerID, p.CategoryID, p.Price, p.OldPrice,
+		p.Description, p.Features, p.Image, p.Stock, p.SKU, p.CreatedAt, p.UpdatedAt, p.Version, p.Options,
+		p.LowStockThreshold, p.AllowBackorder, p.RestockDate)
+}
+
+// updateProductRow overwrites the stored product p.ID with p, except for its
+// rating and derived availability, and bumps its version.
+func updateProductRow(ex sqlx.Execer, p models.Product) error {
+	_, err := ex.Exec(`
+		UPDATE products SET 
+			name=$1, slug=$2, manufacturer_id=$3, category_id=$4, price=$5, old_price=$6, 
+			description=$7, features=$8, image=$9, stock=$10,
+			sku=$11, options=$12, low_stock_threshold=$13, allow_backorder=$14, restock_date=$15,
+			updated_at=NOW(), version=version + 1
+		WHERE id=$16
+	`, p.Name, p.Slug, p.ManufacturerID, p.CategoryID, p.Price, p.OldPrice,
+		p.Description, p.Features, p.Image, p.Stock, p.SKU, p.Options,
+		p.LowStockThreshold, p.AllowBackorder, p.RestockDate, p.ID)
+	return err
+}
+
+// validateProduct checks the fields every stored product must have.
+func validateProduct(p models.Product) []ValidationErrorDetail {
+	var details []ValidationErrorDetail
+
+	if strings.TrimSpace(p.Name) == "" {
+		details = append(details, ValidationErrorDetail{Field: "name", Message: "Name is required"})
+	}
+	if p.Slug == "" {
+		details = append(details, ValidationErrorDetail{Field: "slug", Message: "Slug is required"})
+	}
+	if p.ManufacturerID == "" {
+		details = append(details, ValidationErrorDetail{Field: "manufacturerId", Message: "ManufacturerID is required"})
+	}
+	if p.CategoryID == "" {
+		details = append(details, ValidationErrorDetail{Field: "categoryId", Message: "CategoryID is required"})
+	}
+	if p.Price < 0 {
+		details = append(details, ValidationErrorDetail{Field: "price", Message: "Price cannot be negative"})
+	}
+	if p.OldPrice != nil && *p.OldPrice < 0 {
+		details = append(details, ValidationErrorDetail{Field: "oldPrice", Message: "OldPrice cannot be negative"})
+	}
+	if p.Stock < 0 {
+		details = append(details, ValidationErrorDetail{Field: "stock", Message: "Stock cannot be negative"})
+	}
+	if p.LowStockThreshold < 0 {
+		details = append(details, ValidationErrorDetail{Field: "lowStockThreshold", Message: "LowStockThreshold cannot be negative"})
+	}
+	if msg := availabilityError(p.Availability); msg != "" {
+		details = append(details, ValidationErrorDetail{Field: "availability", Message: msg})
+	}
+	details = append(details, validateOptions(p.Options)...)
+
+	return details
+}
+
+// availabilityError describes an availability that is not one of
+// models.Availabilities. Valid values are accepted, so that a product
+// read back can be written unchanged, but are replaced by the derived one.
+func availabilityError(availability string) string {
+	if availability == "" || slices.Contains(models.Availabilities, availability) {
+		return ""
+	}
+	return "Availability must be one of " + strings.Join(models.Availabilities, ", ") + "; it is derived from stock"
 }
 
 // DeleteProduct godoc
@@ -263,8 +676,11 @@ func UpdateProduct(w http.ResponseWriter, r *http.Request) {
 // @Tags products
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param If-Match header string true "ETag of the version being deleted"
 // @Success 204 {string} string "No Content"
 // @Failure 404 {string} string "Product not found"
+// @Failure 412 {string} string "Product was modified"
+// @Failure 428 {string} string "If-Match header required"
 // @Router /products/{id} [delete]
 func DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	id := strings.TrimPrefix(r.URL.Path, "/products/")
@@ -273,17 +689,5 @@ func DeleteProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := db.Exec(`DELETE FROM products WHERE id = $1`, id)
-	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
-	}
-
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		http.NotFound(w, r)
-		return
-	}
-
-	w.WriteHeader(http.StatusNoContent)
+	deleteVersioned(w, r, "products", id)
 }
@@ -0,0 +1,386 @@
+package crud
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"noble-group-services/models"
+)
+
+// maxReviewTextLength bounds review texts, in characters.
+const maxReviewTextLength = 5000
+
+// reviewColumns are the columns of models.Review.
+const reviewColumns = `id, product_id, author_name, author_email, rating, text, verified_purchase, status, created_at, moderated_at`
+
+// reviewRow is a review listing row together with its keyset sort key.
+type reviewRow struct {
+	models.Review
+	SortKey string `db:"sort_key"`
+}
+
+func (r reviewRow) sortKey() string { return r.SortKey }
+
+// reviewKeys orders reviews newest first.
+var reviewKeys = []sortKey{
+	{Expr: "created_at", Type: "timestamp", Desc: true},
+	{Expr: "id", Type: "text", Desc: true},
+}
+
+// ProductReviewsHandler handles GET, POST /products/{id}/reviews
+func ProductReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		GetProductReviews(w, r)
+	case http.MethodPost:
+		CreateReview(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// reviewProductID extracts {id} from /products/{id}/reviews.
+func reviewProductID(r *http.Request) string {
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/products/"), "/reviews")
+	if strings.Contains(id, "/") {
+		return ""
+	}
+	return id
+}
+
+// GetProductReviews godoc
+// @Summary List product reviews
+// @Description Get the approved reviews of a product, newest first, wrapped in a pagination envelope.
+// @Tags reviews
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Keyset cursor"
+// @Success 200 {object} PageResponse[models.Review]
+// @Failure 400 {string} string "Invalid cursor"
+// @Failure 404 {string} string "Product not found"
+// @Router /products/{id}/reviews [get]
+func GetProductReviews(w http.ResponseWriter, r *http.Request) {
+	productID := reviewProductID(r)
+	if !productExists(w, r, productID) {
+		return
+	}
+
+	lq := listQuery{
+		Columns: reviewColumns,
+		From:    `reviews`,
+		Sort:    "newest",
+		Keys:    reviewKeys,
+	}
+	lq.Where += ` AND product_id = ` + lq.Args.add(productID)
+	lq.Where += ` AND status = ` + lq.Args.add(models.ReviewApproved)
+
+	page, err := fetchPage(lq, parsePageParams(r.URL.Query()), func(row reviewRow) models.Review {
+		review := row.Review
+		review.AuthorEmail = ""
+		return review
+	})
+	writePage(w, page, err)
+}
+
+// CreateReview godoc
+// @Summary Submit a review
+// @Description Submit a review of a product. It is published once a moderator approves it. The review is marked as a verified purchase when an order placed with authorEmail contains the product. Each email can review a product once.
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param review body models.ReviewRequest true "Review"
+// @Success 201 {object} models.Review
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 404 {string} string "Product not found"
+// @Failure 409 {string} string "Product already reviewed"
+// @Router /products/{id}/reviews [post]
+func CreateReview(w http.ResponseWriter, r *http.Request) {
+	productID := reviewProductID(r)
+
+	var req models.ReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.AuthorName = strings.TrimSpace(req.AuthorName)
+	req.AuthorEmail = strings.TrimSpace(req.AuthorEmail)
+	req.Text = strings.TrimSpace(req.Text)
+	if details := validateReview(req); len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+	if !productExists(w, r, productID) {
+		return
+	}
+
+	review := models.Review{
+		ID:          uuid.New().String(),
+		ProductID:   productID,
+		AuthorName:  req.AuthorName,
+		AuthorEmail: req.AuthorEmail,
+		Rating:      req.Rating,
+		Text:        req.Text,
+		Status:      models.ReviewPending,
+		CreatedAt:   time.Now(),
+	}
+
+	err := db.Get(&review.VerifiedPurchase, `
+		SELECT EXISTS (
+			SELECT 1 FROM order_items oi JOIN orders o ON o.id = oi.order_id
+			WHERE oi.product_id = $1 AND LOWER(o.customer_email) = LOWER($2)
+		)
+	`, productID, review.AuthorEmail)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = db.NamedExec(`
+		INSERT INTO reviews (`+reviewColumns+`)
+		VALUES (:id, :product_id, :author_name, :author_email, :rating, :text, :verified_purchase, :status, :created_at, :moderated_at)
+	`, review)
+	if isUniqueViolation(err) {
+		http.Error(w, "You have already reviewed this product", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(review)
+}
+
+// validateReview checks a submitted review.
+func validateReview(req models.ReviewRequest) []ValidationErrorDetail {
+	var details []ValidationErrorDetail
+
+	if req.AuthorName == "" {
+		details = append(details, ValidationErrorDetail{Field: "authorName", Message: "Name is required"})
+	}
+	if addr, err := mail.ParseAddress(req.AuthorEmail); err != nil || addr.Address != req.AuthorEmail {
+		details = append(details, ValidationErrorDetail{Field: "authorEmail", Message: "A valid email is required"})
+	}
+	if req.Rating < 1 || req.Rating > 5 {
+		details = append(details, ValidationErrorDetail{Field: "rating", Message: "Rating must be from 1 to 5"})
+	}
+	if utf8.RuneCountInString(req.Text) > maxReviewTextLength {
+		details = append(details, ValidationErrorDetail{Field: "text", Message: "Text is too long"})
+	}
+	return details
+}
+
+// productExists answers 404 and returns false when there is no product id.
+func productExists(w http.ResponseWriter, r *http.Request, id string) bool {
+	var exists bool
+	if id != "" {
+		if err := db.Get(&exists, `SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)`, id); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return false
+		}
+	}
+	if !exists {
+		http.Error(w, "Product not found", http.StatusNotFound)
+	}
+	return exists
+}
+
+// ReviewsAdminHandler handles GET /admin/reviews
+func ReviewsAdminHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		GetReviews(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// ReviewAdminItemHandler handles PATCH, DELETE /admin/reviews/{id}
+func ReviewAdminItemHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPatch:
+		ModerateReview(w, r)
+	case http.MethodDelete:
+		DeleteReview(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetReviews godoc
+// @Summary List reviews for moderation
+// @Description Get reviews of all products, newest first, with author emails.
+// @Tags admin
+// @Produce json
+// @Param status query string false "Review status" Enums(pending, approved, rejected)
+// @Param productId query string false "Product ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Param cursor query string false "Keyset cursor"
+// @Success 200 {object} PageResponse[models.Review]
+// @Failure 400 {string} string "Invalid cursor"
+// @Router /admin/reviews [get]
+func GetReviews(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	lq := listQuery{
+		Columns: reviewColumns,
+		From:    `reviews`,
+		Sort:    "newest",
+		Keys:    reviewKeys,
+	}
+	if status := query.Get("status"); status != "" {
+		lq.Where += ` AND status = ` + lq.Args.add(status)
+	}
+	if productID := query.Get("productId"); productID != "" {
+		lq.Where += ` AND product_id = ` + lq.Args.add(productID)
+	}
+
+	page, err := fetchPage(lq, parsePageParams(query), func(row reviewRow) models.Review {
+		return row.Review
+	})
+	writePage(w, page, err)
+}
+
+// ModerateReview godoc
+// @Summary Moderate a review
+// @Description Approve, reject or return a review to pending. The product rating and review count are recomputed from its approved reviews.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "Review ID"
+// @Param status body object true "New status, e.g. {\"status\": \"approved\"}"
+// @Success 200 {object} models.Review
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 404 {string} string "Review not found"
+// @Router /admin/reviews/{id} [patch]
+func ModerateReview(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/admin/reviews/")
+
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	switch req.Status {
+	case models.ReviewPending, models.ReviewApproved, models.ReviewRejected:
+	default:
+		writeValidationErrors(w, []ValidationErrorDetail{
+			{Field: "status", Message: "Status must be pending, approved or rejected"},
+		})
+		return
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var review models.Review
+	err = tx.Get(&review, `
+		UPDATE reviews SET status = $2, moderated_at = NOW() WHERE id = $1
+		RETURNING `+reviewColumns, id, req.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := updateProductRating(tx, review.ProductID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	catalogCache.invalidate("products", review.ProductID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+}
+
+// DeleteReview godoc
+// @Summary Delete a review
+// @Description Delete a review and recompute the product rating.
+// @Tags admin
+// @Param id path string true "Review ID"
+// @Success 204 {string} string "No Content"
+// @Failure 404 {string} string "Review not found"
+// @Router /admin/reviews/{id} [delete]
+func DeleteReview(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/admin/reviews/")
+
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var productID string
+	err = tx.Get(&productID, `DELETE FROM reviews WHERE id = $1 RETURNING product_id`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Review not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := updateProductRating(tx, productID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	catalogCache.invalidate("products", productID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// updateProductRating recomputes the rating and review count of a product
+// from its approved reviews. The product row is locked first so concurrent
+// moderations of the same product apply one after the other and the last
+// recomputation sees every committed review. The product version is left
+// alone: ratings are not edited by clients and must not fail their writes.
+func updateProductRating(tx *sqlx.Tx, productID string) error {
+	if _, err := tx.Exec(`SELECT 1 FROM products WHERE id = $1 FOR UPDATE`, productID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+		UPDATE products p SET
+			rating = COALESCE(s.rating, 0),
+			reviews_count = s.count
+		FROM (
+			SELECT ROUND(AVG(rating)::numeric, 2) AS rating, COUNT(*) AS count
+			FROM reviews WHERE product_id = $1 AND status = $2
+		) s
+		WHERE p.id = $1
+	`, productID, models.ReviewApproved)
+	return err
+}
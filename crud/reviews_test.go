@@ -0,0 +1,122 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"noble-group-services/models"
+)
+
+func TestValidateReview(t *testing.T) {
+	valid := models.ReviewRequest{AuthorName: "Айгерим", AuthorEmail: "a@example.kz", Rating: 5, Text: "Отлично"}
+	assert.Empty(t, validateReview(valid))
+
+	invalid := models.ReviewRequest{AuthorEmail: "Aigerim <a@example.kz>", Rating: 6, Text: strings.Repeat("я", maxReviewTextLength+1)}
+	var fields []string
+	for _, d := range validateReview(invalid) {
+		fields = append(fields, d.Field)
+	}
+	assert.Equal(t, []string{"authorName", "authorEmail", "rating", "text"}, fields)
+}
+
+func postReview(t *testing.T, productID string, req models.ReviewRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	w := httptest.NewRecorder()
+	ProductItemHandler(w, httptest.NewRequest(http.MethodPost, "/products/"+productID+"/reviews", bytes.NewReader(body)))
+	return w
+}
+
+func moderateReview(t *testing.T, id, status string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	ReviewAdminItemHandler(w, httptest.NewRequest(http.MethodPatch, "/admin/reviews/"+id, strings.NewReader(`{"status":"`+status+`"}`)))
+	return w
+}
+
+func TestProductReviews(t *testing.T) {
+	setupTestDB(t)
+
+	var p models.Product
+	require.NoError(t, db.Get(&p, `SELECT * FROM products ORDER BY id LIMIT 1`))
+	defer db.Exec(`UPDATE products SET rating = $2, reviews_count = $3 WHERE id = $1`, p.ID, p.Rating, p.ReviewsCount)
+	defer db.Exec(`DELETE FROM reviews WHERE product_id = $1 AND author_email LIKE '%@review-test.kz'`, p.ID)
+
+	// A customer who ordered the product is a verified buyer
+	orderID := uuid.New().String()
+	_, err := db.Exec(`INSERT INTO orders (id, order_number, customer_name, customer_phone, customer_email, address, customer_type, total)
+		VALUES ($1, 'REVIEW-TEST', 'Buyer', '+77000000000', 'Buyer@review-test.kz', 'Almaty', 'individual', $2)`, orderID, p.Price)
+	require.NoError(t, err)
+	defer db.Exec(`DELETE FROM orders WHERE id = $1`, orderID)
+	_, err = db.Exec(`INSERT INTO order_items (id, order_id, product_id, quantity, price) VALUES ($1, $2, $3, 1, $4)`,
+		uuid.New().String(), orderID, p.ID, p.Price)
+	require.NoError(t, err)
+
+	w := postReview(t, p.ID, models.ReviewRequest{AuthorName: "Buyer", AuthorEmail: "buyer@review-test.kz", Rating: 5, Text: "Great"})
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+	var verified models.Review
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &verified))
+	assert.True(t, verified.VerifiedPurchase)
+	assert.Equal(t, models.ReviewPending, verified.Status)
+
+	w = postReview(t, p.ID, models.ReviewRequest{AuthorName: "Guest", AuthorEmail: "guest@review-test.kz", Rating: 2})
+	require.Equal(t, http.StatusCreated, w.Code)
+	var guest models.Review
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &guest))
+	assert.False(t, guest.VerifiedPurchase)
+
+	// One review per email
+	w = postReview(t, p.ID, models.ReviewRequest{AuthorName: "Guest", AuthorEmail: "GUEST@review-test.kz", Rating: 4})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = postReview(t, "missing-product", models.ReviewRequest{AuthorName: "Guest", AuthorEmail: "guest@review-test.kz", Rating: 4})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Pending reviews are not public and do not count
+	listReviews := func() PageResponse[models.Review] {
+		w := httptest.NewRecorder()
+		ProductItemHandler(w, httptest.NewRequest(http.MethodGet, "/products/"+p.ID+"/reviews", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var page PageResponse[models.Review]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page
+	}
+	assert.Zero(t, listReviews().Total)
+
+	require.Equal(t, http.StatusOK, moderateReview(t, verified.ID, models.ReviewApproved).Code)
+	require.Equal(t, http.StatusOK, moderateReview(t, guest.ID, models.ReviewApproved).Code)
+
+	var stored models.Product
+	require.NoError(t, db.Get(&stored, `SELECT * FROM products WHERE id = $1`, p.ID))
+	assert.Equal(t, 2, stored.ReviewsCount)
+	assert.InDelta(t, 3.5, stored.Rating, 0.001)
+	assert.Equal(t, p.Version, stored.Version, "moderation must not bump the product version")
+
+	page := listReviews()
+	require.Equal(t, 2, page.Total)
+	assert.Empty(t, page.Items[0].AuthorEmail, "emails are not public")
+
+	// Rejecting and deleting recompute the rating
+	require.Equal(t, http.StatusOK, moderateReview(t, guest.ID, models.ReviewRejected).Code)
+	require.NoError(t, db.Get(&stored, `SELECT * FROM products WHERE id = $1`, p.ID))
+	assert.Equal(t, 1, stored.ReviewsCount)
+	assert.InDelta(t, 5, stored.Rating, 0.001)
+
+	w = httptest.NewRecorder()
+	ReviewAdminItemHandler(w, httptest.NewRequest(http.MethodDelete, "/admin/reviews/"+verified.ID, nil))
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.NoError(t, db.Get(&stored, `SELECT * FROM products WHERE id = $1`, p.ID))
+	assert.Zero(t, stored.ReviewsCount)
+	assert.Zero(t, stored.Rating)
+
+	assert.Equal(t, http.StatusBadRequest, moderateReview(t, guest.ID, "published").Code)
+	assert.Equal(t, http.StatusNotFound, moderateReview(t, "missing", models.ReviewApproved).Code)
+}
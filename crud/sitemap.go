@@ -0,0 +1,245 @@
+package crud
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sitemapPageSize is the most URLs the sitemap protocol allows in one file.
+var sitemapPageSize = 50000
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// sitemapKinds are the catalog tables listed in the sitemap. Each kind is
+// split into /sitemaps/<kind>-<page>.xml files.
+var sitemapKinds = []string{"products", "categories", "manufacturers"}
+
+// sitemapPath returns the storefront page template of a sitemap kind.
+func sitemapPath(kind string) (string, bool) {
+	switch kind {
+	case "products":
+		return storefront.ProductPath, true
+	case "categories":
+		return storefront.CategoryPath, true
+	case "manufacturers":
+		return storefront.ManufacturerPath, true
+	}
+	return "", false
+}
+
+// sitemapEntry is a <sitemap> of the index or a <url> of a child sitemap.
+type sitemapEntry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// sitemapBaseURL is the origin child sitemaps are linked on: this API,
+// which serves them, or the storefront when the API URL is not set, see
+// SetAPIBaseURL. The storefront then has to proxy /sitemaps/ to the API.
+func sitemapBaseURL() string {
+	if apiBaseURL != "" {
+		return apiBaseURL
+	}
+	return storefront.BaseURL
+}
+
+func sitemapLastMod(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// SitemapHandler handles GET /sitemap.xml
+func SitemapHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		serveCatalog(w, r, GetSitemapIndex, sitemapKinds...)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// SitemapPageHandler handles GET /sitemaps/{kind}-{page}.xml
+func SitemapPageHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		kind, _, ok := parseSitemapPath(r.URL.Path)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		serveCatalog(w, r, GetSitemapPage, kind)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// parseSitemapPath splits "/sitemaps/products-2.xml" into its kind and page.
+func parseSitemapPath(path string) (kind string, page int, ok bool) {
+	name, found := strings.CutPrefix(path, "/sitemaps/")
+	if !found {
+		return "", 0, false
+	}
+	name, found = strings.CutSuffix(name, ".xml")
+	if !found {
+		return "", 0, false
+	}
+	i := strings.LastIndexByte(name, '-')
+	if i < 0 {
+		return "", 0, false
+	}
+	kind = name[:i]
+	page, err := strconv.Atoi(name[i+1:])
+	if err != nil || page < 1 || strconv.Itoa(page) != name[i+1:] {
+		return "", 0, false
+	}
+	if _, known := sitemapPath(kind); !known {
+		return "", 0, false
+	}
+	return kind, page, true
+}
+
+// GetSitemapIndex godoc
+// @Summary Sitemap index
+// @Description Sitemap index of the storefront product, category and manufacturer pages. Each child sitemap holds up to 50 000 URLs; lastmod is the latest update of its pages. Child sitemaps are linked on API_URL, which serves them; without it on SHOP_URL, which must then proxy /sitemaps/ to this API. Page links are built from SHOP_URL and the SHOP_*_PATH templates.
+// @Tags sitemap
+// @Produce xml
+// @Success 200 {string} string "Sitemap index"
+// @Success 304 {string} string "Not Modified"
+// @Router /sitemap.xml [get]
+func GetSitemapIndex(w http.ResponseWriter, r *http.Request) {
+	var entries []sitemapEntry
+	for _, kind := range sitemapKinds {
+		var pages []struct {
+			Page      int        `db:"page"`
+			UpdatedAt *time.Time `db:"updated_at"`
+		}
+		// kind is one of sitemapKinds, never client input.
+		err := db.Select(&pages, `
+			SELECT page + 1 AS page, MAX(updated_at) AS updated_at
+			FROM (SELECT updated_at, (ROW_NUMBER() OVER (ORDER BY id) - 1) / $1 AS page FROM `+kind+`) t
+			GROUP BY page ORDER BY page
+		`, sitemapPageSize)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		for _, p := range pages {
+			entries = append(entries, sitemapEntry{
+				Loc:     sitemapBaseURL() + "/sitemaps/" + kind + "-" + strconv.Itoa(p.Page) + ".xml",
+				LastMod: sitemapLastMod(p.UpdatedAt),
+			})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	if err := writeSitemap(w, "sitemapindex", "sitemap", func(yield func(sitemapEntry) error) error {
+		for _, e := range entries {
+			if err := yield(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		log.Printf("sitemap index: %v", err)
+	}
+}
+
+// GetSitemapPage godoc
+// @Summary Child sitemap
+// @Description One page of up to 50 000 storefront URLs of a kind, ordered by id.
+// @Tags sitemap
+// @Produce xml
+// @Param kind path string true "Page kind" Enums(products, categories, manufacturers)
+// @Param page path int true "Page number, from 1"
+// @Success 200 {string} string "Sitemap"
+// @Success 304 {string} string "Not Modified"
+// @Failure 404 {string} string "Sitemap not found"
+// @Router /sitemaps/{kind}-{page}.xml [get]
+func GetSitemapPage(w http.ResponseWriter, r *http.Request) {
+	kind, page, ok := parseSitemapPath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	path, _ := sitemapPath(kind)
+
+	// kind is one of sitemapKinds, never client input.
+	rows, err := db.Queryx(`SELECT id, slug, updated_at FROM `+kind+` ORDER BY id LIMIT $1 OFFSET $2`,
+		sitemapPageSize, (page-1)*sitemapPageSize)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	var row struct {
+		ID        string     `db:"id"`
+		Slug      string     `db:"slug"`
+		UpdatedAt *time.Time `db:"updated_at"`
+	}
+	// Only the first page may be empty; later ones do not exist.
+	more := rows.Next()
+	if !more && page > 1 {
+		if rows.Err() != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	err = writeSitemap(w, "urlset", "url", func(yield func(sitemapEntry) error) error {
+		for ; more; more = rows.Next() {
+			if err := rows.StructScan(&row); err != nil {
+				return err
+			}
+			if err := yield(sitemapEntry{
+				Loc:     storefront.pageURL(path, row.ID, row.Slug),
+				LastMod: sitemapLastMod(row.UpdatedAt),
+			}); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+	// The status line is already sent; the client sees a truncated file.
+	if err != nil {
+		log.Printf("sitemap %s: %v", r.URL.Path, err)
+	}
+}
+
+// writeSitemap streams a sitemap document with root element root and one
+// element named entry per entry that each calls yield with.
+func writeSitemap(w io.Writer, root, entry string, each func(yield func(sitemapEntry) error) error) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(bw)
+	start := xml.StartElement{Name: xml.Name{Local: root}, Attr: []xml.Attr{
+		{Name: xml.Name{Local: "xmlns"}, Value: sitemapNamespace},
+	}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	el := xml.StartElement{Name: xml.Name{Local: entry}}
+	if err := each(func(e sitemapEntry) error { return enc.EncodeElement(e, el) }); err != nil {
+		return err
+	}
+	if err := enc.EncodeToken(start.End()); err != nil {
+		return err
+	}
+	if err := enc.Flush(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
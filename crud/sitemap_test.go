@@ -0,0 +1,95 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSitemapPath(t *testing.T) {
+	kind, page, ok := parseSitemapPath("/sitemaps/products-12.xml")
+	assert.True(t, ok)
+	assert.Equal(t, "products", kind)
+	assert.Equal(t, 12, page)
+
+	for _, path := range []string{
+		"/sitemaps/products-0.xml", "/sitemaps/products-01.xml", "/sitemaps/products.xml",
+		"/sitemaps/orders-1.xml", "/sitemaps/products-1.txt", "/sitemaps/products-+1.xml",
+	} {
+		_, _, ok := parseSitemapPath(path)
+		assert.False(t, ok, path)
+	}
+}
+
+func TestWriteSitemap(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeSitemap(&buf, "urlset", "url", func(yield func(sitemapEntry) error) error {
+		return yield(sitemapEntry{Loc: "https://noble.kz/products/a?b=1&c=2", LastMod: "2026-10-18T12:00:00Z"})
+	})
+	require.NoError(t, err)
+
+	var doc struct {
+		XMLName xml.Name       `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+		URLs    []sitemapEntry `xml:"url"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc), buf.String())
+	assert.Equal(t, []sitemapEntry{{Loc: "https://noble.kz/products/a?b=1&c=2", LastMod: "2026-10-18T12:00:00Z"}}, doc.URLs)
+}
+
+func TestSitemapHandlers(t *testing.T) {
+	setupTestDB(t)
+	defer func(size int) { sitemapPageSize = size }(sitemapPageSize)
+	sitemapPageSize = 2
+	defer SetAPIBaseURL(apiBaseURL)
+	require.NoError(t, SetAPIBaseURL("https://api.example.com"))
+	catalogCache.purge()
+
+	var productCount int
+	require.NoError(t, db.Get(&productCount, "SELECT COUNT(*) FROM products"))
+
+	w := httptest.NewRecorder()
+	SitemapHandler(w, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+	require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+
+	var index struct {
+		Sitemaps []sitemapEntry `xml:"sitemap"`
+	}
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &index))
+	var productPages int
+	for _, s := range index.Sitemaps {
+		assert.NotEmpty(t, s.LastMod)
+		// Child sitemaps are served by the API, not the storefront
+		assert.True(t, strings.HasPrefix(s.Loc, "https://api.example.com/sitemaps/"), s.Loc)
+		if strings.Contains(s.Loc, "/sitemaps/products-") {
+			productPages++
+		}
+	}
+	assert.Equal(t, (productCount+1)/2, productPages)
+
+	var urls int
+	for page := 1; page <= productPages; page++ {
+		w = httptest.NewRecorder()
+		path := "/sitemaps/products-" + strconv.Itoa(page) + ".xml"
+		SitemapPageHandler(w, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, w.Code, path)
+
+		var set struct {
+			URLs []sitemapEntry `xml:"url"`
+		}
+		require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &set))
+		assert.LessOrEqual(t, len(set.URLs), 2)
+		urls += len(set.URLs)
+	}
+	assert.Equal(t, productCount, urls)
+
+	w = httptest.NewRecorder()
+	SitemapPageHandler(w, httptest.NewRequest(http.MethodGet, "/sitemaps/products-999.xml", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
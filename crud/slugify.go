@@ -0,0 +1,133 @@
+package crud
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxSlugLength is the longest slug generated from a name, in bytes.
+const maxSlugLength = 100
+
+// cyrillicToLatin transliterates Russian and Kazakh letters. Kazakh letters
+// follow the 2021 Latin alphabet without diacritics.
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "h", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	// Kazakh
+	'ә': "a", 'ғ': "g", 'қ': "q", 'ң': "n", 'ө': "o", 'ұ': "u", 'ү': "u",
+	'һ': "h", 'і': "i",
+}
+
+// slugify turns a name into a lowercase ASCII slug: Cyrillic is transliterated,
+// accents are stripped and every other run of characters becomes one hyphen.
+// It returns "" when nothing usable is left.
+func slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+
+	write := func(s string) {
+		if s == "" {
+			return
+		}
+		if hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		hyphen = false
+		b.WriteString(s)
+	}
+
+	for _, r := range norm.NFC.String(strings.ToLower(name)) {
+		if latin, ok := cyrillicToLatin[r]; ok {
+			write(latin)
+			continue
+		}
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			write(string(r))
+			continue
+		}
+		if r == '\'' || r == '’' {
+			continue
+		}
+
+		// Keep the base letters of accented Latin characters, e.g. é -> e.
+		if unicode.IsLetter(r) {
+			var base strings.Builder
+			for _, d := range norm.NFKD.String(string(r)) {
+				if d < unicode.MaxASCII && (unicode.IsLetter(d) || unicode.IsDigit(d)) {
+					base.WriteRune(d)
+				}
+			}
+			if base.Len() > 0 {
+				write(base.String())
+				continue
+			}
+		}
+		hyphen = true
+	}
+
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+		if i := strings.LastIndexByte(slug, '-'); i > 0 {
+			slug = slug[:i]
+		}
+	}
+	return slug
+}
+
+// uniqueSlug returns base, or base with the smallest free numeric suffix
+// ("base-2", "base-3", ...) when base is taken by a row or a slug redirect.
+func uniqueSlug(q sqlx.Queryer, e slugEntity, base string) (string, error) {
+	var taken []string
+	err := sqlx.Select(q, &taken, `
+		SELECT slug FROM `+e.Table+` WHERE slug = $1 OR slug LIKE $1 || '-%'
+		UNION
+		SELECT old_slug FROM slug_redirects WHERE entity_type = $2 AND (old_slug = $1 OR old_slug LIKE $1 || '-%')
+	`, base, e.Type)
+	if err != nil {
+		return "", err
+	}
+
+	return nextFreeSlug(base, taken), nil
+}
+
+// nextFreeSlug picks base or the first "base-N" (N >= 2) not in taken.
+func nextFreeSlug(base string, taken []string) string {
+	used := make(map[string]bool, len(taken))
+	for _, s := range taken {
+		used[s] = true
+	}
+	if !used[base] {
+		return base
+	}
+	for n := 2; ; n++ {
+		candidate := base + "-" + strconv.Itoa(n)
+		if !used[candidate] {
+			return candidate
+		}
+	}
+}
+
+// resolveSlug normalizes a client supplied slug, or generates a unique one
+// from name when slug is empty. Names without usable characters fall back to
+// the entity type, e.g. "product-2".
+func resolveSlug(q sqlx.Queryer, e slugEntity, slug, name string) (string, error) {
+	if strings.TrimSpace(slug) != "" {
+		if normalized := slugify(slug); normalized != "" {
+			return normalized, nil
+		}
+	}
+
+	base := slugify(name)
+	if base == "" {
+		base = e.Type
+	}
+	return uniqueSlug(q, e, base)
+}
@@ -0,0 +1,80 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"noble-group-services/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ================== Slug Generation Unit Tests ==================
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"latin", "iPhone 15 Pro", "iphone-15-pro"},
+		{"russian", "Электроника", "elektronika"},
+		{"russian phrase", "Щётка для пыли", "schetka-dlya-pyli"},
+		{"signs dropped", "Подъёмник объёмный", "podemnik-obemnyy"},
+		{"kazakh", "Қазақстан өнімі", "qazaqstan-onimi"},
+		{"kazakh letters", "ӘҒҚҢӨҰҮҺІ", "agqnouuhi"},
+		{"accents", "Crème Brûlée", "creme-brulee"},
+		{"punctuation collapsed", "  MacBook Air (M3) -- 13\" ", "macbook-air-m3-13"},
+		{"apostrophe", "Children's Toys", "childrens-toys"},
+		{"nothing usable", "!!!", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, slugify(tt.in))
+		})
+	}
+}
+
+func TestSlugify_TruncatesAtWordBoundary(t *testing.T) {
+	slug := slugify(strings.Repeat("word ", 40))
+
+	assert.LessOrEqual(t, len(slug), maxSlugLength)
+	assert.False(t, strings.HasSuffix(slug, "-"))
+	assert.True(t, strings.HasSuffix(slug, "word"))
+}
+
+func TestNextFreeSlug(t *testing.T) {
+	assert.Equal(t, "drel", nextFreeSlug("drel", nil))
+	assert.Equal(t, "drel-2", nextFreeSlug("drel", []string{"drel"}))
+	assert.Equal(t, "drel-4", nextFreeSlug("drel", []string{"drel", "drel-2", "drel-3", "drel-udarnaya"}))
+}
+
+func TestManufacturersHandler_Post_GeneratesSlug(t *testing.T) {
+	setupTestDB(t)
+
+	var ids []string
+	defer func() {
+		for _, id := range ids {
+			db.Exec("DELETE FROM manufacturers WHERE id = $1", id)
+		}
+	}()
+
+	for _, want := range []string{"zavod-kazahmys", "zavod-kazahmys-2"} {
+		body, _ := json.Marshal(models.Manufacturer{Name: "Завод Казахмыс"})
+		req := httptest.NewRequest(http.MethodPost, "/products/manufacturers", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		ManufacturersHandler(w, req)
+		require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+
+		var created models.Manufacturer
+		json.NewDecoder(w.Body).Decode(&created)
+		ids = append(ids, created.ID)
+		assert.Equal(t, want, created.Slug)
+	}
+}
@@ -0,0 +1,89 @@
+package crud
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+)
+
+// slugEntity is a table whose rows are addressable by slug.
+type slugEntity struct {
+	Type  string // slug_redirects.entity_type
+	Table string
+}
+
+var (
+	productSlugs      = slugEntity{Type: "product", Table: "products"}
+	categorySlugs     = slugEntity{Type: "category", Table: "categories"}
+	manufacturerSlugs = slugEntity{Type: "manufacturer", Table: "manufacturers"}
+)
+
+// claimSlug drops any redirect from slug, since slug now belongs to a live row.
+func claimSlug(ex sqlx.Execer, e slugEntity, slug string) error {
+	_, err := ex.Exec(`DELETE FROM slug_redirects WHERE entity_type = $1 AND old_slug = $2`, e.Type, slug)
+	return err
+}
+
+// recordSlugChange redirects oldSlug to row id when an update changed its slug.
+func recordSlugChange(tx *sqlx.Tx, e slugEntity, id, oldSlug, newSlug string) error {
+	if oldSlug == newSlug {
+		return nil
+	}
+	if err := claimSlug(tx, e, newSlug); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+		INSERT INTO slug_redirects (entity_type, old_slug, entity_id, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (entity_type, old_slug) DO UPDATE SET entity_id = EXCLUDED.entity_id, created_at = EXCLUDED.created_at
+	`, e.Type, oldSlug, id)
+	return err
+}
+
+// redirectSlug answers a lookup of an unknown slug: 301 to the current slug
+// when it was recorded as a former slug, 404 otherwise.
+func redirectSlug(w http.ResponseWriter, r *http.Request, e slugEntity, slug string, prefix string) {
+	var current string
+	err := db.Get(&current, `
+		SELECT t.slug FROM slug_redirects sr
+		JOIN `+e.Table+` t ON t.id = sr.entity_id
+		WHERE sr.entity_type = $1 AND sr.old_slug = $2
+	`, e.Type, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, prefix+current, http.StatusMovedPermanently)
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// isCheckViolation reports whether err is a Postgres check constraint violation.
+func isCheckViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23514"
+}
+
+// writeConflict reports a unique violation, e.g. a slug that is already taken.
+func writeConflict(w http.ResponseWriter, err error) {
+	http.Error(w, conflictMessage(err), http.StatusConflict)
+}
+
+// conflictMessage describes a unique violation with the key that clashed.
+func conflictMessage(err error) string {
+	var pgErr *pgconn.PgError
+	errors.As(err, &pgErr)
+	return "Already exists: " + pgErr.Detail
+}
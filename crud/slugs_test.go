@@ -0,0 +1,97 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"noble-group-services/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ================== Slug Lookup Unit Tests ==================
+
+func TestProductBySlugHandler_Get(t *testing.T) {
+	setupTestDB(t)
+
+	var p models.Product
+	err := db.Get(&p, "SELECT id, slug FROM products LIMIT 1")
+	if err != nil {
+		t.Skip("No products in database")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/products/by-slug/"+p.Slug, nil)
+	w := httptest.NewRecorder()
+	ProductBySlugHandler(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var fetched models.Product
+	json.NewDecoder(w.Body).Decode(&fetched)
+	assert.Equal(t, p.ID, fetched.ID)
+
+	req = httptest.NewRequest(http.MethodGet, "/products/by-slug/no-such-product", nil)
+	w = httptest.NewRecorder()
+	ProductBySlugHandler(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestManufacturerBySlugHandler_RedirectsOldSlug(t *testing.T) {
+	setupTestDB(t)
+
+	// Create
+	body, _ := json.Marshal(models.Manufacturer{Name: "Slug Redirect", Slug: "slug-redirect-old"})
+	req := httptest.NewRequest(http.MethodPost, "/products/manufacturers", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	ManufacturersHandler(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+
+	var created models.Manufacturer
+	json.NewDecoder(w.Body).Decode(&created)
+	defer db.Exec("DELETE FROM manufacturers WHERE id = $1", created.ID)
+	defer db.Exec("DELETE FROM slug_redirects WHERE entity_id = $1", created.ID)
+
+	// Rename the slug
+	created.Slug = "slug-redirect-new"
+	body, _ = json.Marshal(created)
+	req = httptest.NewRequest(http.MethodPut, "/products/manufacturers/"+created.ID, bytes.NewBuffer(body))
+	req.Header.Set("If-Match", versionETag(created.Version))
+	w = httptest.NewRecorder()
+	ManufacturerItemHandler(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// The old slug redirects to the new one
+	req = httptest.NewRequest(http.MethodGet, "/products/manufacturers/by-slug/slug-redirect-old", nil)
+	w = httptest.NewRecorder()
+	ManufacturerBySlugHandler(w, req)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/products/manufacturers/by-slug/slug-redirect-new", w.Header().Get("Location"))
+
+	// The new slug resolves directly
+	req = httptest.NewRequest(http.MethodGet, "/products/manufacturers/by-slug/slug-redirect-new", nil)
+	w = httptest.NewRecorder()
+	ManufacturerBySlugHandler(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCategoriesHandler_Post_DuplicateSlug(t *testing.T) {
+	setupTestDB(t)
+
+	body, _ := json.Marshal(models.Category{Name: "Duplicate", Slug: "duplicate-slug-category"})
+	req := httptest.NewRequest(http.MethodPost, "/products/categories", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	CategoriesHandler(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created models.Category
+	json.NewDecoder(w.Body).Decode(&created)
+	defer db.Exec("DELETE FROM categories WHERE id = $1", created.ID)
+
+	req = httptest.NewRequest(http.MethodPost, "/products/categories", bytes.NewBuffer(body))
+	w = httptest.NewRecorder()
+	CategoriesHandler(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
@@ -0,0 +1,118 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"noble-group-services/models"
+	"noble-group-services/services/emails"
+	"noble-group-services/services/notify"
+)
+
+// staffNotifyTimeout bounds the delivery of one staff notification.
+const staffNotifyTimeout = 30 * time.Second
+
+// staffOrderEmail is the outbox kind of new order emails to the staff.
+const staffOrderEmail = "staff_order_placed"
+
+// staffEmails is the distribution list emailed about new orders, see
+// SetStaffEmails.
+var staffEmails []string
+
+// SetStaffEmails sets the addresses emailed about new orders. The emails go
+// through the outbox. It must be called before the server starts.
+func SetStaffEmails(to []string) {
+	staffEmails = to
+}
+
+// staffNotifier is told about new orders, see SetStaffNotifier.
+var staffNotifier notify.Notifier
+
+// SetStaffNotifier sets the chat channels the staff learns about new orders
+// through. Without a notifier only staff emails are sent. It must be called
+// before the server starts.
+func SetStaffNotifier(n notify.Notifier) {
+	staffNotifier = n
+}
+
+// queueStaffEmails queues the new order email to every staff address in tx.
+// Call wakeEmailOutbox after tx commits.
+func queueStaffEmails(tx sqlx.Execer, o models.Order, items []emails.Item) error {
+	msg := staffOrderMessage(o, items)
+	for _, to := range staffEmails {
+		if err := queueEmail(tx, staffOrderEmail, to, emails.Message{Subject: msg.Subject, Text: msg.Text}, &o.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyStaff tells the staff chats about a new order in the background.
+// Failures are logged.
+func notifyStaff(o models.Order, items []emails.Item) {
+	if staffNotifier == nil {
+		return
+	}
+	n, msg := staffNotifier, staffOrderMessage(o, items)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), staffNotifyTimeout)
+		defer cancel()
+		if err := n.Notify(ctx, msg); err != nil {
+			log.Printf("Order %s: notifying staff: %v", o.OrderNumber, err)
+		}
+	}()
+}
+
+// staffOrderMessage describes a new order for the staff.
+func staffOrderMessage(o models.Order, items []emails.Item) notify.Message {
+	var b strings.Builder
+	line := func(format string, args ...any) {
+		fmt.Fprintf(&b, format+"\n", args...)
+	}
+
+	line("Новый заказ %s", o.OrderNumber)
+	line("")
+	line("Покупатель: %s", o.CustomerName)
+	line("Телефон: %s", o.CustomerPhone)
+	line("Email: %s", o.CustomerEmail)
+	if o.CompanyName != nil || o.BIN != nil {
+		line("Компания: %s", deref(o.CompanyName))
+		line("БИН: %s", deref(o.BIN))
+	}
+	line("Адрес: %s", o.Address)
+	if o.Comment != nil && *o.Comment != "" {
+		line("Комментарий: %s", *o.Comment)
+	}
+
+	line("")
+	for _, item := range items {
+		name := item.Name
+		if item.Options != "" {
+			name += " (" + item.Options + ")"
+		}
+		line("• %s, %s × %d = %s", name, item.SKU, item.Quantity, emails.FormatMoney(item.Total()))
+	}
+	order := emails.Order{Order: o, Items: items}
+	if discount := order.Discount(); discount > 0 {
+		line("Сумма: %s", emails.FormatMoney(order.Subtotal()))
+		line("Скидка: %s", emails.FormatMoney(discount))
+	}
+	line("Итого: %s", emails.FormatMoney(o.Total))
+
+	return notify.Message{
+		Subject: "Новый заказ " + o.OrderNumber,
+		Text:    strings.TrimSuffix(b.String(), "\n"),
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,39 @@
+package crud
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"noble-group-services/models"
+	"noble-group-services/services/emails"
+)
+
+func TestStaffOrderMessage(t *testing.T) {
+	company, bin := "ТОО «Ромашка»", "123456789012"
+	o := models.Order{
+		OrderNumber: "ORD-2026-000042", CustomerName: "Анна", CustomerPhone: "+77001234567",
+		CustomerEmail: "anna@example.com", Address: "Алматы, ул. Абая, 1", CustomerType: "legal",
+		CompanyName: &company, BIN: &bin, Total: 2_500_000,
+	}
+	items := []emails.Item{
+		{Name: "Генератор", SKU: "GEN-5", Options: "Мощность: 5 кВт", Quantity: 2, Price: 1_000_000},
+		{Name: "Кабель", SKU: "CAB-1", Quantity: 1, Price: 600_000},
+	}
+
+	msg := staffOrderMessage(o, items)
+	assert.Equal(t, "Новый заказ ORD-2026-000042", msg.Subject)
+	for _, want := range []string{
+		"Покупатель: Анна", "Email: anna@example.com", "Компания: ТОО «Ромашка»", "БИН: 123456789012",
+		"• Генератор (Мощность: 5 кВт), GEN-5 × 2 = 2 000 000 ₸",
+		"Сумма: 2 600 000 ₸", "Скидка: 100 000 ₸", "Итого: 2 500 000 ₸",
+	} {
+		assert.Contains(t, msg.Text, want)
+	}
+
+	// Individuals have no company lines and no discount without one
+	o.CompanyName, o.BIN, o.Total = nil, nil, 2_600_000
+	msg = staffOrderMessage(o, items)
+	assert.NotContains(t, msg.Text, "БИН")
+	assert.NotContains(t, msg.Text, "Скидка")
+}
@@ -0,0 +1,59 @@
+package crud
+
+import "strings"
+
+// StorefrontConfig locates catalog pages on the storefront, for links in
+// feeds and sitemaps.
+type StorefrontConfig struct {
+	// BaseURL is the storefront origin, e.g. "https://noble.kz". Without an
+	// API base URL the sitemap index links child sitemaps here, so the
+	// storefront must proxy /sitemaps/ to this API.
+	BaseURL string
+	// Page paths; "{slug}" and "{id}" are replaced.
+	ProductPath      string
+	CategoryPath     string
+	ManufacturerPath string
+}
+
+var storefront = StorefrontConfig{
+	BaseURL:          "http://localhost:3000",
+	ProductPath:      "/products/{slug}",
+	CategoryPath:     "/catalog/{slug}",
+	ManufacturerPath: "/brands/{slug}",
+}
+
+// SetStorefrontConfig overrides the non-empty fields of the storefront
+// configuration. It must be called before the server starts.
+func SetStorefrontConfig(cfg StorefrontConfig) {
+	if cfg.BaseURL != "" {
+		storefront.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	}
+	if cfg.ProductPath != "" {
+		storefront.ProductPath = cfg.ProductPath
+	}
+	if cfg.CategoryPath != "" {
+		storefront.CategoryPath = cfg.CategoryPath
+	}
+	if cfg.ManufacturerPath != "" {
+		storefront.ManufacturerPath = cfg.ManufacturerPath
+	}
+}
+
+// pageURL fills a page path template and makes it absolute.
+func (s StorefrontConfig) pageURL(path, id, slug string) string {
+	return s.BaseURL + strings.NewReplacer("{slug}", slug, "{id}", id).Replace(path)
+}
+
+// absoluteURL resolves a stored path, such as an image, against the
+// storefront. Media uploaded while media URLs were still relative, under
+// /media/, is served by the API instead. Absolute URLs are returned
+// unchanged.
+func (s StorefrontConfig) absoluteURL(u string) string {
+	if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
+		return u
+	}
+	if strings.HasPrefix(u, "/media/") && apiBaseURL != "" {
+		return apiBaseURL + u
+	}
+	return s.BaseURL + "/" + strings.TrimPrefix(u, "/")
+}
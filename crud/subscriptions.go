@@ -0,0 +1,382 @@
+package crud
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"noble-group-services/models"
+	"noble-group-services/services/emails"
+)
+
+const (
+	// subscriptionNotifyBatch bounds the subscriptions queued in one
+	// transaction.
+	subscriptionNotifyBatch = 50
+	// subscriptionEmailKind prefixes the subscription type in the kind of
+	// queued emails, e.g. "subscription_price_below".
+	subscriptionEmailKind = "subscription_"
+	// subscriptionNotifyInterval is how often pending subscriptions are
+	// checked when no product change wakes the notifier, e.g. for changes
+	// made by other replicas.
+	subscriptionNotifyInterval = 5 * time.Minute
+)
+
+// subscriptionWake wakes the notifier after a product handler changed stock
+// or prices. It holds at most one pending wake-up.
+var subscriptionWake = make(chan struct{}, 1)
+
+// wakeSubscriptionNotifier asks the notifier to check pending subscriptions.
+func wakeSubscriptionNotifier() {
+	select {
+	case subscriptionWake <- struct{}{}:
+	default:
+	}
+}
+
+// ProductSubscriptionsHandler handles POST /products/{id}/subscriptions
+func ProductSubscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		CreateSubscription(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// CreateSubscription godoc
+// @Summary Subscribe to a product
+// @Description Ask for an email when an out of stock product is back in stock (back_in_stock), or when its price drops to targetPrice or lower (price_below). Each subscription sends one email; subscribing again while one is pending replaces its targetPrice.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param subscription body models.SubscriptionRequest true "Subscription"
+// @Success 201 {object} models.Subscription
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 404 {string} string "Product not found"
+// @Failure 409 {string} string "Product is in stock"
+// @Router /products/{id}/subscriptions [post]
+func CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	productID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/products/"), "/subscriptions")
+
+	var req models.SubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.Email = strings.TrimSpace(req.Email)
+	if details := validateSubscription(req); len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+
+	var p models.Product
+	err := db.Get(&p, `SELECT id, price, availability FROM products WHERE id = $1`, productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	// Only a transition can be notified
+	if req.Type == models.SubscriptionBackInStock && p.InStock() {
+		http.Error(w, "Product is in stock", http.StatusConflict)
+		return
+	}
+	if req.Type == models.SubscriptionPriceBelow && p.Price <= *req.TargetPrice {
+		http.Error(w, "Price is already at or below targetPrice", http.StatusConflict)
+		return
+	}
+
+	token, err := newSubscriptionToken()
+	if err != nil {
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+	var s models.Subscription
+	err = db.Get(&s, `
+		INSERT INTO product_subscriptions (id, product_id, email, type, target_price, token, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (product_id, LOWER(email), type) WHERE notified_at IS NULL
+		DO UPDATE SET target_price = EXCLUDED.target_price
+		RETURNING *
+	`, uuid.New().String(), productID, req.Email, req.Type, req.TargetPrice, token, time.Now())
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(s)
+}
+
+func validateSubscription(req models.SubscriptionRequest) []ValidationErrorDetail {
+	var details []ValidationErrorDetail
+
+	if addr, err := mail.ParseAddress(req.Email); err != nil || addr.Address != req.Email {
+		details = append(details, ValidationErrorDetail{Field: "email", Message: "A valid email is required"})
+	}
+	switch req.Type {
+	case models.SubscriptionBackInStock:
+		if req.TargetPrice != nil {
+			details = append(details, ValidationErrorDetail{Field: "targetPrice", Message: "Only price_below subscriptions have a targetPrice"})
+		}
+	case models.SubscriptionPriceBelow:
+		if req.TargetPrice == nil || *req.TargetPrice < 0 {
+			details = append(details, ValidationErrorDetail{Field: "targetPrice", Message: "TargetPrice is required and cannot be negative"})
+		}
+	default:
+		details = append(details, ValidationErrorDetail{Field: "type", Message: "Type must be back_in_stock or price_below"})
+	}
+
+	return details
+}
+
+// newSubscriptionToken returns a random unsubscribe token.
+func newSubscriptionToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// UnsubscribeHandler handles GET, POST /subscriptions/unsubscribe
+func UnsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ConfirmUnsubscribe(w, r)
+	case http.MethodPost:
+		Unsubscribe(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// unsubscribePage asks to confirm, so that mail scanners and link
+// prefetchers following the link do not unsubscribe anyone.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1"><title>Отписка от уведомлений</title></head>
+<body>
+<form method="post" action="?token={{.}}">
+<p>Отписаться от уведомлений о товарах?</p>
+<button type="submit">Отписаться</button>
+</form>
+</body>
+</html>
+`))
+
+// ConfirmUnsubscribe godoc
+// @Summary Confirm unsubscribing from product emails
+// @Description The link in subscription emails. Shows a page that unsubscribes with a POST; following the link changes nothing.
+// @Tags products
+// @Produce html
+// @Param token query string true "Token from the email"
+// @Success 200 {string} string "Confirmation page"
+// @Failure 404 {string} string "Subscription not found"
+// @Router /subscriptions/unsubscribe [get]
+func ConfirmUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	if _, err := subscriptionEmailByToken(token); errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	unsubscribePage.Execute(w, token)
+}
+
+// Unsubscribe godoc
+// @Summary Unsubscribe from product emails
+// @Description Cancel every pending subscription of the address the token was sent to. Subscription emails carry this URL in List-Unsubscribe for one-click unsubscribe (RFC 8058).
+// @Tags products
+// @Produce plain
+// @Param token query string true "Token from the email"
+// @Success 200 {string} string "Unsubscribed"
+// @Failure 404 {string} string "Subscription not found"
+// @Router /subscriptions/unsubscribe [post]
+func Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	email, err := subscriptionEmailByToken(r.URL.Query().Get("token"))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Subscription not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = db.Exec(`DELETE FROM product_subscriptions WHERE notified_at IS NULL AND LOWER(email) = LOWER($1)`, email)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "Вы отписались от уведомлений о товарах.")
+}
+
+// subscriptionEmailByToken returns the address an unsubscribe token was
+// sent to, or sql.ErrNoRows.
+func subscriptionEmailByToken(token string) (string, error) {
+	if token == "" {
+		return "", sql.ErrNoRows
+	}
+	var email string
+	err := db.Get(&email, `SELECT email FROM product_subscriptions WHERE token = $1`, token)
+	return email, err
+}
+
+// subscriptionNotice is a pending subscription whose condition holds,
+// together with the product it is about.
+type subscriptionNotice struct {
+	models.Subscription
+	ProductName string `db:"product_name"`
+	ProductSlug string `db:"product_slug"`
+	Price       int    `db:"price"`
+}
+
+// errNoAPIBaseURL is returned when subscription emails cannot link back to
+// this API.
+var errNoAPIBaseURL = errors.New("API base URL is not set, subscription emails need it for unsubscribe links")
+
+// RunSubscriptionNotifier queues the emails of the subscribers whose
+// condition holds whenever a product handler changes stock or prices, and
+// every subscriptionNotifyInterval, until ctx is done. The email outbox
+// delivers them, see RunEmailOutbox. Without an API base URL, see
+// SetAPIBaseURL, it logs and returns, leaving subscriptions pending.
+func RunSubscriptionNotifier(ctx context.Context) {
+	if apiBaseURL == "" {
+		log.Printf("Subscription notifier: %v", errNoAPIBaseURL)
+		return
+	}
+	ticker := time.NewTicker(subscriptionNotifyInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := notifySubscribers(); err != nil {
+			log.Printf("Subscription notifier: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-subscriptionWake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// notifySubscribers queues an email for every pending subscription whose
+// condition holds and marks it notified. It returns the number of emails
+// queued.
+func notifySubscribers() (int, error) {
+	if apiBaseURL == "" {
+		return 0, errNoAPIBaseURL
+	}
+	queued := 0
+	for {
+		n, full, err := notifySubscriberBatch()
+		queued += n
+		if n > 0 {
+			wakeEmailOutbox()
+		}
+		if err != nil || !full {
+			return queued, err
+		}
+	}
+}
+
+// notifySubscriberBatch claims up to subscriptionNotifyBatch due
+// subscriptions, skipping those another replica is claiming, and queues
+// their emails in the same transaction.
+func notifySubscriberBatch() (queued int, full bool, err error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
+	var notices []subscriptionNotice
+	err = tx.Select(&notices, `
+		SELECT s.*, p.name AS product_name, p.slug AS product_slug, p.price
+		FROM product_subscriptions s
+		JOIN products p ON p.id = s.product_id
+		WHERE s.notified_at IS NULL AND (
+			(s.type = 'back_in_stock' AND p.availability IN ('in_stock', 'low_stock'))
+			OR (s.type = 'price_below' AND p.price <= s.target_price)
+		)
+		ORDER BY s.created_at, s.id
+		LIMIT $1
+		FOR UPDATE OF s SKIP LOCKED
+	`, subscriptionNotifyBatch)
+	if err != nil {
+		return 0, false, err
+	}
+
+	ids := make([]string, len(notices))
+	for i, n := range notices {
+		if err := queueEmail(tx, subscriptionEmailKind+n.Type, n.Email, subscriptionEmail(n), nil); err != nil {
+			return 0, false, err
+		}
+		ids[i] = n.ID
+	}
+	if len(ids) > 0 {
+		_, err = tx.Exec(`UPDATE product_subscriptions SET notified_at = NOW() WHERE id = ANY($1)`, ids)
+		if err != nil {
+			return 0, false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, false, err
+	}
+	return len(ids), len(notices) == subscriptionNotifyBatch, nil
+}
+
+// subscriptionEmail writes the email of a due subscription. Its
+// List-Unsubscribe headers allow one-click unsubscribe.
+func subscriptionEmail(n subscriptionNotice) emails.Message {
+	link := storefront.pageURL(storefront.ProductPath, n.ProductID, n.ProductSlug)
+	unsubscribe := apiBaseURL + "/subscriptions/unsubscribe?token=" + url.QueryEscape(n.Token)
+
+	var subject, text string
+	switch n.Type {
+	case models.SubscriptionPriceBelow:
+		subject = "Цена снижена: " + n.ProductName
+		text = fmt.Sprintf("Цена товара «%s» снизилась до %d.", n.ProductName, n.Price)
+	default:
+		subject = "Снова в наличии: " + n.ProductName
+		text = fmt.Sprintf("Товар «%s» снова в наличии по цене %d.", n.ProductName, n.Price)
+	}
+	return emails.Message{
+		Subject: subject,
+		Text: fmt.Sprintf("Здравствуйте!\n\n%s\n%s\n\nВы получили это письмо, потому что подписались на уведомления о товаре.\nОтписаться: %s\n",
+			text, link, unsubscribe),
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribe + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}
+}
@@ -0,0 +1,166 @@
+package crud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"noble-group-services/models"
+	"noble-group-services/services/mailer"
+)
+
+func TestValidateSubscription(t *testing.T) {
+	price := 1000
+	assert.Empty(t, validateSubscription(models.SubscriptionRequest{Email: "a@example.com", Type: models.SubscriptionBackInStock}))
+	assert.Empty(t, validateSubscription(models.SubscriptionRequest{Email: "a@example.com", Type: models.SubscriptionPriceBelow, TargetPrice: &price}))
+
+	fields := func(req models.SubscriptionRequest) []string {
+		var fields []string
+		for _, d := range validateSubscription(req) {
+			fields = append(fields, d.Field)
+		}
+		return fields
+	}
+	assert.Equal(t, []string{"email", "type"}, fields(models.SubscriptionRequest{Email: "Ann <a@example.com>", Type: "restock"}))
+	assert.Equal(t, []string{"targetPrice"}, fields(models.SubscriptionRequest{Email: "a@example.com", Type: models.SubscriptionPriceBelow}))
+	assert.Equal(t, []string{"targetPrice"}, fields(models.SubscriptionRequest{Email: "a@example.com", Type: models.SubscriptionBackInStock, TargetPrice: &price}))
+}
+
+func TestSubscriptionEmail(t *testing.T) {
+	defer SetAPIBaseURL(apiBaseURL)
+	require.NoError(t, SetAPIBaseURL("https://api.example.com/"))
+
+	target := 900
+	n := subscriptionNotice{
+		Subscription: models.Subscription{ProductID: "p1", Type: models.SubscriptionPriceBelow, TargetPrice: &target, Token: "t0k"},
+		ProductName:  "Генератор", ProductSlug: "generator", Price: 850,
+	}
+	msg := subscriptionEmail(n)
+	assert.Equal(t, "Цена снижена: Генератор", msg.Subject)
+	assert.Contains(t, msg.Text, "850")
+	assert.Contains(t, msg.Text, storefront.BaseURL+"/products/generator")
+	assert.Contains(t, msg.Text, "https://api.example.com/subscriptions/unsubscribe?token=t0k")
+	assert.Equal(t, "<https://api.example.com/subscriptions/unsubscribe?token=t0k>", msg.Headers["List-Unsubscribe"])
+	assert.Equal(t, "List-Unsubscribe=One-Click", msg.Headers["List-Unsubscribe-Post"])
+
+	n.Type = models.SubscriptionBackInStock
+	assert.Equal(t, "Снова в наличии: Генератор", subscriptionEmail(n).Subject)
+}
+
+func TestSetAPIBaseURL(t *testing.T) {
+	defer SetAPIBaseURL(apiBaseURL)
+	require.NoError(t, SetAPIBaseURL("https://api.example.com/"))
+	assert.Equal(t, "https://api.example.com", apiBaseURL)
+	assert.NoError(t, SetAPIBaseURL(""))
+	assert.Error(t, SetAPIBaseURL("/api"))
+	assert.Error(t, SetAPIBaseURL("api.example.com"))
+	assert.Equal(t, "", apiBaseURL)
+
+	// Without it subscription emails would carry relative links
+	_, err := notifySubscribers()
+	assert.ErrorIs(t, err, errNoAPIBaseURL)
+}
+
+func TestProductSubscriptions(t *testing.T) {
+	setupTestDB(t)
+	defer SetAPIBaseURL(apiBaseURL)
+	require.NoError(t, SetAPIBaseURL("https://api.example.com"))
+
+	var ref models.Product
+	require.NoError(t, db.Get(&ref, `SELECT * FROM products ORDER BY id LIMIT 1`))
+
+	suffix := uuid.New().String()[:8]
+	body, _ := json.Marshal(models.Product{
+		Name: "Subscription Test " + suffix, ManufacturerID: ref.ManufacturerID, CategoryID: ref.CategoryID,
+		SKU: "SUB-" + suffix, Price: 1000,
+	})
+	w := httptest.NewRecorder()
+	ProductsHandler(w, httptest.NewRequest(http.MethodPost, "/products", bytes.NewReader(body)))
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+	var p models.Product
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	defer db.Exec(`DELETE FROM products WHERE id = $1`, p.ID)
+
+	subscribe := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ProductItemHandler(w, httptest.NewRequest(http.MethodPost, "/products/"+p.ID+"/subscriptions", strings.NewReader(body)))
+		return w
+	}
+	email := "sub-" + suffix + "@example.com"
+	require.Equal(t, http.StatusCreated, subscribe(`{"email":"`+email+`","type":"back_in_stock"}`).Code)
+	w = subscribe(`{"email":"` + email + `","type":"price_below","targetPrice":800}`)
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+	assert.Equal(t, http.StatusConflict, subscribe(`{"email":"`+email+`","type":"price_below","targetPrice":1000}`).Code)
+	assert.Equal(t, http.StatusBadRequest, subscribe(`{"email":"`+email+`","type":"restock"}`).Code)
+
+	w = httptest.NewRecorder()
+	ProductItemHandler(w, httptest.NewRequest(http.MethodPost, "/products/missing/subscriptions", strings.NewReader(`{"email":"`+email+`","type":"back_in_stock"}`)))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Nothing is due until the stock comes back; the email then goes
+	// through the outbox, which retries failed sends
+	defer db.Exec(`DELETE FROM email_outbox WHERE recipient = $1`, email)
+	n, err := notifySubscribers()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	_, err = db.Exec(`UPDATE products SET stock = 3 WHERE id = $1`, p.ID)
+	require.NoError(t, err)
+	n, err = notifySubscribers()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, err = notifySubscribers()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	var queued models.OutboxEmail
+	require.NoError(t, db.Get(&queued, `SELECT * FROM email_outbox WHERE recipient = $1`, email))
+	assert.Equal(t, "subscription_back_in_stock", queued.Kind)
+	assert.Equal(t, models.EmailPending, queued.Status)
+	assert.Contains(t, queued.Headers["List-Unsubscribe"], "/subscriptions/unsubscribe?token=")
+
+	sender := &mailer.Recorder{}
+	_, err = deliverEmails(context.Background(), sender)
+	require.NoError(t, err)
+	var delivered []mailer.Message
+	for _, m := range sender.Messages() {
+		if m.To[0] == email {
+			delivered = append(delivered, m)
+		}
+	}
+	require.Len(t, delivered, 1)
+	assert.Equal(t, "List-Unsubscribe=One-Click", delivered[0].Headers["List-Unsubscribe-Post"])
+
+	// Following the unsubscribe link only asks to confirm; posting to it
+	// drops the pending price alert
+	var token string
+	require.NoError(t, db.Get(&token, `SELECT token FROM product_subscriptions WHERE product_id = $1 AND type = 'back_in_stock'`, p.ID))
+	w = httptest.NewRecorder()
+	UnsubscribeHandler(w, httptest.NewRequest(http.MethodGet, "/subscriptions/unsubscribe?token="+token, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `method="post"`)
+	var pending int
+	require.NoError(t, db.Get(&pending, `SELECT COUNT(*) FROM product_subscriptions WHERE product_id = $1 AND notified_at IS NULL`, p.ID))
+	assert.Equal(t, 1, pending)
+
+	w = httptest.NewRecorder()
+	UnsubscribeHandler(w, httptest.NewRequest(http.MethodPost, "/subscriptions/unsubscribe?token="+token, strings.NewReader("List-Unsubscribe=One-Click")))
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err = db.Exec(`UPDATE products SET price = 700 WHERE id = $1`, p.ID)
+	require.NoError(t, err)
+	n, err = notifySubscribers()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	w = httptest.NewRecorder()
+	UnsubscribeHandler(w, httptest.NewRequest(http.MethodGet, "/subscriptions/unsubscribe?token=unknown", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
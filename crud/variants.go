@@ -0,0 +1,497 @@
+package crud
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"noble-group-services/models"
+)
+
+// optionFilterPrefix starts the listing parameters that filter on variant
+// option values, e.g. option.Мощность=5 кВт.
+const optionFilterPrefix = "option."
+
+// ProductVariantsHandler handles GET, POST /products/{id}/variants and PUT,
+// DELETE /products/{id}/variants/{variantId}
+func ProductVariantsHandler(w http.ResponseWriter, r *http.Request) {
+	_, variantID, _ := parseVariantPath(r.URL.Path)
+	switch {
+	case variantID == "" && r.Method == http.MethodGet:
+		GetProductVariants(w, r)
+	case variantID == "" && r.Method == http.MethodPost:
+		CreateVariant(w, r)
+	case variantID != "" && r.Method == http.MethodPut:
+		UpdateVariant(w, r)
+	case variantID != "" && r.Method == http.MethodDelete:
+		DeleteVariant(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// parseVariantPath splits /products/{id}/variants and
+// /products/{id}/variants/{variantId}.
+func parseVariantPath(path string) (productID, variantID string, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(path, "/products/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] != "variants" {
+		return "", "", false
+	}
+	if len(parts) == 3 {
+		if parts[2] == "" {
+			return "", "", false
+		}
+		variantID = parts[2]
+	}
+	return parts[0], variantID, true
+}
+
+// GetProductVariants godoc
+// @Summary List product variants
+// @Description Get the variants of a product, oldest first.
+// @Tags variants
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {array} models.ProductVariant
+// @Failure 404 {string} string "Product not found"
+// @Router /products/{id}/variants [get]
+func GetProductVariants(w http.ResponseWriter, r *http.Request) {
+	productID, _, _ := parseVariantPath(r.URL.Path)
+	if !productExists(w, r, productID) {
+		return
+	}
+
+	body, _, err := catalogCache.load("variants:"+productID, func() (interface{}, []string, error) {
+		variants, err := productVariants(db, productID)
+		return variants, []string{"products:" + productID}, err
+	})
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSONBody(w, body)
+}
+
+// productVariants loads the variants of a product with their images.
+func productVariants(q sqlx.Queryer, productID string) ([]models.ProductVariant, error) {
+	variants := []models.ProductVariant{}
+	err := sqlx.Select(q, &variants, `SELECT * FROM product_variants WHERE product_id = $1 ORDER BY created_at, id`, productID)
+	for i := range variants {
+		variants[i].Images = productImages(variants[i].Image)
+	}
+	return variants, err
+}
+
+// withVariants fills p.Variants.
+func withVariants(q sqlx.Queryer, p models.Product) (models.Product, error) {
+	variants, err := productVariants(q, p.ID)
+	if len(variants) > 0 {
+		p.Variants = variants
+	}
+	return p, err
+}
+
+// CreateVariant godoc
+// @Summary Create a product variant
+// @Description Add a variant to a product. Its options must give a value, defined by the product, for every product option.
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param variant body models.ProductVariant true "Variant"
+// @Success 201 {object} models.ProductVariant
+// @Header 201 {string} ETag "Variant version"
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 404 {string} string "Product not found"
+// @Failure 409 {string} string "SKU or option combination already exists"
+// @Router /products/{id}/variants [post]
+func CreateVariant(w http.ResponseWriter, r *http.Request) {
+	productID, _, _ := parseVariantPath(r.URL.Path)
+
+	var v models.ProductVariant
+	if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	options, err := lockProductOptions(tx, productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Product not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	v.ID = uuid.New().String()
+	v.ProductID = productID
+	v.CreatedAt = time.Now()
+	v.UpdatedAt = v.CreatedAt
+	v.Version = 1
+	if v.Image == nil {
+		v.Image = models.JSONStringArray{}
+	}
+	if details := validateVariant(options, v); len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO product_variants (id, product_id, sku, options, price, old_price, stock, image, created_at, updated_at, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`, v.ID, v.ProductID, v.SKU, v.Options, v.Price, v.OldPrice, v.Stock, v.Image, v.CreatedAt, v.UpdatedAt, v.Version)
+	if err != nil {
+		if isUniqueViolation(err) {
+			writeConflict(w, err)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := syncStock(tx, productID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	catalogCache.invalidate("products", productID)
+	wakeSubscriptionNotifier()
+
+	v.Images = productImages(v.Image)
+	w.Header().Set("Content-Type", "application/json")
+	setVersionETag(w, v.Version)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(v)
+}
+
+// UpdateVariant godoc
+// @Summary Replace a product variant
+// @Description Replace the SKU, options, prices, stock and images of a variant.
+// @Tags variants
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param variantId path string true "Variant ID"
+// @Param If-Match header string true "ETag of the version being replaced"
+// @Param variant body models.ProductVariant true "Variant"
+// @Success 200 {object} models.ProductVariant
+// @Failure 400 {object} ValidationErrorResponse
+// @Failure 404 {string} string "Variant not found"
+// @Failure 409 {string} string "SKU or option combination already exists"
+// @Failure 412 {string} string "Variant was modified"
+// @Failure 428 {string} string "If-Match header required"
+// @Router /products/{id}/variants/{variantId} [put]
+func UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, _ := parseVariantPath(r.URL.Path)
+
+	var v models.ProductVariant
+	if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tx, current, options, ok := lockVariant(w, r, productID, variantID)
+	if !ok {
+		return
+	}
+	defer tx.Rollback()
+
+	v.ID, v.ProductID, v.CreatedAt = current.ID, current.ProductID, current.CreatedAt
+	if v.Image == nil {
+		v.Image = models.JSONStringArray{}
+	}
+	if details := validateVariant(options, v); len(details) > 0 {
+		writeValidationErrors(w, details)
+		return
+	}
+
+	err := tx.Get(&v, `
+		UPDATE product_variants SET
+			sku=$1, options=$2, price=$3, old_price=$4, stock=$5, image=$6,
+			updated_at=NOW(), version=version + 1
+		WHERE id=$7
+		RETURNING *
+	`, v.SKU, v.Options, v.Price, v.OldPrice, v.Stock, v.Image, v.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			writeConflict(w, err)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// Stock held in warehouses overrides the submitted one
+	err = syncStock(tx, productID)
+	if err == nil {
+		err = tx.Get(&v, `SELECT * FROM product_variants WHERE id = $1`, v.ID)
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	catalogCache.invalidate("products", productID)
+	wakeSubscriptionNotifier()
+
+	v.Images = productImages(v.Image)
+	w.Header().Set("Content-Type", "application/json")
+	setVersionETag(w, v.Version)
+	json.NewEncoder(w).Encode(v)
+}
+
+// DeleteVariant godoc
+// @Summary Delete a product variant
+// @Description Delete a variant. Orders keep their lines without the variant reference.
+// @Tags variants
+// @Param id path string true "Product ID"
+// @Param variantId path string true "Variant ID"
+// @Param If-Match header string true "ETag of the version being deleted"
+// @Success 204 {string} string "No Content"
+// @Failure 404 {string} string "Variant not found"
+// @Failure 412 {string} string "Variant was modified"
+// @Failure 428 {string} string "If-Match header required"
+// @Router /products/{id}/variants/{variantId} [delete]
+func DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, _ := parseVariantPath(r.URL.Path)
+
+	tx, _, _, ok := lockVariant(w, r, productID, variantID)
+	if !ok {
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM product_variants WHERE id = $1`, variantID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := syncStock(tx, productID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	catalogCache.invalidate("products", productID)
+	wakeSubscriptionNotifier()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lockVariant starts a transaction holding the product and the variant,
+// checked against the request's If-Match header. On failure it has already
+// responded.
+func lockVariant(w http.ResponseWriter, r *http.Request, productID, variantID string) (*sqlx.Tx, models.ProductVariant, models.ProductOptions, bool) {
+	var current models.ProductVariant
+	tx, err := db.Beginx()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return nil, current, nil, false
+	}
+
+	options, err := lockProductOptions(tx, productID)
+	if err == nil {
+		err = tx.Get(&current, `SELECT * FROM product_variants WHERE id = $1 AND product_id = $2 FOR UPDATE`, variantID, productID)
+	}
+	if err == nil {
+		err = checkIfMatch(r, current.Version)
+	}
+	switch {
+	case err == nil:
+		return tx, current, options, true
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "Variant not found", http.StatusNotFound)
+	case errors.Is(err, errPreconditionRequired), errors.Is(err, errPreconditionFailed):
+		writePreconditionError(w, err)
+	default:
+		http.Error(w, "Database error", http.StatusInternalServerError)
+	}
+	tx.Rollback()
+	return nil, current, nil, false
+}
+
+// lockProductOptions locks a product against concurrent changes of its
+// variants and returns its option definitions.
+func lockProductOptions(tx *sqlx.Tx, productID string) (models.ProductOptions, error) {
+	var options models.ProductOptions
+	err := tx.Get(&options, `SELECT options FROM products WHERE id = $1 FOR UPDATE`, productID)
+	return options, err
+}
+
+// syncVariantTotals sets the price of a product with variants to the lowest
+// variant price and its stock to the total variant stock. Like the rating,
+// they are derived and do not bump the product version, but the product is
+// marked as changed so that catalog listings are revalidated.
+func syncVariantTotals(tx *sqlx.Tx, productID string) error {
+	_, err := tx.Exec(`
+		UPDATE products p SET
+			price = COALESCE(s.price, p.price),
+			stock = COALESCE(s.stock, p.stock),
+			updated_at = NOW()
+		FROM (
+			SELECT MIN(price) AS price, SUM(stock) AS stock
+			FROM product_variants WHERE product_id = $1
+		) s
+		WHERE p.id = $1
+	`, productID)
+	return err
+}
+
+// validateVariant checks a variant against the options of its product.
+func validateVariant(options models.ProductOptions, v models.ProductVariant) []ValidationErrorDetail {
+	var details []ValidationErrorDetail
+
+	if strings.TrimSpace(v.SKU) == "" {
+		details = append(details, ValidationErrorDetail{Field: "sku", Message: "SKU is required"})
+	}
+	if msg := variantOptionsError(options, v.Options); msg != "" {
+		details = append(details, ValidationErrorDetail{Field: "options", Message: msg})
+	}
+	if v.Price < 0 {
+		details = append(details, ValidationErrorDetail{Field: "price", Message: "Price cannot be negative"})
+	}
+	if v.OldPrice != nil && *v.OldPrice < 0 {
+		details = append(details, ValidationErrorDetail{Field: "oldPrice", Message: "OldPrice cannot be negative"})
+	}
+	if v.Stock < 0 {
+		details = append(details, ValidationErrorDetail{Field: "stock", Message: "Stock cannot be negative"})
+	}
+
+	return details
+}
+
+// variantOptionsError describes why values do not pick exactly one defined
+// value of every product option, or returns "".
+func variantOptionsError(options models.ProductOptions, values models.VariantOptions) string {
+	if len(options) == 0 {
+		return "Product has no options"
+	}
+	for _, o := range options {
+		value, ok := values[o.Name]
+		if !ok {
+			return "Value of " + o.Name + " is required"
+		}
+		if !slices.Contains(o.Values, value) {
+			return o.Name + " cannot be " + value
+		}
+	}
+	if len(values) > len(options) {
+		return "Options must only name product options"
+	}
+	return ""
+}
+
+// validateOptions checks the option definitions of a product.
+func validateOptions(options models.ProductOptions) []ValidationErrorDetail {
+	names := map[string]bool{}
+	for _, o := range options {
+		if strings.TrimSpace(o.Name) == "" {
+			return []ValidationErrorDetail{{Field: "options", Message: "Option name is required"}}
+		}
+		if names[o.Name] {
+			return []ValidationErrorDetail{{Field: "options", Message: "Duplicate option " + o.Name}}
+		}
+		names[o.Name] = true
+
+		if len(o.Values) == 0 {
+			return []ValidationErrorDetail{{Field: "options", Message: o.Name + " needs at least one value"}}
+		}
+		values := map[string]bool{}
+		for _, v := range o.Values {
+			if strings.TrimSpace(v) == "" || values[v] {
+				return []ValidationErrorDetail{{Field: "options", Message: o.Name + " values must be unique and not empty"}}
+			}
+			values[v] = true
+		}
+	}
+	return nil
+}
+
+// checkVariantsMatch reports variants of a product that the new option
+// definitions would leave without a valid combination.
+func checkVariantsMatch(tx *sqlx.Tx, productID string, options models.ProductOptions) ([]ValidationErrorDetail, error) {
+	variants, err := productVariants(tx, productID)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range variants {
+		if msg := variantOptionsError(options, v.Options); msg != "" {
+			return []ValidationErrorDetail{{Field: "options", Message: "Variant " + v.SKU + ": " + msg}}, nil
+		}
+	}
+	return nil, nil
+}
+
+// optionFilters returns the option.<name> listing parameters as option names
+// with the accepted values, in name order.
+func optionFilters(query map[string][]string) (names []string, values [][]string) {
+	for key := range query {
+		if name, ok := strings.CutPrefix(key, optionFilterPrefix); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values = append(values, query[optionFilterPrefix+name])
+	}
+	return names, values
+}
+
+// optionFilter restricts a product listing to products with a variant that
+// has one of the requested values of every filtered option; in stock when
+// inStock is set.
+func optionFilter(lq *listQuery, names []string, values [][]string, inStock bool) {
+	cond := ``
+	for i, name := range names {
+		cond += ` AND v.options->>` + lq.Args.add(name) + ` = ANY(` + lq.Args.add(values[i]) + `)`
+	}
+	if inStock {
+		cond += ` AND v.stock > 0`
+	}
+	lq.Where += ` AND EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id` + cond + `)`
+}
+
+// applyVariant returns p as sold in variant v.
+func applyVariant(p models.Product, v models.ProductVariant) models.Product {
+	p.Price, p.OldPrice, p.Stock, p.SKU = v.Price, v.OldPrice, v.Stock, v.SKU
+	if len(v.Image) > 0 {
+		p.Image = v.Image
+	}
+	return p
+}
+
+// loadCartVariant returns the variant variantID of a product. Without
+// variantID it reports whether the product has variants and so needs one.
+func loadCartVariant(productID, variantID string) (v *models.ProductVariant, needed bool, err error) {
+	if variantID == "" {
+		err = db.Get(&needed, `SELECT EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1)`, productID)
+		return nil, needed, err
+	}
+	v = &models.ProductVariant{}
+	err = db.Get(v, `SELECT * FROM product_variants WHERE id = $1 AND product_id = $2`, variantID, productID)
+	return v, true, err
+}
@@ -0,0 +1,198 @@
+package crud
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"noble-group-services/models"
+)
+
+func TestParseVariantPath(t *testing.T) {
+	tests := []struct {
+		path, product, variant string
+		ok                     bool
+	}{
+		{"/products/p1/variants", "p1", "", true},
+		{"/products/p1/variants/v1", "p1", "v1", true},
+		{"/products/p1/variants/", "", "", false},
+		{"/products/p1/reviews", "", "", false},
+		{"/products/p1", "", "", false},
+		{"/products//variants", "", "", false},
+		{"/products/p1/variants/v1/x", "", "", false},
+	}
+	for _, tt := range tests {
+		product, variant, ok := parseVariantPath(tt.path)
+		assert.Equal(t, tt.ok, ok, tt.path)
+		assert.Equal(t, tt.product, product, tt.path)
+		assert.Equal(t, tt.variant, variant, tt.path)
+	}
+}
+
+func TestValidateVariantOptions(t *testing.T) {
+	options := models.ProductOptions{
+		{Name: "Мощность", Values: []string{"5 кВт", "10 кВт"}},
+		{Name: "Фазы", Values: []string{"1", "3"}},
+	}
+	assert.Empty(t, validateOptions(options))
+	assert.NotEmpty(t, validateOptions(models.ProductOptions{{Name: "Фазы"}}))
+	assert.NotEmpty(t, validateOptions(models.ProductOptions{{Name: "Фазы", Values: []string{"1", "1"}}}))
+	assert.NotEmpty(t, validateOptions(append(options, options[0])))
+
+	assert.Empty(t, variantOptionsError(options, models.VariantOptions{"Мощность": "5 кВт", "Фазы": "3"}))
+	assert.NotEmpty(t, variantOptionsError(options, models.VariantOptions{"Мощность": "5 кВт"}))
+	assert.NotEmpty(t, variantOptionsError(options, models.VariantOptions{"Мощность": "7 кВт", "Фазы": "3"}))
+	assert.NotEmpty(t, variantOptionsError(options, models.VariantOptions{"Мощность": "5 кВт", "Фазы": "3", "Цвет": "белый"}))
+	assert.NotEmpty(t, variantOptionsError(nil, models.VariantOptions{}))
+
+	v := models.ProductVariant{Options: models.VariantOptions{"Мощность": "5 кВт", "Фазы": "1"}, Price: -1}
+	var fields []string
+	for _, d := range validateVariant(options, v) {
+		fields = append(fields, d.Field)
+	}
+	assert.Equal(t, []string{"sku", "price"}, fields)
+}
+
+func TestOptionFilter(t *testing.T) {
+	query := map[string][]string{
+		"option.Фазы":     {"3"},
+		"option.Мощность": {"5 кВт", "10 кВт"},
+		"option.":         {"x"},
+		"category":        {"generators"},
+	}
+	names, values := optionFilters(query)
+	assert.Equal(t, []string{"Мощность", "Фазы"}, names)
+	assert.Equal(t, [][]string{{"5 кВт", "10 кВт"}, {"3"}}, values)
+
+	var lq listQuery
+	optionFilter(&lq, names, values, true)
+	assert.Equal(t, ` AND EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id`+
+		` AND v.options->>$1 = ANY($2) AND v.options->>$3 = ANY($4) AND v.stock > 0)`, lq.Where)
+	assert.Len(t, lq.Args, 4)
+}
+
+func variantRequest(t *testing.T, method, path, ifMatch string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	w := httptest.NewRecorder()
+	ProductItemHandler(w, req)
+	return w
+}
+
+func TestProductVariants(t *testing.T) {
+	setupTestDB(t)
+
+	var ref models.Product
+	require.NoError(t, db.Get(&ref, `SELECT * FROM products ORDER BY id LIMIT 1`))
+
+	suffix := uuid.New().String()[:8]
+	body, _ := json.Marshal(models.Product{
+		Name: "Variant Test " + suffix, ManufacturerID: ref.ManufacturerID, CategoryID: ref.CategoryID,
+		SKU: "VT-" + suffix, Price: 1, Availability: "in_stock",
+		Options: models.ProductOptions{{Name: "Мощность", Values: []string{"5 кВт", "10 кВт"}}},
+	})
+	w := httptest.NewRecorder()
+	ProductsHandler(w, httptest.NewRequest(http.MethodPost, "/products", bytes.NewReader(body)))
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+	var p models.Product
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	defer db.Exec(`DELETE FROM products WHERE id = $1`, p.ID)
+
+	base := "/products/" + p.ID + "/variants"
+	small := models.ProductVariant{SKU: "VT-" + suffix + "-5", Options: models.VariantOptions{"Мощность": "5 кВт"}, Price: 500, Stock: 2}
+	w = variantRequest(t, http.MethodPost, base, "", small)
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &small))
+
+	large := models.ProductVariant{SKU: "VT-" + suffix + "-10", Options: models.VariantOptions{"Мощность": "10 кВт"}, Price: 900}
+	w = variantRequest(t, http.MethodPost, base, "", large)
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &large))
+
+	// Same option values again, and values the product does not define
+	dup := models.ProductVariant{SKU: "VT-" + suffix + "-dup", Options: small.Options, Price: 1}
+	assert.Equal(t, http.StatusConflict, variantRequest(t, http.MethodPost, base, "", dup).Code)
+	dup.Options = models.VariantOptions{"Мощность": "7 кВт"}
+	assert.Equal(t, http.StatusBadRequest, variantRequest(t, http.MethodPost, base, "", dup).Code)
+
+	// The product shows its variants, the lowest price and the total stock
+	w = httptest.NewRecorder()
+	ProductItemHandler(w, httptest.NewRequest(http.MethodGet, "/products/"+p.ID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var stored models.Product
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stored))
+	assert.Len(t, stored.Variants, 2)
+	assert.Equal(t, 500, stored.Price)
+	assert.Equal(t, 2, stored.Stock)
+
+	listed := func(query string) bool {
+		w := httptest.NewRecorder()
+		ProductsHandler(w, httptest.NewRequest(http.MethodGet, "/products?limit=100&search=variant+test+"+suffix+"&"+query, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		var products []models.Product
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &products))
+		return len(products) == 1
+	}
+	assert.True(t, listed("option.%D0%9C%D0%BE%D1%89%D0%BD%D0%BE%D1%81%D1%82%D1%8C=10+%D0%BA%D0%92%D1%82"))
+	assert.False(t, listed("option.%D0%9C%D0%BE%D1%89%D0%BD%D0%BE%D1%81%D1%82%D1%8C=10+%D0%BA%D0%92%D1%82&inStockOnly=true"))
+	assert.False(t, listed("option.%D0%9C%D0%BE%D1%89%D0%BD%D0%BE%D1%81%D1%82%D1%8C=7+%D0%BA%D0%92%D1%82"))
+
+	// Carts and orders refer to the variant and charge its price
+	sessionID := uuid.New().String()
+	addToCart := func(variantID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/cart", strings.NewReader(`{"productId":"`+p.ID+`","variantId":"`+variantID+`"}`))
+		req.Header.Set("X-Session-ID", sessionID)
+		w := httptest.NewRecorder()
+		CartHandler(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusBadRequest, addToCart("").Code)
+	assert.Equal(t, http.StatusNotFound, addToCart("missing").Code)
+	w = addToCart(small.ID)
+	require.Equal(t, http.StatusOK, w.Code, "Response: %s", w.Body.String())
+	var cart CartResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cart))
+	require.Len(t, cart.Items, 1)
+	assert.Equal(t, small.ID, cart.Items[0].LineID())
+	assert.Equal(t, small.SKU, cart.Items[0].SKU)
+	assert.Equal(t, 500, cart.Total)
+
+	form := models.CheckoutForm{
+		CustomerType: "individual", Name: "Variant Buyer", Phone: "+77001234567",
+		Email: "buyer@example.com", Address: "Almaty, Abay 1",
+		Carts: []models.CartItemRequest{{ProductID: p.ID, VariantID: large.ID, Quantity: 2}},
+	}
+	body, _ = json.Marshal(form)
+	w = httptest.NewRecorder()
+	OrdersHandler(w, httptest.NewRequest(http.MethodPost, "/orders", bytes.NewReader(body)))
+	require.Equal(t, http.StatusCreated, w.Code, "Response: %s", w.Body.String())
+	var order struct {
+		OrderID string `json:"orderId"`
+		Total   int    `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &order))
+	defer db.Exec(`DELETE FROM orders WHERE id = $1`, order.OrderID)
+	assert.Equal(t, 1800, order.Total)
+	var item models.OrderItem
+	require.NoError(t, db.Get(&item, `SELECT * FROM order_items WHERE order_id = $1`, order.OrderID))
+	require.NotNil(t, item.VariantID)
+	assert.Equal(t, large.ID, *item.VariantID)
+
+	// Writes are versioned; deleting recomputes the product price
+	assert.Equal(t, http.StatusPreconditionRequired, variantRequest(t, http.MethodDelete, base+"/"+small.ID, "", nil).Code)
+	assert.Equal(t, http.StatusNoContent, variantRequest(t, http.MethodDelete, base+"/"+small.ID, versionETag(small.Version), nil).Code)
+	require.NoError(t, db.Get(&stored, `SELECT * FROM products WHERE id = $1`, p.ID))
+	assert.Equal(t, 900, stored.Price)
+	assert.Zero(t, stored.Stock)
+}
@@ -24,138 +24,90 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/cart": {
+        "/admin/emails": {
             "get": {
-                "description": "Get the current session's cart",
+                "description": "Get queued, sent and dead customer emails, newest first.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "cart"
+                    "admin"
                 ],
-                "summary": "Get current cart",
+                "summary": "List outgoing emails",
                 "parameters": [
                     {
+                        "enum": [
+                            "pending",
+                            "sent",
+                            "dead"
+                        ],
                         "type": "string",
-                        "description": "Session ID",
-                        "name": "X-Session-ID",
-                        "in": "header"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/crud.CartResponse"
-                        }
-                    }
-                }
-            },
-            "post": {
-                "description": "Add a product to the cart",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "cart"
-                ],
-                "summary": "Add item to cart",
-                "parameters": [
+                        "description": "Email status",
+                        "name": "status",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "Session ID",
-                        "name": "X-Session-ID",
-                        "in": "header"
+                        "description": "Order ID",
+                        "name": "orderId",
+                        "in": "query"
                     },
                     {
-                        "description": "Product ID and Quantity",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "productId": {
-                                    "type": "string"
-                                },
-                                "quantity": {
-                                    "type": "integer"
-                                }
-                            }
-                        }
+                        "type": "string",
+                        "description": "Recipient address",
+                        "name": "recipient",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Keyset cursor",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/crud.CartResponse"
+                            "$ref": "#/definitions/crud.PageResponse-models_OutboxEmail"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "404": {
-                        "description": "Product not found",
+                        "description": "Invalid cursor",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Remove all items from the cart",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "cart"
-                ],
-                "summary": "Clear cart",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Session ID",
-                        "name": "X-Session-ID",
-                        "in": "header"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/crud.CartResponse"
-                        }
-                    }
-                }
             }
         },
-        "/cart/{id}": {
-            "delete": {
-                "description": "Remove a product from the cart",
+        "/admin/emails/{id}": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "cart"
+                    "admin"
                 ],
-                "summary": "Remove item from cart",
+                "summary": "Get an outgoing email",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Session ID",
-                        "name": "X-Session-ID",
-                        "in": "header"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Product ID",
+                        "description": "Email ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -165,73 +117,46 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/crud.CartResponse"
+                            "$ref": "#/definitions/models.OutboxEmail"
                         }
                     },
                     "404": {
-                        "description": "Item not found",
+                        "description": "Email not found",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Update the quantity of a product in the cart",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/admin/emails/{id}/resend": {
+            "post": {
+                "description": "Queue an email for immediate delivery with a fresh set of attempts. Dead emails are retried; sent emails are sent again.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "cart"
+                    "admin"
                 ],
-                "summary": "Update cart item quantity",
+                "summary": "Resend an email",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Session ID",
-                        "name": "X-Session-ID",
-                        "in": "header"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Product ID",
+                        "description": "Email ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "New Quantity",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "quantity": {
-                                    "type": "integer"
-                                }
-                            }
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/crud.CartResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid request",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.OutboxEmail"
                         }
                     },
                     "404": {
-                        "description": "Item not found",
+                        "description": "Email not found",
                         "schema": {
                             "type": "string"
                         }
@@ -239,75 +164,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/orders": {
+        "/admin/media": {
             "post": {
-                "description": "Place a new order with the items in the cart",
+                "description": "Store an image sent as the \"file\" form field and return its URL. The type is detected from the content, not the file name; JPEG, PNG, GIF and WebP are accepted.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "orders"
+                    "admin"
                 ],
-                "summary": "Place a new order",
+                "summary": "Upload media",
                 "parameters": [
                     {
-                        "description": "Checkout Form",
-                        "name": "checkoutForm",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.CheckoutForm"
-                        }
+                        "type": "file",
+                        "description": "Image",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/crud.MediaUpload"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid upload",
                         "schema": {
-                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                            "type": "string"
                         }
-                    }
-                }
-            }
-        },
-        "/orders/{id}": {
-            "delete": {
-                "description": "Delete an order by ID",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "orders"
-                ],
-                "summary": "Delete order",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Order ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "204": {
-                        "description": "No Content",
+                    },
+                    "413": {
+                        "description": "File too large",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Order not found",
+                    "415": {
+                        "description": "Unsupported media type",
                         "schema": {
                             "type": "string"
                         }
@@ -315,20 +214,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/products": {
+        "/admin/products/export": {
             "get": {
-                "description": "Get a list of products with optional filtering",
+                "description": "Stream every product matching the GET /products filters as a sheet that POST /admin/products/import reads back. Columns: sku, name, slug, price, oldPrice, description, features, image, stock, availability, lowStockThreshold, allowBackorder, restockDate, manufacturer, category. manufacturer and category hold slugs; features and image hold JSON arrays. In CSV and XLSX, text cells starting with =, +, - or @ are prefixed with ' so that spreadsheet programs do not run them as formulas; the import removes it.",
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/x-ndjson"
                 ],
                 "tags": [
-                    "products"
+                    "admin"
                 ],
-                "summary": "Get list of products",
+                "summary": "Export products",
                 "parameters": [
                     {
+                        "enum": [
+                            "csv",
+                            "xlsx",
+                            "jsonl"
+                        ],
                         "type": "string",
-                        "description": "Category Slug",
+                        "default": "csv",
+                        "description": "File format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Category Slug, includes subcategories",
                         "name": "category",
                         "in": "query"
                     },
@@ -346,130 +259,157 @@ const docTemplate = `{
                     },
                     {
                         "type": "boolean",
-                        "description": "Only in stock",
+                        "description": "Only in_stock and low_stock products",
                         "name": "inStockOnly",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 20,
-                        "description": "Items per page",
-                        "name": "limit",
+                        "enum": [
+                            "name",
+                            "price_asc",
+                            "price_desc",
+                            "rating",
+                            "newest",
+                            "popularity",
+                            "discount",
+                            "relevance"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "sort",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Product sheet",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Product"
-                            }
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid format or sort",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/products/import": {
             "post": {
-                "description": "Create a new product",
+                "description": "Upsert products by SKU from a CSV, XLSX or JSONL sheet, sent as the \"file\" form field or as the raw body. The first row, or the keys of each JSONL object, names the columns: sku, name, slug, price, oldPrice, description, features, image, stock, availability, lowStockThreshold, allowBackorder, restockDate, manufacturer, category; other columns are ignored. availability is derived from stock and only checked. restockDate is written as 2006-01-02 or 02.01.2006. manufacturer and category match an ID, a slug or a name; manufacturerId and categoryId are accepted as their headers. features and image hold a JSON array or \"|\"-separated values. Columns missing from the sheet keep their stored values. All rows are applied in one transaction: if any row fails nothing is stored and the report lists every error.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "admin"
                 ],
-                "summary": "Create a product",
+                "summary": "Import products",
                 "parameters": [
                     {
-                        "description": "Product",
-                        "name": "product",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Product"
-                        }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Created",
+                        "type": "file",
+                        "description": "CSV, XLSX or JSONL file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Validate and report without storing",
+                        "name": "dryRun",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Create unknown manufacturers and categories",
+                        "name": "createMissing",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Product"
+                            "$ref": "#/definitions/crud.ProductImportReport"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Invalid file",
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ProductImportReport"
+                        }
                     }
                 }
             }
         },
-        "/products/categories": {
+        "/admin/reviews": {
             "get": {
-                "description": "Get a list of all product categories",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "categories"
-                ],
-                "summary": "Get all categories",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Category"
-                            }
-                        }
-                    }
-                }
-            },
-            "post": {
-                "description": "Create a new product category",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get reviews of all products, newest first, with author emails.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "categories"
+                    "admin"
                 ],
-                "summary": "Create a category",
+                "summary": "List reviews for moderation",
                 "parameters": [
                     {
-                        "description": "Category",
-                        "name": "category",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Category"
-                        }
+                        "enum": [
+                            "pending",
+                            "approved",
+                            "rejected"
+                        ],
+                        "type": "string",
+                        "description": "Review status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "productId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Keyset cursor",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Category"
+                            "$ref": "#/definitions/crud.PageResponse-models_Review"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Invalid cursor",
                         "schema": {
                             "type": "string"
                         }
@@ -477,42 +417,39 @@ const docTemplate = `{
                 }
             }
         },
-        "/products/categories/{id}": {
-            "get": {
-                "description": "Get details of a specific category",
-                "produces": [
-                    "application/json"
-                ],
+        "/admin/reviews/{id}": {
+            "delete": {
+                "description": "Delete a review and recompute the product rating.",
                 "tags": [
-                    "categories"
+                    "admin"
                 ],
-                "summary": "Get category by ID",
+                "summary": "Delete a review",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Category ID",
+                        "description": "Review ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content",
                         "schema": {
-                            "$ref": "#/definitions/models.Category"
+                            "type": "string"
                         }
                     },
                     "404": {
-                        "description": "Category not found",
+                        "description": "Review not found",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
             },
-            "put": {
-                "description": "Update an existing category",
+            "patch": {
+                "description": "Approve, reject or return a review to pending. The product rating and review count are recomputed from its approved reviews.",
                 "consumes": [
                     "application/json"
                 ],
@@ -520,24 +457,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "categories"
+                    "admin"
                 ],
-                "summary": "Update category",
+                "summary": "Moderate a review",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Category ID",
+                        "description": "Review ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Category",
-                        "name": "category",
+                        "description": "New status, e.g. {\\",
+                        "name": "status",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Category"
+                            "type": "object"
                         }
                     }
                 ],
@@ -545,47 +482,77 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Category"
+                            "$ref": "#/definitions/models.Review"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Category not found",
+                        "description": "Review not found",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a category",
+            }
+        },
+        "/admin/stock": {
+            "get": {
+                "description": "Get the quantities held per warehouse, product and variant, wrapped in a pagination envelope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "categories"
+                    "admin"
                 ],
-                "summary": "Delete category",
+                "summary": "List stock levels",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Category ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Warehouse ID",
+                        "name": "warehouseId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "productId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Keyset cursor",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/crud.PageResponse-models_StockLevel"
+                        }
                     },
-                    "404": {
-                        "description": "Category not found",
+                    "400": {
+                        "description": "Invalid cursor",
                         "schema": {
                             "type": "string"
                         }
@@ -593,30 +560,86 @@ const docTemplate = `{
                 }
             }
         },
-        "/products/manufacturers": {
+        "/admin/stock/movements": {
             "get": {
-                "description": "Get a list of all manufacturers",
+                "description": "Get the stock ledger, newest first, wrapped in a pagination envelope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "manufacturers"
+                    "admin"
+                ],
+                "summary": "List stock movements",
+                "parameters": [
+                    {
+                        "enum": [
+                            "receipt",
+                            "sale",
+                            "return",
+                            "adjustment",
+                            "transfer"
+                        ],
+                        "type": "string",
+                        "description": "Movement type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Warehouse ID",
+                        "name": "warehouseId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "productId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "orderId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Keyset cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all manufacturers",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Manufacturer"
-                            }
+                            "$ref": "#/definitions/crud.PageResponse-models_StockMovement"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid cursor",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create a new manufacturer",
+                "description": "Append a movement to the stock ledger and apply it to the warehouse level. The stock of the product, or variant, becomes the sum of its warehouse levels. A transfer is recorded as two movements sharing a transferId.",
                 "consumes": [
                     "application/json"
                 ],
@@ -624,17 +647,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "manufacturers"
+                    "admin"
                 ],
-                "summary": "Create a manufacturer",
+                "summary": "Post a stock movement",
                 "parameters": [
                     {
-                        "description": "Manufacturer",
-                        "name": "manufacturer",
+                        "description": "Movement",
+                        "name": "movement",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Manufacturer"
+                            "$ref": "#/definitions/models.StockMovementRequest"
                         }
                     }
                 ],
@@ -642,11 +665,20 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Manufacturer"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.StockMovement"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Insufficient stock",
                         "schema": {
                             "type": "string"
                         }
@@ -654,67 +686,141 @@ const docTemplate = `{
                 }
             }
         },
-        "/products/manufacturers/{id}": {
+        "/admin/stock/reconciliation": {
             "get": {
-                "description": "Get details of a specific manufacturer",
+                "description": "Compare every warehouse level with the sum of its ledger movements, and the stock of every product and variant that has levels with their sum.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "manufacturers"
+                    "admin"
                 ],
-                "summary": "Get manufacturer by ID",
+                "summary": "Reconcile stock",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Manufacturer ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Product ID",
+                        "name": "productId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Warehouse ID, for levels",
+                        "name": "warehouseId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only rows with a difference",
+                        "name": "mismatchesOnly",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Manufacturer"
-                        }
-                    },
-                    "404": {
-                        "description": "Manufacturer not found",
-                        "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/crud.StockReconciliation"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update an existing manufacturer",
-                "consumes": [
+            }
+        },
+        "/admin/warehouses": {
+            "get": {
+                "description": "Get all warehouses ordered by code.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List warehouses",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Warehouse"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a warehouse. New warehouses are active unless active is false.",
+                "consumes": [
                     "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "manufacturers"
+                    "admin"
+                ],
+                "summary": "Create a warehouse",
+                "parameters": [
+                    {
+                        "description": "Warehouse",
+                        "name": "warehouse",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Warehouse"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Warehouse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Code already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/warehouses/{id}": {
+            "put": {
+                "description": "Replace the code, name, address and active flag of a warehouse. Inactive warehouses keep their stock but accept no movements.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
                 ],
-                "summary": "Update manufacturer",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Replace a warehouse",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Manufacturer ID",
+                        "description": "Warehouse ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Manufacturer",
-                        "name": "manufacturer",
+                        "description": "Warehouse",
+                        "name": "warehouse",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Manufacturer"
+                            "$ref": "#/definitions/models.Warehouse"
                         }
                     }
                 ],
@@ -722,71 +828,165 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Manufacturer"
+                            "$ref": "#/definitions/models.Warehouse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Manufacturer not found",
+                        "description": "Warehouse not found",
                         "schema": {
                             "type": "string"
                         }
+                    },
+                    "409": {
+                        "description": "Code already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/cart": {
+            "get": {
+                "description": "Get the current session's cart",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cart"
+                ],
+                "summary": "Get current cart",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "X-Session-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/crud.CartResponse"
+                        }
                     }
                 }
             },
-            "delete": {
-                "description": "Delete a manufacturer",
+            "post": {
+                "description": "Add a product to the cart. Products with variants need the variantId of the chosen variant, which sets the price.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "manufacturers"
+                    "cart"
                 ],
-                "summary": "Delete manufacturer",
+                "summary": "Add item to cart",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Manufacturer ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Session ID",
+                        "name": "X-Session-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Product ID, Variant ID and Quantity",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "productId": {
+                                    "type": "string"
+                                },
+                                "quantity": {
+                                    "type": "integer"
+                                },
+                                "variantId": {
+                                    "type": "string"
+                                }
+                            }
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content",
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/crud.CartResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "404": {
-                        "description": "Manufacturer not found",
+                        "description": "Product or variant not found",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
+            },
+            "delete": {
+                "description": "Remove all items from the cart",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cart"
+                ],
+                "summary": "Clear cart",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "X-Session-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/crud.CartResponse"
+                        }
+                    }
+                }
             }
         },
-        "/products/{id}": {
-            "get": {
-                "description": "Get details of a specific product",
+        "/cart/{id}": {
+            "delete": {
+                "description": "Remove a product from the cart",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "cart"
                 ],
-                "summary": "Get product by ID",
+                "summary": "Remove item from cart",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product ID",
+                        "description": "Session ID",
+                        "name": "X-Session-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Variant ID, or Product ID for products without variants",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -796,19 +996,19 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Product"
+                            "$ref": "#/definitions/crud.CartResponse"
                         }
                     },
                     "404": {
-                        "description": "Product not found",
+                        "description": "Item not found",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
             },
-            "put": {
-                "description": "Update an existing product",
+            "patch": {
+                "description": "Update the quantity of a product in the cart",
                 "consumes": [
                     "application/json"
                 ],
@@ -816,24 +1016,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "cart"
                 ],
-                "summary": "Update product",
+                "summary": "Update cart item quantity",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product ID",
+                        "description": "Session ID",
+                        "name": "X-Session-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Variant ID, or Product ID for products without variants",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Product",
-                        "name": "product",
+                        "description": "New Quantity",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Product"
+                            "type": "object",
+                            "properties": {
+                                "quantity": {
+                                    "type": "integer"
+                                }
+                            }
                         }
                     }
                 ],
@@ -841,7 +1052,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Product"
+                            "$ref": "#/definitions/crud.CartResponse"
                         }
                     },
                     "400": {
@@ -851,95 +1062,3724 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Product not found",
+                        "description": "Item not found",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a product",
+            }
+        },
+        "/feeds/kaspi.xml": {
+            "get": {
+                "description": "Product feed in the Kaspi.kz price list format. The feed is regenerated after the catalog changes.",
                 "produces": [
-                    "application/json"
+                    "text/xml"
                 ],
                 "tags": [
-                    "products"
+                    "feeds"
                 ],
-                "summary": "Delete product",
+                "summary": "Kaspi.kz feed",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Product ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content",
+                    "200": {
+                        "description": "Kaspi feed",
                         "schema": {
                             "type": "string"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Strong validator of the feed"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Last catalog change"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "Product not found",
+                    "304": {
+                        "description": "Not Modified",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "crud.CartResponse": {
-            "type": "object",
-            "properties": {
-                "count": {
-                    "description": "← теперь общее количество товаров!",
-                    "type": "integer"
+        },
+        "/feeds/yml.xml": {
+            "get": {
+                "description": "Product feed in the YML format read by Yandex Market and other marketplaces. The feed is regenerated after the catalog changes.",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "feeds"
+                ],
+                "summary": "Yandex Market feed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "YML feed",
+                        "schema": {
+                            "type": "string"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Strong validator of the feed"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Last catalog change"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/images/{variant}/{key}.{format}": {
+            "get": {
+                "description": "Redirect to a resized copy of an uploaded image, generating and storing it on first use. Variants: thumb (160px), card (480px), full (1200px) wide.",
+                "tags": [
+                    "media"
+                ],
+                "summary": "Get an image variant",
+                "parameters": [
+                    {
+                        "enum": [
+                            "thumb",
+                            "card",
+                            "full"
+                        ],
+                        "type": "string",
+                        "description": "Variant",
+                        "name": "variant",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Storage key of the original",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "jpeg",
+                            "webp"
+                        ],
+                        "type": "string",
+                        "description": "Format",
+                        "name": "format",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the variant",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Image not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "Original is not a readable image",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders": {
+            "post": {
+                "description": "Place a new order with the items in the cart",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Place a new order",
+                "parameters": [
+                    {
+                        "description": "Checkout Form",
+                        "name": "checkoutForm",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CheckoutForm"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/orders/{id}": {
+            "delete": {
+                "description": "Delete an order by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Delete order",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Set the status of an order, and optionally its tracking number. The customer is emailed when the status changes; the email for shipped orders includes the tracking number.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "Change order status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Status",
+                        "name": "update",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.OrderStatusUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Order not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products": {
+            "get": {
+                "description": "Get a list of products with optional filtering",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Get list of products",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category Slug, includes subcategories",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Manufacturer Slug",
+                        "name": "manufacturer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search term",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only in_stock and low_stock products",
+                        "name": "inStockOnly",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only products with a variant having this value of option {name}; repeat for any of several values",
+                        "name": "option.{name}",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only products with this value of enum or boolean attribute {code}; repeat for any of several values",
+                        "name": "attr.{code}",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Only products with number attribute {code} at least this",
+                        "name": "attr.{code}.min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Only products with number attribute {code} at most this",
+                        "name": "attr.{code}.max",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "price_asc",
+                            "price_desc",
+                            "rating",
+                            "newest",
+                            "popularity",
+                            "discount",
+                            "relevance"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Product"
+                            }
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "Configured with CATALOG_CACHE_CONTROL"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Strong validator of the listing"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Last catalog change"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort or filter",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new product. When slug is omitted it is generated from the name, transliterating Cyrillic.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Create a product",
+                "parameters": [
+                    {
+                        "description": "Product",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Slug or SKU already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/by-slug/{slug}": {
+            "get": {
+                "description": "Get details of a specific product by its slug. Former slugs redirect to the current one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Get product by slug",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product Slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Product version"
+                            }
+                        }
+                    },
+                    "301": {
+                        "description": "Moved to the current slug",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/categories": {
+            "get": {
+                "description": "Get a list of all product categories",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Get all categories",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Category"
+                            }
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "Configured with CATALOG_CACHE_CONTROL"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Strong validator of the listing"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Last catalog change"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new product category. When slug is omitted it is generated from the name, transliterating Cyrillic.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Create a category",
+                "parameters": [
+                    {
+                        "description": "Category",
+                        "name": "category",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Category"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Category"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Slug already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/categories/by-slug/{slug}": {
+            "get": {
+                "description": "Get details of a specific category by its slug, including its breadcrumb path. Former slugs redirect to the current one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Get category by slug",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category Slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Category"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Category version"
+                            }
+                        }
+                    },
+                    "301": {
+                        "description": "Moved to the current slug",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/categories/tree": {
+            "get": {
+                "description": "Get all categories nested under their parents, each with its breadcrumb path",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Get category tree",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CategoryNode"
+                            }
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "Configured with CATALOG_CACHE_CONTROL"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Strong validator of the listing"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Last catalog change"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/categories/{id}": {
+            "get": {
+                "description": "Get details of a specific category, including its breadcrumb path. The ETag header carries the category version expected in If-Match by writes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Get category by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Category"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Category version"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace an existing category. Name and slug are required; the new parent may not be the category itself or one of its descendants.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Replace category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being replaced",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Category",
+                        "name": "category",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Category"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Category"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Slug already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Category was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a category",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Delete category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being deleted",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Category was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Partially update a category with JSON Merge Patch (RFC 7386): only fields present in the body change, null clears a field.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Patch category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being replaced",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Merge patch",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Category"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Slug already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Category was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/categories/{id}/attributes": {
+            "get": {
+                "description": "Get the attributes that apply to the products of a category: its own and those inherited from its parents, from the root down.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attributes"
+                ],
+                "summary": "List category attributes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Attribute"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Define an attribute for the products of a category and its subcategories. Codes are unique along a category's ancestors and subcategories; values are only allowed for enums and unit only for numbers.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attributes"
+                ],
+                "summary": "Create a category attribute",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Attribute",
+                        "name": "attribute",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Attribute"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Attribute"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Code already used",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/categories/{id}/attributes/{attributeId}": {
+            "put": {
+                "description": "Replace the name, unit, enum values and position of an attribute. Code and type cannot change, and enum values in use cannot be removed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attributes"
+                ],
+                "summary": "Replace a category attribute",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Attribute ID",
+                        "name": "attributeId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Attribute",
+                        "name": "attribute",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Attribute"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Attribute"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Attribute not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete an attribute together with the product values of it.",
+                "tags": [
+                    "attributes"
+                ],
+                "summary": "Delete a category attribute",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Attribute ID",
+                        "name": "attributeId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Attribute not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/compare": {
+            "get": {
+                "description": "Get products side by side with a matrix of their attribute values. Rows follow the attribute order of the first product's category; each row holds one value per product, in the requested order.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attributes"
+                ],
+                "summary": "Compare products",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated product IDs, at most 10",
+                        "name": "ids",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ProductComparison"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ids",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/manufacturers": {
+            "get": {
+                "description": "Get a list of all manufacturers",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manufacturers"
+                ],
+                "summary": "Get all manufacturers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Manufacturer"
+                            }
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "Configured with CATALOG_CACHE_CONTROL"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Strong validator of the listing"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Last catalog change"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new manufacturer. When slug is omitted it is generated from the name, transliterating Cyrillic.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manufacturers"
+                ],
+                "summary": "Create a manufacturer",
+                "parameters": [
+                    {
+                        "description": "Manufacturer",
+                        "name": "manufacturer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Manufacturer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Manufacturer"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Slug already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/manufacturers/by-slug/{slug}": {
+            "get": {
+                "description": "Get details of a specific manufacturer by its slug. Former slugs redirect to the current one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manufacturers"
+                ],
+                "summary": "Get manufacturer by slug",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Manufacturer Slug",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Manufacturer"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Manufacturer version"
+                            }
+                        }
+                    },
+                    "301": {
+                        "description": "Moved to the current slug",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Manufacturer not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/manufacturers/{id}": {
+            "get": {
+                "description": "Get details of a specific manufacturer. The ETag header carries the manufacturer version expected in If-Match by writes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manufacturers"
+                ],
+                "summary": "Get manufacturer by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Manufacturer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Manufacturer"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Manufacturer version"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Manufacturer not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace an existing manufacturer. Name and slug are required.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manufacturers"
+                ],
+                "summary": "Replace manufacturer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Manufacturer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being replaced",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Manufacturer",
+                        "name": "manufacturer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Manufacturer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Manufacturer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Manufacturer not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Slug already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Manufacturer was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a manufacturer",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manufacturers"
+                ],
+                "summary": "Delete manufacturer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Manufacturer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being deleted",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Manufacturer not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Manufacturer was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Partially update a manufacturer with JSON Merge Patch (RFC 7386): only fields present in the body change, null clears a field.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manufacturers"
+                ],
+                "summary": "Patch manufacturer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Manufacturer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being replaced",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Merge patch",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Manufacturer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Manufacturer not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Slug already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Manufacturer was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}": {
+            "get": {
+                "description": "Get details of a specific product. The ETag header carries the product version expected in If-Match by writes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Get product by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Product version"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace an existing product. Every writable field is overwritten; name, slug, manufacturerId and categoryId are required.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Replace product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being replaced",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Product",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Slug or SKU already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Product was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a product",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Delete product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being deleted",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Product was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Partially update a product with JSON Merge Patch (RFC 7386): only fields present in the body change, null clears a field.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Patch product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being replaced",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Merge patch",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Slug or SKU already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Product was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/attributes": {
+            "get": {
+                "description": "Get the attribute values of a product, in the order of its category's attributes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attributes"
+                ],
+                "summary": "Get product attributes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AttributeValue"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Replace the attribute values of a product with a map from attribute code to value: a number, one of the enum values, or true/false. Attributes left out or null are cleared.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attributes"
+                ],
+                "summary": "Set product attributes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Values by attribute code",
+                        "name": "values",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.AttributeValue"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/reviews": {
+            "get": {
+                "description": "Get the approved reviews of a product, newest first, wrapped in a pagination envelope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "List product reviews",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Keyset cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/crud.PageResponse-models_Review"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid cursor",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Submit a review of a product. It is published once a moderator approves it. The review is marked as a verified purchase when an order placed with authorEmail contains the product. Each email can review a product once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Submit a review",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Review",
+                        "name": "review",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Review"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Product already reviewed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/subscriptions": {
+            "post": {
+                "description": "Ask for an email when an out of stock product is back in stock (back_in_stock), or when its price drops to targetPrice or lower (price_below). Each subscription sends one email; subscribing again while one is pending replaces its targetPrice.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Subscribe to a product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Subscription",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Product is in stock",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/variants": {
+            "get": {
+                "description": "Get the variants of a product, oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "variants"
+                ],
+                "summary": "List product variants",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ProductVariant"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Add a variant to a product. Its options must give a value, defined by the product, for every product option.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "variants"
+                ],
+                "summary": "Create a product variant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Variant",
+                        "name": "variant",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductVariant"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductVariant"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Variant version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Product not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "SKU or option combination already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{id}/variants/{variantId}": {
+            "put": {
+                "description": "Replace the SKU, options, prices, stock and images of a variant.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "variants"
+                ],
+                "summary": "Replace a product variant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Variant ID",
+                        "name": "variantId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being replaced",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Variant",
+                        "name": "variant",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductVariant"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProductVariant"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/crud.ValidationErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Variant not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "SKU or option combination already exists",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Variant was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a variant. Orders keep their lines without the variant reference.",
+                "tags": [
+                    "variants"
+                ],
+                "summary": "Delete a product variant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Variant ID",
+                        "name": "variantId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the version being deleted",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Variant not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "Variant was modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header required",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/sitemap.xml": {
+            "get": {
+                "description": "Sitemap index of the storefront product, category and manufacturer pages. Each child sitemap holds up to 50 000 URLs; lastmod is the latest update of its pages. Child sitemaps are linked on API_URL, which serves them; without it on SHOP_URL, which must then proxy /sitemaps/ to this API. Page links are built from SHOP_URL and the SHOP_*_PATH templates.",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "sitemap"
+                ],
+                "summary": "Sitemap index",
+                "responses": {
+                    "200": {
+                        "description": "Sitemap index",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/sitemaps/{kind}-{page}.xml": {
+            "get": {
+                "description": "One page of up to 50 000 storefront URLs of a kind, ordered by id.",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "sitemap"
+                ],
+                "summary": "Child sitemap",
+                "parameters": [
+                    {
+                        "enum": [
+                            "products",
+                            "categories",
+                            "manufacturers"
+                        ],
+                        "type": "string",
+                        "description": "Page kind",
+                        "name": "kind",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, from 1",
+                        "name": "page",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sitemap",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Sitemap not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/unsubscribe": {
+            "get": {
+                "description": "The link in subscription emails. Shows a page that unsubscribes with a POST; following the link changes nothing.",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Confirm unsubscribing from product emails",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token from the email",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Confirmation page",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Cancel every pending subscription of the address the token was sent to. Subscription emails carry this URL in List-Unsubscribe for one-click unsubscribe (RFC 8058).",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Unsubscribe from product emails",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token from the email",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Unsubscribed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/orders": {
+            "get": {
+                "description": "Get orders, newest first, wrapped in a pagination envelope. Pass either page or the nextCursor of a previous response.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "orders"
+                ],
+                "summary": "List orders",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Keyset cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/crud.PageResponse-models_Order"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid cursor",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/products": {
+            "get": {
+                "description": "Get products wrapped in a pagination envelope. Pass either page or the nextCursor of a previous response.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Get a page of products",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Category Slug, includes subcategories",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Manufacturer Slug",
+                        "name": "manufacturer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search term",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only in_stock and low_stock products",
+                        "name": "inStockOnly",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only products with a variant having this value of option {name}; repeat for any of several values",
+                        "name": "option.{name}",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only products with this value of enum or boolean attribute {code}; repeat for any of several values",
+                        "name": "attr.{code}",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Only products with number attribute {code} at least this",
+                        "name": "attr.{code}.min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Only products with number attribute {code} at most this",
+                        "name": "attr.{code}.max",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "price_asc",
+                            "price_desc",
+                            "rating",
+                            "newest",
+                            "popularity",
+                            "discount",
+                            "relevance"
+                        ],
+                        "type": "string",
+                        "description": "Sort order",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Keyset cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/crud.PageResponse-models_Product"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "Configured with CATALOG_CACHE_CONTROL"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Strong validator of the listing"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Last catalog change"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort, filter or cursor",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/products/categories": {
+            "get": {
+                "description": "Get categories wrapped in a pagination envelope. Pass either page or the nextCursor of a previous response.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Get a page of categories",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Keyset cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/crud.PageResponse-models_Category"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "Configured with CATALOG_CACHE_CONTROL"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Strong validator of the listing"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Last catalog change"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid cursor",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/products/manufacturers": {
+            "get": {
+                "description": "Get manufacturers wrapped in a pagination envelope. Pass either page or the nextCursor of a previous response.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "manufacturers"
+                ],
+                "summary": "Get a page of manufacturers",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Keyset cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/crud.PageResponse-models_Manufacturer"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "Configured with CATALOG_CACHE_CONTROL"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "Strong validator of the listing"
+                            },
+                            "Last-Modified": {
+                                "type": "string",
+                                "description": "Last catalog change"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid cursor",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "crud.CartResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "общее количество товаров",
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CartItem"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "crud.ComparedAttribute": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "differs": {
+                    "description": "Differs is set when the products do not all have the same value.",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "values": {
+                    "type": "array",
+                    "items": {}
+                }
+            }
+        },
+        "crud.ItemReconciliation": {
+            "type": "object",
+            "properties": {
+                "difference": {
+                    "description": "Difference is Stock minus Levels.",
+                    "type": "integer"
+                },
+                "levels": {
+                    "type": "integer"
+                },
+                "productId": {
+                    "type": "string"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "variantId": {
+                    "type": "string"
+                }
+            }
+        },
+        "crud.LevelReconciliation": {
+            "type": "object",
+            "properties": {
+                "difference": {
+                    "description": "Difference is Level minus Movements.",
+                    "type": "integer"
+                },
+                "level": {
+                    "type": "integer"
+                },
+                "movements": {
+                    "type": "integer"
+                },
+                "productId": {
+                    "type": "string"
+                },
+                "variantId": {
+                    "type": "string"
+                },
+                "warehouseId": {
+                    "type": "string"
+                }
+            }
+        },
+        "crud.MediaUpload": {
+            "type": "object",
+            "properties": {
+                "contentType": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "crud.PageResponse-models_Category": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Category"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "crud.PageResponse-models_Manufacturer": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Manufacturer"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "crud.PageResponse-models_Order": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Order"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "crud.PageResponse-models_OutboxEmail": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OutboxEmail"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "crud.PageResponse-models_Product": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Product"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "crud.PageResponse-models_Review": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Review"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "crud.PageResponse-models_StockLevel": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StockLevel"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "crud.PageResponse-models_StockMovement": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.StockMovement"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "crud.ProductComparison": {
+            "type": "object",
+            "properties": {
+                "attributes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/crud.ComparedAttribute"
+                    }
+                },
+                "products": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Product"
+                    }
+                }
+            }
+        },
+        "crud.ProductImportError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                },
+                "sku": {
+                    "type": "string"
+                }
+            }
+        },
+        "crud.ProductImportReport": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "createdCategories": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "createdManufacturers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "dryRun": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/crud.ProductImportError"
+                    }
+                },
+                "ignoredColumns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rows": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "crud.StockReconciliation": {
+            "type": "object",
+            "properties": {
+                "balanced": {
+                    "description": "Balanced is set when every row below has no difference.",
+                    "type": "boolean"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/crud.ItemReconciliation"
+                    }
+                },
+                "levels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/crud.LevelReconciliation"
+                    }
+                }
+            }
+        },
+        "crud.ValidationErrorDetail": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "crud.ValidationErrorResponse": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/crud.ValidationErrorDetail"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Attribute": {
+            "type": "object",
+            "properties": {
+                "categoryId": {
+                    "type": "string"
+                },
+                "code": {
+                    "description": "Code names the attribute in filters, e.g. attr.power.min=5.",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "values": {
+                    "description": "Values lists the allowed values of an enum attribute.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.AttributeValue": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "value": {}
+            }
+        },
+        "models.Breadcrumb": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CartItem": {
+            "type": "object",
+            "properties": {
+                "allowBackorder": {
+                    "type": "boolean"
+                },
+                "attributes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AttributeValue"
+                    }
+                },
+                "availability": {
+                    "type": "string"
+                },
+                "category": {
+                    "$ref": "#/definitions/models.Category"
+                },
+                "categoryId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "features": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "image": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductImage"
+                    }
+                },
+                "lowStockThreshold": {
+                    "description": "LowStockThreshold is the stock at or below which the product is\nlow_stock. Out of stock products can still be ordered when\nAllowBackorder is set, and RestockDate tells when stock is expected.",
+                    "type": "integer"
+                },
+                "manufacturer": {
+                    "$ref": "#/definitions/models.Manufacturer"
+                },
+                "manufacturerId": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "oldPrice": {
+                    "type": "integer"
+                },
+                "options": {
+                    "description": "Options are the options the product is sold in. With variants, Price\nand Stock are the lowest variant price and the total variant stock.\nStock held in warehouses is the sum of the warehouse stock levels.\nVariants and Attributes are only filled in single product responses.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductOption"
+                    }
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "restockDate": {
+                    "type": "string"
+                },
+                "reviews": {
+                    "type": "integer"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "stock": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "variant": {
+                    "description": "Variant is the chosen variant of a product with options. Its price,\nSKU and stock are copied into Product.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ProductVariant"
+                        }
+                    ]
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductVariant"
+                    }
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CartItemRequest": {
+            "type": "object",
+            "properties": {
+                "product_id": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "variant_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Category": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "image": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Breadcrumb"
+                    }
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CategoryNode": {
+            "type": "object",
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CategoryNode"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "image": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Breadcrumb"
+                    }
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CheckoutForm": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "bin": {
+                    "type": "string"
+                },
+                "carts": {
+                    "description": "New field",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CartItemRequest"
+                    }
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "company": {
+                    "description": "New field",
+                    "type": "boolean"
+                },
+                "companyName": {
+                    "type": "string"
+                },
+                "customerType": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "locale": {
+                    "description": "Locale is the language of the order emails, \"ru\", \"kk\" or \"en\". By\ndefault it is taken from Accept-Language.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.EmailHeaders": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "string"
+            }
+        },
+        "models.ImageVariant": {
+            "type": "object",
+            "properties": {
+                "jpeg": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "webp": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Manufacturer": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "logo": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Order": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "bin": {
+                    "type": "string"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "companyName": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "customerEmail": {
+                    "type": "string"
+                },
+                "customerName": {
+                    "type": "string"
+                },
+                "customerPhone": {
+                    "type": "string"
+                },
+                "customerType": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "locale": {
+                    "description": "Locale is the language of the emails about the order.",
+                    "type": "string"
+                },
+                "orderNumber": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "trackingNumber": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrderStatusUpdate": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string"
+                },
+                "trackingNumber": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.OutboxEmail": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Attempts counts failed and successful delivery attempts. A pending\nemail is retried at NextAttemptAt.",
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "headers": {
+                    "description": "Headers are extra headers, such as List-Unsubscribe.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.EmailHeaders"
+                        }
+                    ]
+                },
+                "htmlBody": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "nextAttemptAt": {
+                    "type": "string"
+                },
+                "orderId": {
+                    "description": "OrderID is the order the email is about, if any.",
+                    "type": "string"
                 },
-                "items": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.CartItem"
-                    }
+                "recipient": {
+                    "type": "string"
                 },
-                "total": {
-                    "type": "integer"
-                }
-            }
-        },
-        "crud.ValidationErrorDetail": {
-            "type": "object",
-            "properties": {
-                "field": {
+                "sentAt": {
                     "type": "string"
                 },
-                "message": {
+                "status": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "textBody": {
                     "type": "string"
                 }
             }
         },
-        "crud.ValidationErrorResponse": {
+        "models.Product": {
             "type": "object",
             "properties": {
-                "details": {
+                "allowBackorder": {
+                    "type": "boolean"
+                },
+                "attributes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/crud.ValidationErrorDetail"
+                        "$ref": "#/definitions/models.AttributeValue"
                     }
                 },
-                "error": {
-                    "type": "string"
-                }
-            }
-        },
-        "models.CartItem": {
-            "type": "object",
-            "properties": {
                 "availability": {
                     "type": "string"
                 },
@@ -949,6 +4789,9 @@ const docTemplate = `{
                 "categoryId": {
                     "type": "string"
                 },
+                "createdAt": {
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
@@ -967,6 +4810,16 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductImage"
+                    }
+                },
+                "lowStockThreshold": {
+                    "description": "LowStockThreshold is the stock at or below which the product is\nlow_stock. Out of stock products can still be ordered when\nAllowBackorder is set, and RestockDate tells when stock is expected.",
+                    "type": "integer"
+                },
                 "manufacturer": {
                     "$ref": "#/definitions/models.Manufacturer"
                 },
@@ -979,15 +4832,22 @@ const docTemplate = `{
                 "oldPrice": {
                     "type": "integer"
                 },
-                "price": {
-                    "type": "integer"
+                "options": {
+                    "description": "Options are the options the product is sold in. With variants, Price\nand Stock are the lowest variant price and the total variant stock.\nStock held in warehouses is the sum of the warehouse stock levels.\nVariants and Attributes are only filled in single product responses.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductOption"
+                    }
                 },
-                "quantity": {
+                "price": {
                     "type": "integer"
                 },
                 "rating": {
                     "type": "number"
                 },
+                "restockDate": {
+                    "type": "string"
+                },
                 "reviews": {
                     "type": "integer"
                 },
@@ -999,156 +4859,317 @@ const docTemplate = `{
                 },
                 "stock": {
                     "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductVariant"
+                    }
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "models.CartItemRequest": {
+        "models.ProductImage": {
             "type": "object",
             "properties": {
-                "product_id": {
+                "original": {
                     "type": "string"
                 },
-                "quantity": {
-                    "type": "integer"
+                "srcset": {
+                    "description": "SrcSet holds ready srcset attribute values by format, \"jpeg\" and\n\"webp\", listing the variants from the smallest.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImageVariant"
+                    }
                 }
             }
         },
-        "models.Category": {
+        "models.ProductOption": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "values": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.ProductVariant": {
             "type": "object",
             "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
                 "image": {
-                    "type": "string"
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "name": {
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProductImage"
+                    }
+                },
+                "oldPrice": {
+                    "type": "integer"
+                },
+                "options": {
+                    "$ref": "#/definitions/models.VariantOptions"
+                },
+                "price": {
+                    "type": "integer"
+                },
+                "productId": {
                     "type": "string"
                 },
-                "parentId": {
+                "sku": {
                     "type": "string"
                 },
-                "slug": {
+                "stock": {
+                    "type": "integer"
+                },
+                "updatedAt": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "models.CheckoutForm": {
+        "models.Review": {
             "type": "object",
             "properties": {
-                "address": {
+                "authorEmail": {
                     "type": "string"
                 },
-                "bin": {
+                "authorName": {
                     "type": "string"
                 },
-                "carts": {
-                    "description": "New field",
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.CartItemRequest"
-                    }
+                "createdAt": {
+                    "type": "string"
                 },
-                "comment": {
+                "id": {
                     "type": "string"
                 },
-                "company": {
-                    "description": "New field",
+                "moderatedAt": {
+                    "type": "string"
+                },
+                "productId": {
+                    "type": "string"
+                },
+                "rating": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "verifiedPurchase": {
+                    "description": "VerifiedPurchase is set when an order with the author's email\ncontains the product.",
                     "type": "boolean"
+                }
+            }
+        },
+        "models.ReviewRequest": {
+            "type": "object",
+            "properties": {
+                "authorEmail": {
+                    "type": "string"
                 },
-                "companyName": {
+                "authorName": {
                     "type": "string"
                 },
-                "customerType": {
+                "rating": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.StockLevel": {
+            "type": "object",
+            "properties": {
+                "productId": {
                     "type": "string"
                 },
-                "email": {
+                "quantity": {
+                    "type": "integer"
+                },
+                "updatedAt": {
                     "type": "string"
                 },
-                "name": {
+                "variantId": {
                     "type": "string"
                 },
-                "phone": {
+                "warehouseId": {
                     "type": "string"
                 }
             }
         },
-        "models.Manufacturer": {
+        "models.StockMovement": {
             "type": "object",
             "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
-                "logo": {
+                "orderId": {
                     "type": "string"
                 },
-                "name": {
+                "productId": {
                     "type": "string"
                 },
-                "slug": {
+                "quantity": {
+                    "type": "integer"
+                },
+                "reference": {
+                    "type": "string"
+                },
+                "transferId": {
+                    "description": "TransferID links the two movements of a transfer.",
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "variantId": {
+                    "type": "string"
+                },
+                "warehouseId": {
                     "type": "string"
                 }
             }
         },
-        "models.Product": {
+        "models.StockMovementRequest": {
             "type": "object",
             "properties": {
-                "availability": {
+                "comment": {
                     "type": "string"
                 },
-                "category": {
-                    "$ref": "#/definitions/models.Category"
+                "orderId": {
+                    "type": "string"
                 },
-                "categoryId": {
+                "productId": {
                     "type": "string"
                 },
-                "description": {
+                "quantity": {
+                    "type": "integer"
+                },
+                "reference": {
                     "type": "string"
                 },
-                "features": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "toWarehouseId": {
+                    "type": "string"
                 },
-                "id": {
+                "type": {
                     "type": "string"
                 },
-                "image": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "variantId": {
+                    "type": "string"
                 },
-                "manufacturer": {
-                    "$ref": "#/definitions/models.Manufacturer"
+                "warehouseId": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Subscription": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
                 },
-                "manufacturerId": {
+                "email": {
                     "type": "string"
                 },
-                "name": {
+                "id": {
                     "type": "string"
                 },
-                "oldPrice": {
-                    "type": "integer"
+                "notifiedAt": {
+                    "type": "string"
                 },
-                "price": {
+                "productId": {
+                    "type": "string"
+                },
+                "targetPrice": {
                     "type": "integer"
                 },
-                "rating": {
-                    "type": "number"
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SubscriptionRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
                 },
-                "reviews": {
+                "targetPrice": {
                     "type": "integer"
                 },
-                "sku": {
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.VariantOptions": {
+            "type": "object",
+            "additionalProperties": {
+                "type": "string"
+            }
+        },
+        "models.Warehouse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Active warehouses accept new movements. Warehouses with movements are\ndeactivated rather than deleted.",
+                    "type": "boolean"
+                },
+                "address": {
                     "type": "string"
                 },
-                "slug": {
+                "code": {
                     "type": "string"
                 },
-                "stock": {
-                    "type": "integer"
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
                 }
             }
         }
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 
 	v1 "noble-group-services/api/v1"
+	v2 "noble-group-services/api/v2"
 	"noble-group-services/core"
 	"noble-group-services/crud"
 	_ "noble-group-services/docs" // Swagger docs
+	"noble-group-services/services/mailer"
+	"noble-group-services/services/notify"
+	"noble-group-services/services/storage"
 )
 
 // @title Noble Group Services API
@@ -42,10 +48,70 @@ func main() {
 
 	// Set DB for CRUD operations
 	crud.SetDB(core.DB)
+	if cacheControl := os.Getenv("CATALOG_CACHE_CONTROL"); cacheControl != "" {
+		crud.SetCatalogCacheControl(cacheControl)
+	}
+	if cacheBytes, err := strconv.Atoi(os.Getenv("CATALOG_CACHE_BYTES")); err == nil {
+		crud.SetCatalogCacheSize(cacheBytes)
+	}
+	crud.SetStorefrontConfig(crud.StorefrontConfig{
+		BaseURL:          os.Getenv("SHOP_URL"),
+		ProductPath:      os.Getenv("SHOP_PRODUCT_PATH"),
+		CategoryPath:     os.Getenv("SHOP_CATEGORY_PATH"),
+		ManufacturerPath: os.Getenv("SHOP_MANUFACTURER_PATH"),
+	})
+	crud.SetFeedConfig(crud.FeedConfig{
+		ShopName:        os.Getenv("SHOP_NAME"),
+		Company:         os.Getenv("SHOP_COMPANY"),
+		Currency:        os.Getenv("SHOP_CURRENCY"),
+		KaspiMerchantID: os.Getenv("KASPI_MERCHANT_ID"),
+		KaspiStoreID:    os.Getenv("KASPI_STORE_ID"),
+		Dir:             os.Getenv("FEED_DIR"),
+	})
+
+	mediaStorage, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure media storage: %v", err)
+	}
+	crud.SetMediaStorage(mediaStorage)
+	if err := crud.SetAPIBaseURL(os.Getenv("API_URL")); err != nil {
+		log.Printf("Ignoring API_URL: %v", err)
+	}
+	if mediaBytes, err := strconv.ParseInt(os.Getenv("MEDIA_MAX_BYTES"), 10, 64); err == nil {
+		crud.SetMediaMaxSize(mediaBytes)
+	}
+
+	// Drop cached catalog responses when another replica changes the catalog
+	go crud.ListenCatalogChanges(context.Background(), dsn)
+
+	// Deliver queued emails, and queue those of back-in-stock and
+	// price-drop subscribers
+	emailMailer, err := mailer.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure email: %v", err)
+	}
+	if attempts, err := strconv.Atoi(os.Getenv("EMAIL_MAX_ATTEMPTS")); err == nil {
+		crud.SetEmailMaxAttempts(attempts)
+	}
+	go crud.RunEmailOutbox(context.Background(), emailMailer)
+	go crud.RunSubscriptionNotifier(context.Background())
+
+	// Tell the staff about new orders
+	staffEmails, err := notify.StaffEmailsFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure staff notifications: %v", err)
+	}
+	crud.SetStaffEmails(staffEmails)
+	staffChats, err := notify.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure staff notifications: %v", err)
+	}
+	crud.SetStaffNotifier(staffChats)
 
 	// Setup Router
 	mux := http.NewServeMux()
 	v1.SetupRoutes(mux)
+	v2.SetupRoutes(mux)
 
 	// Start Server
 	port := os.Getenv("PORT")
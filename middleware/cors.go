@@ -26,8 +26,9 @@ func CORSMiddleware(next http.Handler) http.Handler {
 			// Or for simplicity in this task, if origin is missing, we don't care.
 		}
 
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Session-ID")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Session-ID, If-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusOK)
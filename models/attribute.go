@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Attribute types.
+const (
+	AttributeNumber  = "number"
+	AttributeEnum    = "enum"
+	AttributeBoolean = "boolean"
+)
+
+// Attribute is a typed specification defined for the products of a category
+// and its subcategories, e.g. power in kW.
+type Attribute struct {
+	ID         string `db:"id" json:"id"`
+	CategoryID string `db:"category_id" json:"categoryId"`
+	// Code names the attribute in filters, e.g. attr.power.min=5.
+	Code string  `db:"code" json:"code"`
+	Name string  `db:"name" json:"name"`
+	Type string  `db:"type" json:"type"`
+	Unit *string `db:"unit" json:"unit,omitempty"`
+	// Values lists the allowed values of an enum attribute.
+	Values    JSONStringArray `db:"enum_values" json:"values"`
+	Position  int             `db:"position" json:"position"`
+	CreatedAt time.Time       `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updatedAt"`
+}
+
+// AttributeValue is the value of an attribute for one product: a number, an
+// enum value string or a boolean.
+type AttributeValue struct {
+	Code  string      `json:"code"`
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Unit  *string     `json:"unit,omitempty"`
+	Value interface{} `json:"value"`
+}
@@ -3,4 +3,16 @@ package models
 type CartItem struct {
 	Product
 	Quantity int `json:"quantity"`
+	// Variant is the chosen variant of a product with options. Its price,
+	// SKU and stock are copied into Product.
+	Variant *ProductVariant `json:"variant,omitempty"`
+}
+
+// LineID identifies the item in its cart: the variant ID, or the product ID
+// of a product without variants.
+func (i CartItem) LineID() string {
+	if i.Variant != nil {
+		return i.Variant.ID
+	}
+	return i.ID
 }
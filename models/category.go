@@ -1,9 +1,27 @@
 package models
 
+import "time"
+
 type Category struct {
-	ID       string  `db:"id" json:"id"`
-	Name     string  `db:"name" json:"name"`
-	Slug     string  `db:"slug" json:"slug"`
-	ParentID *string `db:"parent_id" json:"parentId,omitempty"`
-	Image    *string `db:"image" json:"image,omitempty"`
+	ID        string       `db:"id" json:"id"`
+	Name      string       `db:"name" json:"name"`
+	Slug      string       `db:"slug" json:"slug"`
+	ParentID  *string      `db:"parent_id" json:"parentId,omitempty"`
+	Image     *string      `db:"image" json:"image,omitempty"`
+	UpdatedAt *time.Time   `db:"updated_at" json:"updatedAt,omitempty"`
+	Version   int          `db:"version" json:"version,omitempty"`
+	Path      []Breadcrumb `db:"-" json:"path,omitempty"`
+}
+
+// Breadcrumb is one step of a category path, from the root down.
+type Breadcrumb struct {
+	ID   string `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+	Slug string `db:"slug" json:"slug"`
+}
+
+// CategoryNode is a category together with its subcategories.
+type CategoryNode struct {
+	Category
+	Children []*CategoryNode `json:"children"`
 }
@@ -2,6 +2,7 @@ package models
 
 type CartItemRequest struct {
 	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id,omitempty"`
 	Quantity  int    `json:"quantity"`
 }
 
@@ -16,4 +17,8 @@ type CheckoutForm struct {
 	Comment      *string           `json:"comment,omitempty"`
 	Company      bool              `json:"company"` // New field
 	Carts        []CartItemRequest `json:"carts"`   // New field
+
+	// Locale is the language of the order emails, "ru", "kk" or "en". By
+	// default it is taken from Accept-Language.
+	Locale string `json:"locale,omitempty"`
 }
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Outbox email statuses.
+const (
+	EmailPending = "pending"
+	EmailSent    = "sent"
+	EmailDead    = "dead"
+)
+
+// OutboxEmail is a customer email queued for delivery.
+type OutboxEmail struct {
+	ID        string `db:"id" json:"id"`
+	Kind      string `db:"kind" json:"kind"`
+	Recipient string `db:"recipient" json:"recipient"`
+	Subject   string `db:"subject" json:"subject"`
+	TextBody  string `db:"text_body" json:"textBody"`
+	HTMLBody  string `db:"html_body" json:"htmlBody,omitempty"`
+	// Headers are extra headers, such as List-Unsubscribe.
+	Headers EmailHeaders `db:"headers" json:"headers,omitempty"`
+	// OrderID is the order the email is about, if any.
+	OrderID *string `db:"order_id" json:"orderId,omitempty"`
+	Status  string  `db:"status" json:"status"`
+	// Attempts counts failed and successful delivery attempts. A pending
+	// email is retried at NextAttemptAt.
+	Attempts      int        `db:"attempts" json:"attempts"`
+	NextAttemptAt time.Time  `db:"next_attempt_at" json:"nextAttemptAt"`
+	LastError     *string    `db:"last_error" json:"lastError,omitempty"`
+	CreatedAt     time.Time  `db:"created_at" json:"createdAt"`
+	SentAt        *time.Time `db:"sent_at" json:"sentAt,omitempty"`
+}
+
+// EmailHeaders are the extra headers of an email, by name.
+type EmailHeaders map[string]string
+
+// Scan implements the sql.Scanner interface.
+func (h *EmailHeaders) Scan(value interface{}) error {
+	return scanJSON(value, h)
+}
+
+// Value implements the driver.Valuer interface.
+func (h EmailHeaders) Value() (driver.Value, error) {
+	if h == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(h)
+}
@@ -0,0 +1,19 @@
+package models
+
+// ProductImage is an entry of Product.Image with its resized variants.
+// Images hosted outside the media storage have no variants.
+type ProductImage struct {
+	Original string         `json:"original"`
+	Variants []ImageVariant `json:"variants,omitempty"`
+	// SrcSet holds ready srcset attribute values by format, "jpeg" and
+	// "webp", listing the variants from the smallest.
+	SrcSet map[string]string `json:"srcset,omitempty"`
+}
+
+// ImageVariant is an image scaled down to at most Width pixels wide.
+type ImageVariant struct {
+	Name  string `json:"name"`
+	Width int    `json:"width"`
+	JPEG  string `json:"jpeg"`
+	WebP  string `json:"webp"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// Stock movement types. Receipts and returns add stock, sales remove it,
+// adjustments correct it either way and transfers move it between
+// warehouses as a pair of movements.
+const (
+	MovementReceipt    = "receipt"
+	MovementSale       = "sale"
+	MovementReturn     = "return"
+	MovementAdjustment = "adjustment"
+	MovementTransfer   = "transfer"
+)
+
+type Warehouse struct {
+	ID      string `db:"id" json:"id"`
+	Code    string `db:"code" json:"code"`
+	Name    string `db:"name" json:"name"`
+	Address string `db:"address" json:"address"`
+	// Active warehouses accept new movements. Warehouses with movements are
+	// deactivated rather than deleted.
+	Active    bool      `db:"active" json:"active"`
+	CreatedAt time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+// StockLevel is the quantity of a product, or of one of its variants, held
+// in a warehouse. It is only changed by stock movements.
+type StockLevel struct {
+	WarehouseID string    `db:"warehouse_id" json:"warehouseId"`
+	ProductID   string    `db:"product_id" json:"productId"`
+	VariantID   *string   `db:"variant_id" json:"variantId,omitempty"`
+	Quantity    int       `db:"quantity" json:"quantity"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+// StockMovement is an entry of the append-only stock ledger. Quantity is the
+// signed change of the warehouse level.
+type StockMovement struct {
+	ID          string  `db:"id" json:"id"`
+	Type        string  `db:"type" json:"type"`
+	WarehouseID string  `db:"warehouse_id" json:"warehouseId"`
+	ProductID   string  `db:"product_id" json:"productId"`
+	VariantID   *string `db:"variant_id" json:"variantId,omitempty"`
+	Quantity    int     `db:"quantity" json:"quantity"`
+	// TransferID links the two movements of a transfer.
+	TransferID *string   `db:"transfer_id" json:"transferId,omitempty"`
+	OrderID    *string   `db:"order_id" json:"orderId,omitempty"`
+	Reference  *string   `db:"reference" json:"reference,omitempty"`
+	Comment    *string   `db:"comment" json:"comment,omitempty"`
+	CreatedAt  time.Time `db:"created_at" json:"createdAt"`
+}
+
+// StockMovementRequest posts a movement. Quantity is positive, except for
+// adjustments where it is the signed correction. Transfers move Quantity
+// from WarehouseID to ToWarehouseID.
+type StockMovementRequest struct {
+	Type          string  `json:"type"`
+	WarehouseID   string  `json:"warehouseId"`
+	ToWarehouseID string  `json:"toWarehouseId,omitempty"`
+	ProductID     string  `json:"productId"`
+	VariantID     *string `json:"variantId,omitempty"`
+	Quantity      int     `json:"quantity"`
+	OrderID       *string `json:"orderId,omitempty"`
+	Reference     *string `json:"reference,omitempty"`
+	Comment       *string `json:"comment,omitempty"`
+}
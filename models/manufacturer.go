@@ -1,8 +1,12 @@
 package models
 
+import "time"
+
 type Manufacturer struct {
-	ID   string  `db:"id" json:"id"`
-	Name string  `db:"name" json:"name"`
-	Slug string  `db:"slug" json:"slug"`
-	Logo *string `db:"logo" json:"logo,omitempty"`
+	ID        string     `db:"id" json:"id"`
+	Name      string     `db:"name" json:"name"`
+	Slug      string     `db:"slug" json:"slug"`
+	Logo      *string    `db:"logo" json:"logo,omitempty"`
+	UpdatedAt *time.Time `db:"updated_at" json:"updatedAt,omitempty"`
+	Version   int        `db:"version" json:"version,omitempty"`
 }
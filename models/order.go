@@ -2,6 +2,19 @@ package models
 
 import "time"
 
+// Order statuses. Customers are emailed when the status changes.
+const (
+	OrderNew        = "new"
+	OrderConfirmed  = "confirmed"
+	OrderProcessing = "processing"
+	OrderShipped    = "shipped"
+	OrderDelivered  = "delivered"
+	OrderCancelled  = "cancelled"
+)
+
+// OrderStatuses are the valid values of Order.Status.
+var OrderStatuses = []string{OrderNew, OrderConfirmed, OrderProcessing, OrderShipped, OrderDelivered, OrderCancelled}
+
 type Order struct {
 	ID            string    `db:"id" json:"id"`
 	OrderNumber   string    `db:"order_number" json:"orderNumber"`
@@ -16,12 +29,24 @@ type Order struct {
 	Total         int       `db:"total" json:"total"`
 	Status        string    `db:"status" json:"status"`
 	CreatedAt     time.Time `db:"created_at" json:"createdAt"`
+
+	// Locale is the language of the emails about the order.
+	Locale         string  `db:"locale" json:"locale"`
+	TrackingNumber *string `db:"tracking_number" json:"trackingNumber,omitempty"`
+}
+
+// OrderStatusUpdate is the body of an order status change. TrackingNumber,
+// when set, replaces the stored one.
+type OrderStatusUpdate struct {
+	Status         string  `json:"status"`
+	TrackingNumber *string `json:"trackingNumber,omitempty"`
 }
 
 type OrderItem struct {
-	ID        string `db:"id" json:"id"`
-	OrderID   string `db:"order_id" json:"orderId"`
-	ProductID string `db:"product_id" json:"productId"`
-	Quantity  int    `db:"quantity" json:"quantity"`
-	Price     int    `db:"price" json:"price"`
+	ID        string  `db:"id" json:"id"`
+	OrderID   string  `db:"order_id" json:"orderId"`
+	ProductID string  `db:"product_id" json:"productId"`
+	VariantID *string `db:"variant_id" json:"variantId,omitempty"`
+	Quantity  int     `db:"quantity" json:"quantity"`
+	Price     int     `db:"price" json:"price"`
 }
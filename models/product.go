@@ -1,5 +1,23 @@
 package models
 
+import "time"
+
+// Product availabilities. Availability is derived from the stock, the
+// low-stock threshold, the backorder allowance and the restock date of a
+// product whenever it is stored, see schema_availability.sql.
+const (
+	AvailabilityInStock    = "in_stock"
+	AvailabilityLowStock   = "low_stock"
+	AvailabilityBackorder  = "backorder"
+	AvailabilityExpected   = "expected"
+	AvailabilityOutOfStock = "out_of_stock"
+)
+
+// Availabilities are the valid values of Product.Availability.
+var Availabilities = []string{
+	AvailabilityInStock, AvailabilityLowStock, AvailabilityBackorder, AvailabilityExpected, AvailabilityOutOfStock,
+}
+
 type Product struct {
 	ID             string `db:"id" json:"id"`
 	Name           string `db:"name" json:"name"`
@@ -15,9 +33,39 @@ type Product struct {
 	Description  string          `db:"description" json:"description"`
 	Features     JSONStringArray `db:"features" json:"features"`
 	Image        JSONStringArray `db:"image" json:"image"`
+	Images       []ProductImage  `db:"-" json:"images"`
 	Stock        int             `db:"stock" json:"stock"`
 	Rating       float64         `db:"rating" json:"rating"`
 	ReviewsCount int             `db:"reviews_count" json:"reviews"`
 	SKU          string          `db:"sku" json:"sku"`
 	Availability string          `db:"availability" json:"availability"`
+	CreatedAt    time.Time       `db:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time       `db:"updated_at" json:"updatedAt"`
+	Version      int             `db:"version" json:"version"`
+
+	// Options are the options the product is sold in. With variants, Price
+	// and Stock are the lowest variant price and the total variant stock.
+	// Stock held in warehouses is the sum of the warehouse stock levels.
+	// Variants and Attributes are only filled in single product responses.
+	Options    ProductOptions   `db:"options" json:"options"`
+	Variants   []ProductVariant `db:"-" json:"variants,omitempty"`
+	Attributes []AttributeValue `db:"-" json:"attributes,omitempty"`
+
+	// LowStockThreshold is the stock at or below which the product is
+	// low_stock. Out of stock products can still be ordered when
+	// AllowBackorder is set, and RestockDate tells when stock is expected.
+	LowStockThreshold int        `db:"low_stock_threshold" json:"lowStockThreshold"`
+	AllowBackorder    bool       `db:"allow_backorder" json:"allowBackorder"`
+	RestockDate       *time.Time `db:"restock_date" json:"restockDate,omitempty"`
+}
+
+// InStock reports whether the product has stock to ship now.
+func (p Product) InStock() bool {
+	return p.Availability == AvailabilityInStock || p.Availability == AvailabilityLowStock
+}
+
+// Orderable reports whether the product can be ordered, from stock or as a
+// backorder.
+func (p Product) Orderable() bool {
+	return p.InStock() || p.Availability == AvailabilityBackorder
 }
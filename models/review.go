@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Review statuses. Only approved reviews are public and count towards the
+// product rating.
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+type Review struct {
+	ID          string `db:"id" json:"id"`
+	ProductID   string `db:"product_id" json:"productId"`
+	AuthorName  string `db:"author_name" json:"authorName"`
+	AuthorEmail string `db:"author_email" json:"authorEmail,omitempty"`
+	Rating      int    `db:"rating" json:"rating"`
+	Text        string `db:"text" json:"text"`
+	// VerifiedPurchase is set when an order with the author's email
+	// contains the product.
+	VerifiedPurchase bool       `db:"verified_purchase" json:"verifiedPurchase"`
+	Status           string     `db:"status" json:"status"`
+	CreatedAt        time.Time  `db:"created_at" json:"createdAt"`
+	ModeratedAt      *time.Time `db:"moderated_at" json:"moderatedAt,omitempty"`
+}
+
+// ReviewRequest is the body of a new review.
+type ReviewRequest struct {
+	AuthorName  string `json:"authorName"`
+	AuthorEmail string `json:"authorEmail"`
+	Rating      int    `json:"rating"`
+	Text        string `json:"text"`
+}
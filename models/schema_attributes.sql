@@ -0,0 +1,31 @@
+-- Typed product specifications. A category defines attributes that apply to
+-- its products and those of its subcategories; products store one value per
+-- attribute: numbers in number_value, enum values and booleans ('true',
+-- 'false') in text_value.
+CREATE TABLE IF NOT EXISTS category_attributes (
+    id          VARCHAR(36) PRIMARY KEY,
+    category_id VARCHAR(36) NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    code        TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    type        TEXT NOT NULL CHECK (type IN ('number', 'enum', 'boolean')),
+    unit        TEXT,
+    enum_values JSON NOT NULL DEFAULT '[]',
+    position    INTEGER NOT NULL DEFAULT 0,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (category_id, code)
+);
+
+CREATE TABLE IF NOT EXISTS product_attributes (
+    product_id   VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    attribute_id VARCHAR(36) NOT NULL REFERENCES category_attributes(id) ON DELETE CASCADE,
+    number_value NUMERIC,
+    text_value   TEXT,
+    PRIMARY KEY (product_id, attribute_id),
+    CHECK ((number_value IS NULL) <> (text_value IS NULL))
+);
+
+-- Listing filters look values up by attribute.
+CREATE INDEX IF NOT EXISTS product_attributes_number_idx ON product_attributes (attribute_id, number_value);
+CREATE INDEX IF NOT EXISTS product_attributes_text_idx ON product_attributes (attribute_id, text_value);
+CREATE INDEX IF NOT EXISTS category_attributes_code_idx ON category_attributes (code);
@@ -0,0 +1,45 @@
+-- Product availability is derived from stock and backorder settings rather
+-- than set by hand. It is recomputed by a trigger whenever a product row is
+-- written, so stock changes from variants, the stock ledger and imports all
+-- keep it in step:
+--   in_stock      stock above low_stock_threshold
+--   low_stock     stock between 1 and low_stock_threshold
+--   backorder     no stock, orders are still accepted
+--   expected      no stock, restock_date is set
+--   out_of_stock  no stock
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS low_stock_threshold INTEGER NOT NULL DEFAULT 0 CHECK (low_stock_threshold >= 0),
+    ADD COLUMN IF NOT EXISTS allow_backorder BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS restock_date DATE;
+
+CREATE OR REPLACE FUNCTION product_availability(stock INTEGER, low_stock_threshold INTEGER, allow_backorder BOOLEAN, restock_date DATE)
+RETURNS TEXT AS $$
+    SELECT CASE
+        WHEN stock > low_stock_threshold THEN 'in_stock'
+        WHEN stock > 0 THEN 'low_stock'
+        WHEN allow_backorder THEN 'backorder'
+        WHEN restock_date IS NOT NULL THEN 'expected'
+        ELSE 'out_of_stock'
+    END
+$$ LANGUAGE sql IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION set_product_availability() RETURNS trigger AS $$
+BEGIN
+    NEW.availability := product_availability(NEW.stock, NEW.low_stock_threshold, NEW.allow_backorder, NEW.restock_date);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_availability ON products;
+CREATE TRIGGER products_availability
+    BEFORE INSERT OR UPDATE ON products
+    FOR EACH ROW EXECUTE FUNCTION set_product_availability();
+
+-- Existing rows get their derived value before the constraint applies.
+UPDATE products
+SET availability = product_availability(stock, low_stock_threshold, allow_backorder, restock_date)
+WHERE availability IS DISTINCT FROM product_availability(stock, low_stock_threshold, allow_backorder, restock_date);
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_availability_check;
+ALTER TABLE products ADD CONSTRAINT products_availability_check
+    CHECK (availability IN ('in_stock', 'low_stock', 'backorder', 'expected', 'out_of_stock'));
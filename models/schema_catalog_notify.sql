@@ -0,0 +1,54 @@
+-- Publishes catalog changes on the catalog_changes channel so every API
+-- replica can drop its cached responses. The payload is
+-- {"table": "<table>", "id": "<row id>"}; id is null when a whole table changed.
+CREATE OR REPLACE FUNCTION notify_catalog_change() RETURNS trigger AS $$
+DECLARE
+    row_id TEXT;
+BEGIN
+    IF TG_LEVEL = 'ROW' THEN
+        IF TG_OP = 'DELETE' THEN
+            row_id := OLD.id;
+        ELSE
+            row_id := NEW.id;
+        END IF;
+    END IF;
+    PERFORM pg_notify('catalog_changes', json_build_object('table', TG_TABLE_NAME, 'id', row_id)::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_catalog_notify ON products;
+CREATE TRIGGER products_catalog_notify
+    AFTER INSERT OR UPDATE OR DELETE ON products
+    FOR EACH ROW EXECUTE FUNCTION notify_catalog_change();
+
+DROP TRIGGER IF EXISTS categories_catalog_notify ON categories;
+CREATE TRIGGER categories_catalog_notify
+    AFTER INSERT OR UPDATE OR DELETE ON categories
+    FOR EACH ROW EXECUTE FUNCTION notify_catalog_change();
+
+DROP TRIGGER IF EXISTS manufacturers_catalog_notify ON manufacturers;
+CREATE TRIGGER manufacturers_catalog_notify
+    AFTER INSERT OR UPDATE OR DELETE ON manufacturers
+    FOR EACH ROW EXECUTE FUNCTION notify_catalog_change();
+
+-- Truncation and order items (popularity) invalidate whole tables.
+DROP TRIGGER IF EXISTS products_catalog_notify_truncate ON products;
+CREATE TRIGGER products_catalog_notify_truncate
+    AFTER TRUNCATE ON products
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_catalog_change();
+
+DROP TRIGGER IF EXISTS categories_catalog_notify_truncate ON categories;
+CREATE TRIGGER categories_catalog_notify_truncate
+    AFTER TRUNCATE ON categories
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_catalog_change();
+
+DROP TRIGGER IF EXISTS manufacturers_catalog_notify_truncate ON manufacturers;
+CREATE TRIGGER manufacturers_catalog_notify_truncate
+    AFTER TRUNCATE ON manufacturers
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_catalog_change();
+
+DROP TRIGGER IF EXISTS order_items_catalog_notify ON order_items;
+CREATE TRIGGER order_items_catalog_notify
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON order_items
+    FOR EACH STATEMENT EXECUTE FUNCTION notify_catalog_change();
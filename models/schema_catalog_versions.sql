@@ -0,0 +1,33 @@
+-- Change counters of the catalog tables. Every statement that modifies one of
+-- them bumps its row, so list responses can be validated with one cheap query.
+CREATE TABLE IF NOT EXISTS catalog_versions (
+    table_name TEXT PRIMARY KEY,
+    version    BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO catalog_versions (table_name)
+VALUES ('products'), ('categories'), ('manufacturers')
+ON CONFLICT (table_name) DO NOTHING;
+
+CREATE OR REPLACE FUNCTION bump_catalog_version() RETURNS trigger AS $$
+BEGIN
+    UPDATE catalog_versions SET version = version + 1, updated_at = NOW() WHERE table_name = TG_TABLE_NAME;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_catalog_version ON products;
+CREATE TRIGGER products_catalog_version
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON products
+    FOR EACH STATEMENT EXECUTE FUNCTION bump_catalog_version();
+
+DROP TRIGGER IF EXISTS categories_catalog_version ON categories;
+CREATE TRIGGER categories_catalog_version
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON categories
+    FOR EACH STATEMENT EXECUTE FUNCTION bump_catalog_version();
+
+DROP TRIGGER IF EXISTS manufacturers_catalog_version ON manufacturers;
+CREATE TRIGGER manufacturers_catalog_version
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON manufacturers
+    FOR EACH STATEMENT EXECUTE FUNCTION bump_catalog_version();
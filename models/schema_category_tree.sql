@@ -0,0 +1,2 @@
+-- Speeds up the recursive subcategory lookups used by the tree and product filters.
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
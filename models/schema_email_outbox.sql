@@ -0,0 +1,26 @@
+-- Outgoing customer emails. Emails are written in the same transaction as
+-- the change they report and delivered by a worker, so an unreachable SMTP
+-- server or a restart only delays them:
+--   pending  waiting for next_attempt_at
+--   sent     delivered
+--   dead     failed max attempts; resent by hand from /admin/emails
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id              VARCHAR(36) PRIMARY KEY,
+    kind            TEXT NOT NULL,
+    recipient       TEXT NOT NULL,
+    subject         TEXT NOT NULL,
+    text_body       TEXT NOT NULL,
+    html_body       TEXT NOT NULL DEFAULT '',
+    headers         JSONB NOT NULL DEFAULT '{}',
+    order_id        VARCHAR(36),
+    status          TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'dead')),
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_error      TEXT,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    sent_at         TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS email_outbox_due_idx ON email_outbox (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS email_outbox_created_idx ON email_outbox (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS email_outbox_order_idx ON email_outbox (order_id);
@@ -0,0 +1,57 @@
+-- Multi-warehouse inventory. stock_movements is the append-only ledger;
+-- stock_levels holds the running quantity per warehouse and item, and the
+-- stock of products and variants with levels is their sum.
+CREATE TABLE IF NOT EXISTS warehouses (
+    id         VARCHAR(36) PRIMARY KEY,
+    code       TEXT NOT NULL UNIQUE,
+    name       TEXT NOT NULL,
+    address    TEXT NOT NULL DEFAULT '',
+    active     BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS stock_levels (
+    warehouse_id VARCHAR(36) NOT NULL REFERENCES warehouses(id),
+    product_id   VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id   VARCHAR(36) REFERENCES product_variants(id) ON DELETE CASCADE,
+    quantity     INTEGER NOT NULL CHECK (quantity >= 0),
+    updated_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- One level per warehouse and item; products without variants have a null variant_id.
+CREATE UNIQUE INDEX IF NOT EXISTS stock_levels_item_idx ON stock_levels (warehouse_id, product_id, COALESCE(variant_id, ''));
+CREATE INDEX IF NOT EXISTS stock_levels_product_idx ON stock_levels (product_id);
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id           VARCHAR(36) PRIMARY KEY,
+    type         TEXT NOT NULL CHECK (type IN ('receipt', 'sale', 'return', 'adjustment', 'transfer')),
+    warehouse_id VARCHAR(36) NOT NULL REFERENCES warehouses(id),
+    product_id   VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id   VARCHAR(36) REFERENCES product_variants(id) ON DELETE CASCADE,
+    quantity     INTEGER NOT NULL CHECK (quantity <> 0),
+    transfer_id  VARCHAR(36),
+    order_id     VARCHAR(36),
+    reference    TEXT,
+    comment      TEXT,
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS stock_movements_created_idx ON stock_movements (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS stock_movements_item_idx ON stock_movements (product_id, warehouse_id);
+
+-- Movements are never changed or removed, except together with their
+-- product or variant (a cascaded delete runs below another trigger).
+CREATE OR REPLACE FUNCTION reject_stock_movement_change() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND pg_trigger_depth() > 1 THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS stock_movements_append_only ON stock_movements;
+CREATE TRIGGER stock_movements_append_only
+    BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION reject_stock_movement_change();
@@ -0,0 +1,5 @@
+-- Order emails are written in the locale the order was placed in. Shipped
+-- orders carry the tracking number of the carrier.
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS locale VARCHAR(5) NOT NULL DEFAULT 'ru',
+    ADD COLUMN IF NOT EXISTS tracking_number TEXT;
@@ -0,0 +1,7 @@
+-- Columns and indexes used by the product listing sort orders.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_products_name ON products (name, id);
+CREATE INDEX IF NOT EXISTS idx_products_price ON products (price, id);
+CREATE INDEX IF NOT EXISTS idx_products_created_at ON products (created_at DESC, id);
+CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON order_items (product_id);
@@ -0,0 +1,24 @@
+-- Product reviews. New reviews wait for moderation; only approved reviews
+-- count towards products.rating and products.reviews_count, which are
+-- recomputed in the transaction that changes a review.
+CREATE TABLE IF NOT EXISTS reviews (
+    id VARCHAR(36) PRIMARY KEY,
+    product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    author_name TEXT NOT NULL,
+    author_email TEXT NOT NULL,
+    rating SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    text TEXT NOT NULL DEFAULT '',
+    verified_purchase BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    moderated_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reviews_product_status ON reviews (product_id, status, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_reviews_status ON reviews (status, created_at DESC, id DESC);
+
+-- One review per customer and product.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_product_author ON reviews (product_id, LOWER(author_email));
+
+-- Verified purchases are looked up by customer email.
+CREATE INDEX IF NOT EXISTS idx_orders_customer_email ON orders (LOWER(customer_email));
@@ -0,0 +1,10 @@
+-- Former slugs of products, categories and manufacturers. Lookups by an old
+-- slug redirect to the row's current slug; redirects of deleted rows are
+-- ignored because the lookup joins on the live table.
+CREATE TABLE IF NOT EXISTS slug_redirects (
+    entity_type VARCHAR(20) NOT NULL,
+    old_slug TEXT NOT NULL,
+    entity_id VARCHAR(36) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (entity_type, old_slug)
+);
@@ -0,0 +1,20 @@
+-- Back-in-stock and price-drop subscriptions. Pending subscriptions have no
+-- notified_at; the notifier picks those whose condition holds, emails them
+-- and sets notified_at. token authorizes the unsubscribe link.
+CREATE TABLE IF NOT EXISTS product_subscriptions (
+    id VARCHAR(36) PRIMARY KEY,
+    product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('back_in_stock', 'price_below')),
+    target_price INTEGER CHECK (target_price >= 0),
+    token VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    notified_at TIMESTAMP,
+    CHECK ((type = 'price_below') = (target_price IS NOT NULL))
+);
+
+-- One pending subscription of each type per product and address.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_subscriptions_pending
+    ON product_subscriptions (product_id, LOWER(email), type) WHERE notified_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_product_subscriptions_email ON product_subscriptions (LOWER(email));
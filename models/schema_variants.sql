@@ -0,0 +1,26 @@
+-- Product variants. A product lists the options it is sold in, e.g.
+-- [{"name": "Мощность", "values": ["5 кВт", "10 кВт"]}], and each variant is
+-- one combination of option values, {"Мощность": "5 кВт"}, with its own SKU,
+-- price, stock and images.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS options JSON NOT NULL DEFAULT '[]';
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    id         VARCHAR(36) PRIMARY KEY,
+    product_id VARCHAR(36) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    sku        TEXT NOT NULL UNIQUE,
+    options    JSONB NOT NULL DEFAULT '{}',
+    price      INTEGER NOT NULL CHECK (price >= 0),
+    old_price  INTEGER CHECK (old_price >= 0),
+    stock      INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    image      JSON NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    version    INTEGER NOT NULL DEFAULT 1,
+    UNIQUE (product_id, options)
+);
+
+-- Listing filters on option values.
+CREATE INDEX IF NOT EXISTS product_variants_options_idx ON product_variants USING GIN (options);
+
+-- Carts are kept in memory; order lines remember the variant ordered.
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS variant_id VARCHAR(36) REFERENCES product_variants(id) ON DELETE SET NULL;
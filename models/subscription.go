@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Subscription types. A back_in_stock subscription fires when an out of
+// stock product can be shipped again, a price_below one when the price
+// drops to TargetPrice or lower.
+const (
+	SubscriptionBackInStock = "back_in_stock"
+	SubscriptionPriceBelow  = "price_below"
+)
+
+// Subscription asks for one email about a product. It is kept, with
+// NotifiedAt set, once the email has been sent.
+type Subscription struct {
+	ID          string `db:"id" json:"id"`
+	ProductID   string `db:"product_id" json:"productId"`
+	Email       string `db:"email" json:"email"`
+	Type        string `db:"type" json:"type"`
+	TargetPrice *int   `db:"target_price" json:"targetPrice,omitempty"`
+	// Token authorizes the unsubscribe link of the email.
+	Token      string     `db:"token" json:"-"`
+	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
+	NotifiedAt *time.Time `db:"notified_at" json:"notifiedAt,omitempty"`
+}
+
+// SubscriptionRequest is the body of a new subscription. TargetPrice is
+// required for price_below subscriptions.
+type SubscriptionRequest struct {
+	Email       string `json:"email"`
+	Type        string `json:"type"`
+	TargetPrice *int   `json:"targetPrice,omitempty"`
+}
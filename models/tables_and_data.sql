@@ -3,7 +3,9 @@ CREATE TABLE manufacturers (
                                id   VARCHAR(36) PRIMARY KEY,
                                name TEXT NOT NULL,
                                slug TEXT NOT NULL UNIQUE,
-                               logo TEXT
+                               logo TEXT,
+                               version    INTEGER NOT NULL DEFAULT 1,
+                               updated_at TIMESTAMP NOT NULL DEFAULT NOW()
 );
 
 -- Categories (supports hierarchy)
@@ -13,6 +15,8 @@ CREATE TABLE categories (
                             slug       TEXT NOT NULL UNIQUE,
                             parent_id  VARCHAR(36),
                             image      TEXT,
+                            version    INTEGER NOT NULL DEFAULT 1,
+                            updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
                             FOREIGN KEY (parent_id) REFERENCES categories(id) ON DELETE SET NULL
 );
 
@@ -28,11 +32,18 @@ CREATE TABLE products (
                           description     TEXT NOT NULL DEFAULT '',
                           features        JSON NOT NULL DEFAULT '[]',
                           image           JSON NOT NULL DEFAULT '[]',
+                          options         JSON NOT NULL DEFAULT '[]',
                           stock           INTEGER NOT NULL DEFAULT 0,
                           rating          REAL NOT NULL DEFAULT 0,
                           reviews_count   INTEGER NOT NULL DEFAULT 0,
                           sku             TEXT NOT NULL UNIQUE,
                           availability    TEXT NOT NULL DEFAULT 'in_stock',
+                          low_stock_threshold INTEGER NOT NULL DEFAULT 0,
+                          allow_backorder BOOLEAN NOT NULL DEFAULT FALSE,
+                          restock_date    DATE,
+                          created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+                          updated_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+                          version         INTEGER NOT NULL DEFAULT 1,
                           FOREIGN KEY (manufacturer_id) REFERENCES manufacturers(id) ON DELETE CASCADE,
                           FOREIGN KEY (category_id)     REFERENCES categories(id)     ON DELETE CASCADE
 );
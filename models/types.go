@@ -16,6 +16,19 @@ func (a *JSONStringArray) Scan(value interface{}) error {
 		return nil
 	}
 
+	return scanJSON(value, a)
+}
+
+// Value implements the driver.Valuer interface.
+func (a JSONStringArray) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return json.Marshal(a)
+}
+
+// scanJSON decodes a JSON column value into dest.
+func scanJSON(value interface{}, dest interface{}) error {
 	var b []byte
 	switch v := value.(type) {
 	case []byte:
@@ -26,13 +39,5 @@ func (a *JSONStringArray) Scan(value interface{}) error {
 		return errors.New("type assertion to []byte or string failed")
 	}
 
-	return json.Unmarshal(b, a)
-}
-
-// Value implements the driver.Valuer interface.
-func (a JSONStringArray) Value() (driver.Value, error) {
-	if a == nil {
-		return nil, nil
-	}
-	return json.Marshal(a)
+	return json.Unmarshal(b, dest)
 }
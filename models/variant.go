@@ -0,0 +1,66 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// ProductOption is an option a product is sold in, e.g. power, with the
+// values its variants may take in display order.
+type ProductOption struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// ProductOptions are the option definitions of a product, stored as JSON.
+type ProductOptions []ProductOption
+
+// Scan implements the sql.Scanner interface.
+func (o *ProductOptions) Scan(value interface{}) error {
+	return scanJSON(value, o)
+}
+
+// Value implements the driver.Valuer interface. No options are stored as an
+// empty array.
+func (o ProductOptions) Value() (driver.Value, error) {
+	if o == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(o)
+}
+
+// VariantOptions maps the option names of a product to the values of one of
+// its variants.
+type VariantOptions map[string]string
+
+// Scan implements the sql.Scanner interface.
+func (o *VariantOptions) Scan(value interface{}) error {
+	return scanJSON(value, o)
+}
+
+// Value implements the driver.Valuer interface.
+func (o VariantOptions) Value() (driver.Value, error) {
+	if o == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(o)
+}
+
+// ProductVariant is one combination of the option values of a product, sold
+// under its own SKU, price and stock. Images, when set, replace the product
+// images for the variant.
+type ProductVariant struct {
+	ID        string          `db:"id" json:"id"`
+	ProductID string          `db:"product_id" json:"productId"`
+	SKU       string          `db:"sku" json:"sku"`
+	Options   VariantOptions  `db:"options" json:"options"`
+	Price     int             `db:"price" json:"price"`
+	OldPrice  *int            `db:"old_price" json:"oldPrice,omitempty"`
+	Stock     int             `db:"stock" json:"stock"`
+	Image     JSONStringArray `db:"image" json:"image"`
+	Images    []ProductImage  `db:"-" json:"images"`
+	CreatedAt time.Time       `db:"created_at" json:"createdAt"`
+	UpdatedAt time.Time       `db:"updated_at" json:"updatedAt"`
+	Version   int             `db:"version" json:"version"`
+}
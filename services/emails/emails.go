@@ -0,0 +1,209 @@
+// Package emails renders the emails sent to customers from embedded
+// templates. Every email has an HTML and a plain-text part sharing one
+// layout per format; the wording comes from the locales/*.json message
+// catalogs.
+package emails
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	"noble-group-services/models"
+)
+
+// Order emails.
+const (
+	OrderPlaced        = "order_placed"
+	OrderStatusChanged = "order_status"
+	OrderShipped       = "order_shipped"
+)
+
+// DefaultLocale is used for orders without a supported locale.
+const DefaultLocale = "ru"
+
+//go:embed templates/*.html templates/*.txt
+var templateFiles embed.FS
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogs maps a locale to its messages, loaded from locales/<locale>.json.
+var catalogs = loadCatalogs()
+
+var (
+	htmlTemplates = make(map[string]*htmltemplate.Template)
+	textTemplates = make(map[string]*texttemplate.Template)
+)
+
+func init() {
+	// The functions are bound to a locale when rendering.
+	placeholder := templateFuncs(DefaultLocale)
+	for _, kind := range []string{OrderPlaced, OrderStatusChanged, OrderShipped} {
+		htmlTemplates[kind] = htmltemplate.Must(htmltemplate.New(kind).Funcs(placeholder).
+			ParseFS(templateFiles, "templates/layout.html", "templates/"+kind+".html"))
+		textTemplates[kind] = texttemplate.Must(texttemplate.New(kind).Funcs(placeholder).
+			ParseFS(templateFiles, "templates/layout.txt", "templates/"+kind+".txt"))
+	}
+}
+
+func loadCatalogs() map[string]map[string]string {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	catalogs := make(map[string]map[string]string, len(files))
+	for _, f := range files {
+		data, err := localeFiles.ReadFile("locales/" + f.Name())
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("emails: locales/%s: %v", f.Name(), err))
+		}
+		catalogs[strings.TrimSuffix(f.Name(), ".json")] = messages
+	}
+	return catalogs
+}
+
+// MatchLocale returns the first supported locale among values, which are
+// locale codes or Accept-Language headers, or DefaultLocale.
+func MatchLocale(values ...string) string {
+	for _, v := range values {
+		for _, tag := range strings.Split(v, ",") {
+			tag, _, _ = strings.Cut(tag, ";")
+			tag, _, _ = strings.Cut(strings.TrimSpace(tag), "-")
+			if _, ok := catalogs[strings.ToLower(tag)]; ok {
+				return strings.ToLower(tag)
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+// Message is a rendered email.
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+	// Headers are extra headers, such as List-Unsubscribe.
+	Headers map[string]string
+}
+
+// Item is an order line as listed in emails.
+type Item struct {
+	Name string
+	SKU  string
+	// Options describes the variant, e.g. "Мощность: 5 кВт".
+	Options  string
+	Quantity int
+	Price    int
+}
+
+// Total is the price of the line.
+func (i Item) Total() int { return i.Price * i.Quantity }
+
+// Order is the data of the order emails. The locale of the email is
+// Order.Locale.
+type Order struct {
+	models.Order
+	Items []Item
+	// PreviousStatus is the status before a status change.
+	PreviousStatus string
+	ShopName       string
+	ShopURL        string
+}
+
+// Subtotal is the sum of the lines, before discounts.
+func (o Order) Subtotal() int {
+	sum := 0
+	for _, i := range o.Items {
+		sum += i.Total()
+	}
+	return sum
+}
+
+// Discount is what the order total saves on the lines.
+func (o Order) Discount() int { return o.Subtotal() - o.Total }
+
+// Render renders the order email kind in the locale of the order.
+func Render(kind string, o Order) (Message, error) {
+	htmlTmpl, ok := htmlTemplates[kind]
+	if !ok {
+		return Message{}, fmt.Errorf("emails: unknown email %q", kind)
+	}
+	funcs := templateFuncs(MatchLocale(o.Locale))
+
+	var subject, text, html bytes.Buffer
+	textTmpl := texttemplate.Must(textTemplates[kind].Clone()).Funcs(funcs)
+	if err := textTmpl.ExecuteTemplate(&subject, "subject", o); err != nil {
+		return Message{}, err
+	}
+	if err := textTmpl.ExecuteTemplate(&text, "layout", o); err != nil {
+		return Message{}, err
+	}
+	if err := htmltemplate.Must(htmlTmpl.Clone()).Funcs(funcs).ExecuteTemplate(&html, "layout", o); err != nil {
+		return Message{}, err
+	}
+	return Message{Subject: strings.TrimSpace(subject.String()), Text: text.String(), HTML: html.String()}, nil
+}
+
+// templateFuncs are the functions of the templates in locale:
+//
+//	t      translates a message key, formatting it with the arguments
+//	status translates an order status
+//	money  formats an amount in tenge
+func templateFuncs(locale string) map[string]any {
+	t := func(key string, args ...any) string {
+		msg, ok := catalogs[locale][key]
+		if !ok {
+			if msg, ok = catalogs[DefaultLocale][key]; !ok {
+				return key
+			}
+		}
+		if len(args) == 0 {
+			return msg
+		}
+		for i, a := range args {
+			// Optional fields, such as Order.TrackingNumber
+			if p, ok := a.(*string); ok && p != nil {
+				args[i] = *p
+			}
+		}
+		return fmt.Sprintf(msg, args...)
+	}
+	return map[string]any{
+		"t": t,
+		"status": func(status string) string {
+			if msg := t("status." + status); msg != "status."+status {
+				return msg
+			}
+			return status
+		},
+		"money": FormatMoney,
+	}
+}
+
+// FormatMoney writes an amount in tenge with digit groups separated by
+// no-break spaces, e.g. "1 299 000 ₸".
+func FormatMoney(amount int) string {
+	digits := strconv.Itoa(amount)
+	sign := ""
+	if amount < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString("\u00a0")
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String() + "\u00a0₸"
+}
@@ -0,0 +1,90 @@
+package emails
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"noble-group-services/models"
+)
+
+func testOrder(locale string) Order {
+	company, bin := "ТОО <Ромашка>", "123456789012"
+	return Order{
+		Order: models.Order{
+			OrderNumber: "ORD-2026-000042", CustomerName: "Айгерим", Address: "Алматы, Абая 1",
+			CompanyName: &company, BIN: &bin, Total: 1_250_000, Status: models.OrderNew, Locale: locale,
+		},
+		Items: []Item{
+			{Name: "Генератор", SKU: "GEN-5", Options: "Мощность: 5 кВт", Quantity: 2, Price: 600_000},
+			{Name: "Кабель", Quantity: 1, Price: 100_000},
+		},
+		ShopName: "Noble Group",
+		ShopURL:  "https://noble.kz",
+	}
+}
+
+func TestRender_OrderPlaced(t *testing.T) {
+	msg, err := Render(OrderPlaced, testOrder("ru"))
+	require.NoError(t, err)
+	assert.Equal(t, "Заказ ORD-2026-000042 оформлен", msg.Subject)
+
+	assert.Contains(t, msg.Text, "Здравствуйте, Айгерим!")
+	assert.Contains(t, msg.Text, "- Генератор (Мощность: 5 кВт), Артикул GEN-5: 2 × 600 000 ₸ = 1 200 000 ₸\n")
+	assert.Contains(t, msg.Text, "Скидка: 50 000 ₸")
+	assert.Contains(t, msg.Text, "Итого: 1 250 000 ₸")
+	assert.Contains(t, msg.Text, "БИН: 123456789012")
+	// Plain text is not escaped, HTML is
+	assert.Contains(t, msg.Text, "ТОО <Ромашка>")
+	assert.Contains(t, msg.HTML, "ТОО &lt;Ромашка&gt;")
+	assert.Contains(t, msg.HTML, `<html lang="ru">`)
+	assert.Contains(t, msg.HTML, "<td>Кабель</td>")
+}
+
+func TestRender_Locales(t *testing.T) {
+	o := testOrder("kk")
+	o.PreviousStatus, o.Status = models.OrderNew, models.OrderConfirmed
+	msg, err := Render(OrderStatusChanged, o)
+	require.NoError(t, err)
+	assert.Equal(t, "ORD-2026-000042 тапсырысы: расталды", msg.Subject)
+	assert.Contains(t, msg.Text, "жаңа → расталды")
+
+	tracking := "KZ123"
+	o = testOrder("fr")
+	o.Status, o.TrackingNumber = models.OrderShipped, &tracking
+	msg, err = Render(OrderShipped, o)
+	require.NoError(t, err)
+	assert.Equal(t, "Заказ ORD-2026-000042 отправлен", msg.Subject)
+	assert.Contains(t, msg.HTML, "Трек-номер: KZ123")
+
+	o.Locale, o.Total = "en", o.Subtotal()
+	msg, err = Render(OrderShipped, o)
+	require.NoError(t, err)
+	assert.Contains(t, msg.Text, "Tracking number: KZ123")
+	assert.False(t, strings.Contains(msg.Text, "Discount"), "no discount line without a discount: %s", msg.Text)
+
+	_, err = Render("order_lost", o)
+	assert.Error(t, err)
+}
+
+func TestLocaleCatalogs(t *testing.T) {
+	for locale, messages := range catalogs {
+		for key := range catalogs[DefaultLocale] {
+			assert.Contains(t, messages, key, locale)
+		}
+	}
+}
+
+func TestMatchLocale(t *testing.T) {
+	assert.Equal(t, "kk", MatchLocale("", "kk-KZ,ru;q=0.8"))
+	assert.Equal(t, "en", MatchLocale("EN"))
+	assert.Equal(t, "ru", MatchLocale("de", "fr-FR"))
+}
+
+func TestFormatMoney(t *testing.T) {
+	assert.Equal(t, "0 ₸", FormatMoney(0))
+	assert.Equal(t, "999 ₸", FormatMoney(999))
+	assert.Equal(t, "-1 000 ₸", FormatMoney(-1000))
+}
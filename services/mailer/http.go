@@ -0,0 +1,87 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTPMailer sends through an HTTP email API that accepts messages as JSON
+// with a bearer API key, such as Resend.
+type HTTPMailer struct {
+	Sender
+	// URL is the send endpoint, e.g. "https://api.resend.com/emails".
+	URL    string
+	APIKey string
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// httpMessage is the JSON body of a send request.
+type httpMessage struct {
+	From        string            `json:"from"`
+	To          []string          `json:"to,omitempty"`
+	Cc          []string          `json:"cc,omitempty"`
+	Bcc         []string          `json:"bcc,omitempty"`
+	ReplyTo     string            `json:"reply_to,omitempty"`
+	Subject     string            `json:"subject"`
+	Text        string            `json:"text,omitempty"`
+	HTML        string            `json:"html,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Attachments []httpAttachment  `json:"attachments,omitempty"`
+}
+
+type httpAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type,omitempty"`
+	// Content is base64 encoded.
+	Content string `json:"content"`
+}
+
+func (m *HTTPMailer) Send(ctx context.Context, msg Message) error {
+	msg, err := m.prepare(msg)
+	if err != nil {
+		return err
+	}
+	body := httpMessage{
+		From: m.From.String(), To: msg.To, Cc: msg.Cc, Bcc: msg.Bcc, ReplyTo: msg.ReplyTo,
+		Subject: msg.Subject, Text: msg.Text, HTML: msg.HTML, Headers: msg.Headers,
+	}
+	for _, a := range msg.Attachments {
+		body.Attachments = append(body.Attachments, httpAttachment{
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			Content:     base64.StdEncoding.EncodeToString(a.Data),
+		})
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+m.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := m.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("mailer: %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+	return nil
+}
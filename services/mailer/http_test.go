@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPMailer_Send(t *testing.T) {
+	var got httpMessage
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(status)
+		w.Write([]byte(`{"message":"invalid from"}`))
+	}))
+	defer server.Close()
+
+	m := &HTTPMailer{
+		Sender: Sender{From: mail.Address{Name: "Shop", Address: "shop@example.com"}, Cc: []string{"sales@example.com"}},
+		URL:    server.URL, APIKey: "key",
+	}
+	msg := Message{
+		To: []string{"ann@example.com"}, Subject: "Тест", Text: "Текст", HTML: "<p>Текст</p>",
+		Headers:     map[string]string{"List-Unsubscribe-Post": "List-Unsubscribe=One-Click"},
+		Attachments: []Attachment{{Filename: "a.txt", ContentType: "text/plain", Data: []byte("hi")}},
+	}
+	require.NoError(t, m.Send(context.Background(), msg))
+	assert.Equal(t, `"Shop" <shop@example.com>`, got.From)
+	assert.Equal(t, []string{"ann@example.com"}, got.To)
+	assert.Equal(t, []string{"sales@example.com"}, got.Cc)
+	assert.Equal(t, "<p>Текст</p>", got.HTML)
+	assert.Equal(t, "List-Unsubscribe=One-Click", got.Headers["List-Unsubscribe-Post"])
+	require.Len(t, got.Attachments, 1)
+	assert.Equal(t, "aGk=", got.Attachments[0].Content)
+
+	status = http.StatusUnprocessableEntity
+	err := m.Send(context.Background(), msg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid from")
+}
@@ -0,0 +1,141 @@
+// Package mailer sends email through SMTP or an HTTP email API.
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Mailer sends email messages.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Message is an email. At least one of To, Cc and Bcc is required. HTML,
+// when set, is sent as an alternative to Text.
+type Message struct {
+	To      []string
+	Cc      []string
+	Bcc     []string
+	ReplyTo string
+	Subject string
+	Text    string
+	HTML    string
+	// Headers are extra headers, such as List-Unsubscribe.
+	Headers map[string]string
+
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to a Message.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Sender holds the settings shared by every message of a Mailer.
+type Sender struct {
+	// From is the sender name and address.
+	From mail.Address
+	// ReplyTo is used for messages without their own.
+	ReplyTo string
+	// Cc and Bcc are copied on every message, e.g. to archive them.
+	Cc  []string
+	Bcc []string
+}
+
+// prepare applies the sender settings to msg and checks it can be sent.
+func (s Sender) prepare(msg Message) (Message, error) {
+	if s.From.Address == "" {
+		return msg, errors.New("mailer: no sender address")
+	}
+	if msg.ReplyTo == "" {
+		msg.ReplyTo = s.ReplyTo
+	}
+	msg.Cc = append(append([]string(nil), msg.Cc...), s.Cc...)
+	msg.Bcc = append(append([]string(nil), msg.Bcc...), s.Bcc...)
+	if len(msg.To)+len(msg.Cc)+len(msg.Bcc) == 0 {
+		return msg, errors.New("mailer: no recipients")
+	}
+	return msg, nil
+}
+
+// NewFromEnv builds the mailer selected by MAIL_PROVIDER: "smtp" (the
+// default) or "http". SMTP settings default to Gmail with STARTTLS, and the
+// sender address to SMTP_USER.
+func NewFromEnv() (Mailer, error) {
+	sender := Sender{
+		From:    mail.Address{Name: os.Getenv("MAIL_FROM_NAME"), Address: envOr("MAIL_FROM", os.Getenv("SMTP_USER"))},
+		ReplyTo: os.Getenv("MAIL_REPLY_TO"),
+		Cc:      envList("MAIL_CC"),
+		Bcc:     envList("MAIL_BCC"),
+	}
+	for _, addr := range append([]string{sender.From.Address, sender.ReplyTo}, append(sender.Cc, sender.Bcc...)...) {
+		if _, err := mail.ParseAddress(addr); addr != "" && err != nil {
+			return nil, fmt.Errorf("mailer: invalid address %q", addr)
+		}
+	}
+
+	switch provider := os.Getenv("MAIL_PROVIDER"); provider {
+	case "", "smtp":
+		m := &SMTPMailer{
+			Sender:   sender,
+			Host:     envOr("SMTP_HOST", "smtp.gmail.com"),
+			Port:     envOr("SMTP_PORT", "587"),
+			TLS:      envOr("SMTP_TLS", TLSStartTLS),
+			Username: os.Getenv("SMTP_USER"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}
+		if _, err := strconv.ParseUint(m.Port, 10, 16); err != nil {
+			return nil, fmt.Errorf("mailer: invalid SMTP_PORT %q", m.Port)
+		}
+		if m.TLS != TLSStartTLS && m.TLS != TLSImplicit && m.TLS != TLSNone {
+			return nil, fmt.Errorf("mailer: SMTP_TLS must be %s, %s or %s", TLSStartTLS, TLSImplicit, TLSNone)
+		}
+		// net/smtp refuses to send credentials in the clear to other hosts
+		if m.TLS == TLSNone && m.Username != "" && !isLocalhost(m.Host) {
+			return nil, fmt.Errorf("mailer: SMTP_TLS=%s cannot authenticate with %s; unset SMTP_USER or use %s", TLSNone, m.Host, TLSStartTLS)
+		}
+		return m, nil
+	case "http":
+		m := &HTTPMailer{
+			Sender: sender,
+			URL:    envOr("MAIL_API_URL", "https://api.resend.com/emails"),
+			APIKey: os.Getenv("MAIL_API_KEY"),
+		}
+		if m.APIKey == "" {
+			return nil, errors.New("mailer: MAIL_API_KEY is required")
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("mailer: unknown MAIL_PROVIDER %q", provider)
+	}
+}
+
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// envList splits a comma-separated variable.
+func envList(name string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
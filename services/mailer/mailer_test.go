@@ -0,0 +1,156 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parts reads the leaves of a MIME body as "content-type: content".
+func parts(t *testing.T, contentType string, body io.Reader) []string {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	require.NoError(t, err)
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		data, err := io.ReadAll(body)
+		require.NoError(t, err)
+		return []string{mediaType + ": " + string(data)}
+	}
+
+	var leaves []string
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return leaves
+		}
+		require.NoError(t, err)
+		leaves = append(leaves, parts(t, p.Header.Get("Content-Type"), p)...)
+	}
+}
+
+func TestSender_Compose(t *testing.T) {
+	s := Sender{From: mail.Address{Name: "Нобл Групп", Address: "shop@example.com"}}
+	msg := Message{
+		To: []string{"ann@example.com"}, Cc: []string{"sales@example.com"}, Bcc: []string{"archive@example.com"},
+		ReplyTo: "support@example.com", Subject: "Заказ ORD-1", Text: "Текст", HTML: "<p>Текст</p>",
+		Headers:     map[string]string{"List-Unsubscribe": "<https://api.example.com/unsubscribe>"},
+		Attachments: []Attachment{{Filename: "счёт.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4")}},
+	}
+	data, err := s.compose(msg, time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	m, err := mail.ReadMessage(strings.NewReader(string(data)))
+	require.NoError(t, err)
+	from, err := m.Header.AddressList("From")
+	require.NoError(t, err)
+	assert.Equal(t, "Нобл Групп", from[0].Name)
+	assert.Equal(t, "ann@example.com", m.Header.Get("To"))
+	assert.Equal(t, "sales@example.com", m.Header.Get("Cc"))
+	assert.Equal(t, "support@example.com", m.Header.Get("Reply-To"))
+	assert.Empty(t, m.Header.Get("Bcc"))
+	assert.Equal(t, "<https://api.example.com/unsubscribe>", m.Header.Get("List-Unsubscribe"))
+	assert.Equal(t, "Sun, 18 Oct 2026 12:00:00 +0000", m.Header.Get("Date"))
+	assert.True(t, strings.HasSuffix(m.Header.Get("Message-ID"), "@example.com>"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Заказ ORD-1", subject)
+
+	assert.Equal(t, []string{"text/plain: Текст", "text/html: <p>Текст</p>", "application/pdf: JVBERi0xLjQ=\r\n"},
+		parts(t, m.Header.Get("Content-Type"), m.Body))
+	assert.Contains(t, string(data), `filename*=utf-8''%D1%81%D1%87%D1%91%D1%82.pdf`)
+
+	// Plain messages are a single part
+	data, err = s.compose(Message{To: []string{"ann@example.com"}, Text: "Текст"}, time.Now())
+	require.NoError(t, err)
+	m, err = mail.ReadMessage(strings.NewReader(string(data)))
+	require.NoError(t, err)
+	assert.Equal(t, "quoted-printable", m.Header.Get("Content-Transfer-Encoding"))
+	assert.Equal(t, []string{"text/plain: Текст"}, parts(t, m.Header.Get("Content-Type"), quotedprintable.NewReader(m.Body)))
+}
+
+func TestSender_Prepare(t *testing.T) {
+	s := Sender{From: mail.Address{Address: "shop@example.com"}, ReplyTo: "support@example.com", Bcc: []string{"archive@example.com"}}
+
+	msg, err := s.prepare(Message{To: []string{"ann@example.com"}, Bcc: []string{"boss@example.com"}})
+	require.NoError(t, err)
+	assert.Equal(t, "support@example.com", msg.ReplyTo)
+	assert.Equal(t, []string{"boss@example.com", "archive@example.com"}, msg.Bcc)
+
+	msg, err = s.prepare(Message{To: []string{"ann@example.com"}, ReplyTo: "manager@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "manager@example.com", msg.ReplyTo)
+
+	_, err = Sender{}.prepare(Message{To: []string{"ann@example.com"}})
+	assert.Error(t, err)
+	_, err = Sender{From: s.From}.prepare(Message{})
+	assert.Error(t, err)
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("SMTP_USER", "shop@example.com")
+	t.Setenv("MAIL_CC", "sales@example.com, ")
+	m, err := NewFromEnv()
+	require.NoError(t, err)
+	s := m.(*SMTPMailer)
+	assert.Equal(t, "smtp.gmail.com", s.Host)
+	assert.Equal(t, "587", s.Port)
+	assert.Equal(t, TLSStartTLS, s.TLS)
+	assert.Equal(t, "shop@example.com", s.From.Address)
+	assert.Equal(t, []string{"sales@example.com"}, s.Cc)
+
+	t.Setenv("MAIL_PROVIDER", "http")
+	t.Setenv("MAIL_API_KEY", "key")
+	m, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.resend.com/emails", m.(*HTTPMailer).URL)
+
+	for name, value := range map[string]string{
+		"MAIL_PROVIDER": "pigeon",
+		"MAIL_REPLY_TO": "not an address",
+		"MAIL_API_KEY":  "",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			_, err := NewFromEnv()
+			assert.Error(t, err)
+		})
+	}
+
+	t.Setenv("MAIL_PROVIDER", "smtp")
+	t.Setenv("SMTP_TLS", "ssl")
+	_, err = NewFromEnv()
+	assert.Error(t, err)
+
+	// Credentials are only sent in the clear to a local relay
+	t.Setenv("SMTP_TLS", TLSNone)
+	_, err = NewFromEnv()
+	assert.ErrorContains(t, err, "smtp.gmail.com")
+	t.Setenv("SMTP_HOST", "localhost")
+	_, err = NewFromEnv()
+	assert.NoError(t, err)
+	t.Setenv("SMTP_HOST", "relay.internal")
+	t.Setenv("SMTP_USER", "")
+	t.Setenv("MAIL_FROM", "shop@example.com")
+	_, err = NewFromEnv()
+	assert.NoError(t, err)
+}
+
+func TestRecorder(t *testing.T) {
+	var r Recorder
+	require.NoError(t, r.Send(context.Background(), Message{To: []string{"ann@example.com"}}))
+	r.Fail(errors.New("down"))
+	assert.Error(t, r.Send(context.Background(), Message{To: []string{"bob@example.com"}}))
+	require.Len(t, r.Messages(), 1)
+	assert.Equal(t, []string{"ann@example.com"}, r.Messages()[0].To)
+}
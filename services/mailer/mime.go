@@ -0,0 +1,158 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"slices"
+	"strings"
+	"time"
+)
+
+// compose writes msg as a MIME message from s. Bcc recipients are left out
+// of the headers.
+func (s Sender) compose(msg Message, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+		}
+	}
+	header("From", s.From.String())
+	header("To", strings.Join(msg.To, ", "))
+	header("Cc", strings.Join(msg.Cc, ", "))
+	header("Reply-To", msg.ReplyTo)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", messageID(s.From.Address))
+	for _, name := range slices.Sorted(maps.Keys(msg.Headers)) {
+		header(name, msg.Headers[name])
+	}
+	header("MIME-Version", "1.0")
+
+	contentHeader, content, err := body(msg)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg.Attachments) == 0 {
+		writeHeader(&buf, contentHeader)
+		buf.Write(content)
+		return buf.Bytes(), nil
+	}
+
+	var parts bytes.Buffer
+	mw := multipart.NewWriter(&parts)
+	pw, err := mw.CreatePart(contentHeader)
+	if err != nil {
+		return nil, err
+	}
+	pw.Write(content)
+	for _, a := range msg.Attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": a.Filename})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(pw, a.Data)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	header("Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mw.Boundary()}))
+	buf.WriteString("\r\n")
+	buf.Write(parts.Bytes())
+	return buf.Bytes(), nil
+}
+
+// body is the text of msg, with its HTML alternative if any.
+func body(msg Message) (textproto.MIMEHeader, []byte, error) {
+	if msg.HTML == "" {
+		return textPart("text/plain", msg.Text)
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		header, content, err := textPart(part.contentType, part.content)
+		if err != nil {
+			return nil, nil, err
+		}
+		pw, err := mw.CreatePart(header)
+		if err != nil {
+			return nil, nil, err
+		}
+		pw.Write(content)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, nil, err
+	}
+	header := textproto.MIMEHeader{
+		"Content-Type": {mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": mw.Boundary()})},
+	}
+	return header, buf.Bytes(), nil
+}
+
+// textPart encodes text as a quoted-printable UTF-8 part.
+func textPart(contentType, text string) (textproto.MIMEHeader, []byte, error) {
+	var buf bytes.Buffer
+	qw := quotedprintable.NewWriter(&buf)
+	if _, err := qw.Write([]byte(text)); err != nil {
+		return nil, nil, err
+	}
+	if err := qw.Close(); err != nil {
+		return nil, nil, err
+	}
+	header := textproto.MIMEHeader{
+		"Content-Type":              {contentType + `; charset="utf-8"`},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	}
+	return header, buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	for name, values := range header {
+		for _, v := range values {
+			fmt.Fprintf(buf, "%s: %s\r\n", name, v)
+		}
+	}
+	buf.WriteString("\r\n")
+}
+
+// writeBase64 writes data base64 encoded in lines of 76 characters.
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
+
+// messageID returns a unique Message-ID in the domain of the sender address.
+func messageID(from string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	domain := "localhost"
+	if _, d, ok := strings.Cut(from, "@"); ok && d != "" {
+		domain = d
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+)
+
+// Recorder is a Mailer that keeps the messages it is given instead of
+// sending them, for tests. It is safe for concurrent use.
+type Recorder struct {
+	mu       sync.Mutex
+	messages []Message
+	err      error
+}
+
+// Send records msg, or fails with the error set by Fail.
+func (r *Recorder) Send(ctx context.Context, msg Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+// Fail makes Send return err, or succeed again when err is nil.
+func (r *Recorder) Fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+// Messages returns the messages sent so far.
+func (r *Recorder) Messages() []Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Message(nil), r.messages...)
+}
@@ -0,0 +1,121 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// SMTP connection security.
+const (
+	// TLSStartTLS upgrades a plain connection with STARTTLS, usually on
+	// port 587. The upgrade is required.
+	TLSStartTLS = "starttls"
+	// TLSImplicit connects with TLS from the start, usually on port 465.
+	TLSImplicit = "implicit"
+	// TLSNone sends in the clear, for relays on a trusted network. Such
+	// relays must not need credentials: AUTH PLAIN is only sent in the
+	// clear to localhost.
+	TLSNone = "none"
+)
+
+// SMTPMailer sends through an SMTP server.
+type SMTPMailer struct {
+	Sender
+	Host string
+	Port string
+	// TLS is TLSStartTLS, TLSImplicit or TLSNone.
+	TLS string
+	// Username and Password authenticate with AUTH PLAIN when Username is
+	// set.
+	Username string
+	Password string
+	// TLSConfig defaults to verifying the certificate of Host.
+	TLSConfig *tls.Config
+	// Timeout bounds a delivery without a context deadline. It defaults to
+	// 30 seconds.
+	Timeout time.Duration
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	msg, err := m.prepare(msg)
+	if err != nil {
+		return err
+	}
+	data, err := m.compose(msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := m.Timeout
+		if timeout == 0 {
+			timeout = 30 * time.Second
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	conn, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if m.TLS == TLSStartTLS {
+		if err := c.StartTLS(m.tlsConfig()); err != nil {
+			return err
+		}
+	}
+	if m.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(m.From.Address); err != nil {
+		return err
+	}
+	for _, rcpt := range append(append(append([]string(nil), msg.To...), msg.Cc...), msg.Bcc...) {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (m *SMTPMailer) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(m.Host, m.Port)
+	if m.TLS == TLSImplicit {
+		d := &tls.Dialer{Config: m.tlsConfig()}
+		return d.DialContext(ctx, "tcp", addr)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", addr)
+}
+
+func (m *SMTPMailer) tlsConfig() *tls.Config {
+	if m.TLSConfig != nil {
+		return m.TLSConfig.Clone()
+	}
+	return &tls.Config{ServerName: m.Host}
+}
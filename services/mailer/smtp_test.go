@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"context"
+	"net/mail"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"noble-group-services/services/mailer/smtptest"
+)
+
+func TestSMTPMailer_Send(t *testing.T) {
+	msg := Message{To: []string{"ann@example.com"}, Bcc: []string{"archive@example.com"}, Subject: "Тест", Text: "Текст"}
+
+	for _, mode := range []string{TLSStartTLS, TLSImplicit, TLSNone} {
+		t.Run(mode, func(t *testing.T) {
+			server := smtptest.NewServer()
+			if mode == TLSImplicit {
+				server = smtptest.NewTLSServer()
+			}
+			defer server.Close()
+			m := &SMTPMailer{
+				Sender: Sender{From: mail.Address{Name: "Shop", Address: "shop@example.com"}},
+				Host:   server.Host, Port: server.Port, TLS: mode, TLSConfig: server.ClientTLSConfig(),
+				Username: "shop@example.com", Password: "secret",
+			}
+			if mode == TLSNone {
+				m.Username = ""
+			}
+
+			require.NoError(t, m.Send(context.Background(), msg))
+			messages := server.Messages()
+			require.Len(t, messages, 1)
+			assert.Equal(t, "shop@example.com", messages[0].From)
+			assert.Equal(t, []string{"ann@example.com", "archive@example.com"}, messages[0].To)
+			assert.Equal(t, mode != TLSNone, messages[0].TLS)
+			assert.Equal(t, m.Username, messages[0].Username)
+		})
+	}
+}
+
+func TestSMTPMailer_Send_Errors(t *testing.T) {
+	server := smtptest.NewServer()
+	defer server.Close()
+	m := &SMTPMailer{Sender: Sender{From: mail.Address{Address: "shop@example.com"}}, Host: server.Host, Port: server.Port, TLS: TLSStartTLS}
+	msg := Message{To: []string{"ann@example.com"}, Text: "Текст"}
+
+	// The server certificate is not trusted
+	assert.Error(t, m.Send(context.Background(), msg))
+
+	m.TLSConfig = server.ClientTLSConfig()
+	server.SetFailing(true)
+	assert.Error(t, m.Send(context.Background(), msg))
+	assert.Empty(t, server.Messages())
+}
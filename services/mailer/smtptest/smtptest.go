@@ -0,0 +1,243 @@
+// Package smtptest provides an SMTP server on the loopback interface for
+// tests. It accepts any credentials and records the messages it receives.
+package smtptest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a message received by a Server.
+type Message struct {
+	From string
+	To   []string
+	// Data is the message as sent, headers included.
+	Data string
+	// TLS reports whether the message was sent over TLS.
+	TLS bool
+	// Username is the AUTH PLAIN user, if the client authenticated.
+	Username string
+}
+
+// Server is a fake SMTP server. It advertises AUTH, and STARTTLS on plain
+// connections.
+type Server struct {
+	// Host and Port are where the server listens.
+	Host, Port string
+
+	listener net.Listener
+	tls      *tls.Config
+	mu       sync.Mutex
+	messages []Message
+	fail     bool
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a server on 127.0.0.1 that accepts plain connections.
+// Close it when done.
+func NewServer() *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic("smtptest: failed to listen: " + err.Error())
+	}
+	return start(l, serverTLSConfig())
+}
+
+// NewTLSServer starts a server on 127.0.0.1 that expects TLS from the start
+// of the connection. Close it when done.
+func NewTLSServer() *Server {
+	config := serverTLSConfig()
+	l, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	if err != nil {
+		panic("smtptest: failed to listen: " + err.Error())
+	}
+	return start(l, config)
+}
+
+func start(l net.Listener, config *tls.Config) *Server {
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	s := &Server{Host: host, Port: port, listener: l, tls: config}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serve(conn)
+			}()
+		}
+	}()
+	return s
+}
+
+// ClientTLSConfig trusts the certificate of the server.
+func (s *Server) ClientTLSConfig() *tls.Config {
+	pool := x509.NewCertPool()
+	pool.AddCert(s.tls.Certificates[0].Leaf)
+	return &tls.Config{RootCAs: pool, ServerName: s.Host}
+}
+
+// Close stops the server and waits for open connections to finish.
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+// SetFailing makes the server reject messages with a temporary error, or
+// accept them again.
+func (s *Server) SetFailing(fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = fail
+}
+
+// Messages returns the messages received so far.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+func (s *Server) failing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fail
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer func() { conn.Close() }()
+	_, secure := conn.(*tls.Conn)
+	tc := textproto.NewConn(conn)
+	tc.PrintfLine("220 %s smtptest", s.Host)
+
+	var msg Message
+	var username string
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tc.PrintfLine("250-%s", s.Host)
+			if !secure {
+				tc.PrintfLine("250-STARTTLS")
+			}
+			tc.PrintfLine("250-8BITMIME")
+			tc.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			if secure {
+				tc.PrintfLine("503 5.5.1 TLS already active")
+				continue
+			}
+			tc.PrintfLine("220 2.0.0 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, tc, secure = tlsConn, textproto.NewConn(tlsConn), true
+			msg, username = Message{}, ""
+		case "AUTH":
+			username = plainUsername(arg)
+			tc.PrintfLine("235 2.7.0 Authentication successful")
+		case "MAIL":
+			if s.failing() {
+				tc.PrintfLine("451 4.3.0 Try again later")
+				continue
+			}
+			msg = Message{From: address(arg), TLS: secure, Username: username}
+			tc.PrintfLine("250 OK")
+		case "RCPT":
+			msg.To = append(msg.To, address(arg))
+			tc.PrintfLine("250 OK")
+		case "DATA":
+			tc.PrintfLine("354 Go ahead")
+			data, err := tc.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			tc.PrintfLine("250 OK")
+		case "RSET":
+			msg = Message{}
+			tc.PrintfLine("250 OK")
+		case "NOOP":
+			tc.PrintfLine("250 OK")
+		case "QUIT":
+			tc.PrintfLine("221 Bye")
+			return
+		default:
+			tc.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// address extracts the address of a "FROM:<a@b>" or "TO:<a@b>" argument.
+func address(arg string) string {
+	start, end := strings.Index(arg, "<"), strings.Index(arg, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return arg[start+1 : end]
+}
+
+// plainUsername extracts the user of an "AUTH PLAIN <base64>" argument.
+func plainUsername(arg string) string {
+	_, resp, _ := strings.Cut(arg, " ")
+	decoded, err := base64.StdEncoding.DecodeString(resp)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(string(decoded), "\x00")
+	if len(parts) != 3 {
+		return ""
+	}
+	return parts[1]
+}
+
+// serverTLSConfig has a self-signed certificate for 127.0.0.1.
+func serverTLSConfig() *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic("smtptest: " + err.Error())
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "smtptest"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		panic("smtptest: " + err.Error())
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}}}
+}
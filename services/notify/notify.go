@@ -0,0 +1,70 @@
+// Package notify delivers short messages to the staff, such as new order
+// alerts, through a Telegram bot. Staff emails go through the email outbox
+// instead, see StaffEmailsFromEnv.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"strings"
+)
+
+// Message is a staff notification. Channels without subjects send Text
+// only.
+type Message struct {
+	Subject string
+	Text    string
+}
+
+// Notifier delivers staff notifications.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// Multi notifies every channel, even when some fail.
+type Multi []Notifier
+
+func (m Multi) Notify(ctx context.Context, msg Message) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewFromEnv builds the chat channels that are configured:
+// TELEGRAM_BOT_TOKEN with TELEGRAM_CHAT_ID. Without any it returns an empty
+// Multi.
+func NewFromEnv() (Multi, error) {
+	var channels Multi
+
+	token, chatID := os.Getenv("TELEGRAM_BOT_TOKEN"), os.Getenv("TELEGRAM_CHAT_ID")
+	if (token == "") != (chatID == "") {
+		return nil, errors.New("notify: TELEGRAM_BOT_TOKEN and TELEGRAM_CHAT_ID are both required")
+	}
+	if token != "" {
+		channels = append(channels, &TelegramNotifier{Token: token, ChatID: chatID})
+	}
+	return channels, nil
+}
+
+// StaffEmailsFromEnv returns the staff distribution list, STAFF_EMAILS, a
+// comma-separated list of addresses.
+func StaffEmailsFromEnv() ([]string, error) {
+	var to []string
+	for _, addr := range strings.Split(os.Getenv("STAFF_EMAILS"), ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return nil, fmt.Errorf("notify: invalid STAFF_EMAILS address %q", addr)
+		}
+		to = append(to, addr)
+	}
+	return to, nil
+}
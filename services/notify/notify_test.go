@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelegramNotifier(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botT0KEN/sendMessage" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"ok":false,"description":"Unauthorized"}`))
+			return
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	}))
+	defer server.Close()
+
+	n := &TelegramNotifier{Token: "T0KEN", ChatID: "-100123", APIURL: server.URL}
+	require.NoError(t, n.Notify(context.Background(), Message{Subject: "ignored", Text: "Новый заказ"}))
+	assert.Equal(t, "-100123", got["chat_id"])
+	assert.Equal(t, "Новый заказ", got["text"])
+
+	require.NoError(t, n.Notify(context.Background(), Message{Text: strings.Repeat("я", 5000)}))
+	assert.Len(t, []rune(got["text"].(string)), telegramMaxText)
+
+	n.Token = "wrong"
+	err := n.Notify(context.Background(), Message{Text: "x"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Unauthorized")
+
+	// Connection errors do not leak the token
+	server.Close()
+	err = n.Notify(context.Background(), Message{Text: "x"})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "wrong")
+}
+
+// notifierFunc adapts a function to Notifier.
+type notifierFunc func(ctx context.Context, msg Message) error
+
+func (f notifierFunc) Notify(ctx context.Context, msg Message) error { return f(ctx, msg) }
+
+func TestMulti(t *testing.T) {
+	var got []Message
+	ok := notifierFunc(func(ctx context.Context, msg Message) error {
+		got = append(got, msg)
+		return nil
+	})
+	down := notifierFunc(func(ctx context.Context, msg Message) error { return errors.New("chat down") })
+
+	err := Multi{down, ok}.Notify(context.Background(), Message{Subject: "Новый заказ", Text: "Текст"})
+	assert.ErrorContains(t, err, "chat down")
+	require.Len(t, got, 1)
+	assert.Equal(t, "Текст", got[0].Text)
+
+	assert.NoError(t, Multi{}.Notify(context.Background(), Message{}))
+}
+
+func TestNewFromEnv(t *testing.T) {
+	channels, err := NewFromEnv()
+	require.NoError(t, err)
+	assert.Empty(t, channels)
+
+	t.Setenv("TELEGRAM_BOT_TOKEN", "T0KEN")
+	t.Setenv("TELEGRAM_CHAT_ID", "-100123")
+	channels, err = NewFromEnv()
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	assert.Equal(t, "-100123", channels[0].(*TelegramNotifier).ChatID)
+
+	t.Setenv("TELEGRAM_CHAT_ID", "")
+	_, err = NewFromEnv()
+	assert.Error(t, err)
+}
+
+func TestStaffEmailsFromEnv(t *testing.T) {
+	to, err := StaffEmailsFromEnv()
+	require.NoError(t, err)
+	assert.Empty(t, to)
+
+	t.Setenv("STAFF_EMAILS", "sales@example.com, boss@example.com,")
+	to, err = StaffEmailsFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sales@example.com", "boss@example.com"}, to)
+
+	t.Setenv("STAFF_EMAILS", "sales")
+	_, err = StaffEmailsFromEnv()
+	assert.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// TelegramNotifier posts to a chat through a Telegram bot. The bot must be
+// a member of the chat.
+type TelegramNotifier struct {
+	Token string
+	// ChatID is the chat ID, e.g. "-1001234567890", or "@channel".
+	ChatID string
+	// APIURL defaults to "https://api.telegram.org".
+	APIURL string
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// telegramMaxText is the longest message text the Bot API accepts.
+const telegramMaxText = 4096
+
+func (n *TelegramNotifier) Notify(ctx context.Context, msg Message) error {
+	text := []rune(msg.Text)
+	if len(text) > telegramMaxText {
+		text = append(text[:telegramMaxText-1], '…')
+	}
+	body, err := json.Marshal(map[string]any{
+		"chat_id":                  n.ChatID,
+		"text":                     string(text),
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	apiURL := n.APIURL
+	if apiURL == "" {
+		apiURL = "https://api.telegram.org"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+"/bot"+n.Token+"/sendMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Leave out the request URL, it holds the bot token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("notify: telegram: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || !result.OK {
+		return fmt.Errorf("notify: telegram: %s %s", resp.Status, result.Description)
+	}
+	return nil
+}
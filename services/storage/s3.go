@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Storage keeps files in a bucket of an S3-compatible service such as AWS
+// S3, MinIO or Cloudflare R2. Requests are signed with AWS Signature
+// Version 4.
+type S3Storage struct {
+	// Endpoint is the service origin, e.g. "https://s3.eu-central-1.amazonaws.com".
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PublicURL prefixes keys in returned URLs, e.g. a CDN in front of the
+	// bucket. It defaults to the object URL.
+	PublicURL string
+	// PathStyle addresses the bucket as Endpoint/Bucket instead of the
+	// Bucket.host virtual host, as MinIO and most stand-ins expect.
+	PathStyle bool
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+
+	now func() time.Time
+}
+
+func (s *S3Storage) Put(ctx context.Context, key, contentType string, data []byte) (string, error) {
+	if !validKey(key) {
+		return "", fmt.Errorf("storage: invalid key %q", key)
+	}
+	req, err := s.request(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	if _, err := s.do(req, data, http.StatusOK); err != nil {
+		return "", err
+	}
+	return s.URL(key), nil
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+	if !validKey(key) {
+		return nil, ErrNotFound
+	}
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.do(req, nil, http.StatusOK, http.StatusNotFound)
+	if err == errS3NotFound {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Exists asks for the object with a HEAD request.
+func (s *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
+	if !validKey(key) {
+		return false, nil
+	}
+	req, err := s.request(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return false, err
+	}
+	_, err = s.do(req, nil, http.StatusOK, http.StatusNotFound)
+	if err == errS3NotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *S3Storage) URL(key string) string {
+	if s.PublicURL != "" {
+		return strings.TrimSuffix(s.PublicURL, "/") + "/" + key
+	}
+	req, err := s.request(context.Background(), http.MethodGet, key, nil)
+	if err != nil {
+		return ""
+	}
+	return req.URL.String()
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return fmt.Errorf("storage: invalid key %q", key)
+	}
+	req, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	// S3 answers 204 whether or not the object existed.
+	_, err = s.do(req, nil, http.StatusNoContent, http.StatusNotFound)
+	if err == errS3NotFound {
+		return nil
+	}
+	return err
+}
+
+// request builds an unsigned request for the object under key.
+func (s *S3Storage) request(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(s.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("storage: invalid S3 endpoint: %w", err)
+	}
+	u := *endpoint
+	if s.PathStyle {
+		u.Path += "/" + s.Bucket + "/" + key
+	} else {
+		u.Host = s.Bucket + "." + u.Host
+		u.Path += "/" + key
+	}
+	u.RawPath = escapePath(u.Path)
+	return http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+}
+
+// errS3NotFound is returned by do for 404 responses it was told to accept.
+var errS3NotFound = errors.New("storage: S3 object not found")
+
+// do signs and sends req, expecting one of the ok statuses, and returns the
+// response body.
+func (s *S3Storage) do(req *http.Request, body []byte, ok ...int) ([]byte, error) {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	s.sign(req, body, now().UTC())
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	for _, code := range ok {
+		if resp.StatusCode != code {
+			continue
+		}
+		if code == http.StatusNotFound {
+			return nil, errS3NotFound
+		}
+		return io.ReadAll(resp.Body)
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return nil, fmt.Errorf("storage: S3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, bytes.TrimSpace(msg))
+}
+
+// sign adds the x-amz-date, x-amz-content-sha256 and Authorization headers
+// of AWS Signature Version 4, signing every header already set.
+func (s *S3Storage) sign(req *http.Request, body []byte, t time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := t.Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), q[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath encodes each path segment the way signatures expect.
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		segments[i] = awsEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// awsEscape percent-encodes everything but the unreserved characters.
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
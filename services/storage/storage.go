@@ -0,0 +1,100 @@
+// Package storage keeps uploaded media files and hands out their public URLs.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ErrNotFound is returned for keys that hold no file.
+var ErrNotFound = errors.New("storage: file not found")
+
+// Storage stores files under slash-separated keys such as
+// "2026/10/0b8e....jpg".
+type Storage interface {
+	// Put stores data under key, replacing any previous file, and returns
+	// the URL the file is served from.
+	Put(ctx context.Context, key, contentType string, data []byte) (string, error)
+	// Get returns the file under key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Exists reports whether a file is stored under key, without reading
+	// it.
+	Exists(ctx context.Context, key string) (bool, error)
+	// URL is the URL the file under key is served from. URL("") is the
+	// prefix of all file URLs.
+	URL(key string) string
+	// Delete removes the file under key. Deleting a missing file is not an
+	// error.
+	Delete(ctx context.Context, key string) error
+}
+
+// NewFromEnv builds the storage selected by MEDIA_STORAGE: "local" (the
+// default) or "s3". Stored URLs are absolute so that they work outside the
+// API, e.g. in marketplace feeds: local files are served from MEDIA_URL,
+// which defaults to API_URL + "/media". Without either, as in development,
+// they are served from localhost on PORT.
+func NewFromEnv() (Storage, error) {
+	switch backend := os.Getenv("MEDIA_STORAGE"); backend {
+	case "", "local":
+		baseURL := os.Getenv("MEDIA_URL")
+		if apiURL := os.Getenv("API_URL"); baseURL == "" && apiURL != "" {
+			baseURL = strings.TrimSuffix(apiURL, "/") + "/media"
+		}
+		if !absoluteURL(baseURL) {
+			fallback := "http://localhost:" + envOr("PORT", "8080") + "/media"
+			log.Printf("Storage: MEDIA_URL, or API_URL, is not an absolute http(s) URL (%q), serving media from %s", baseURL, fallback)
+			baseURL = fallback
+		}
+		return NewLocalStorage(envOr("MEDIA_DIR", "uploads"), baseURL), nil
+	case "s3":
+		s := &S3Storage{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    envOr("S3_REGION", "us-east-1"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+		}
+		if s.Endpoint == "" || s.Bucket == "" || s.AccessKey == "" || s.SecretKey == "" {
+			return nil, fmt.Errorf("storage: S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required")
+		}
+		if s.PublicURL != "" && !absoluteURL(s.PublicURL) {
+			return nil, fmt.Errorf("storage: S3_PUBLIC_URL must be an absolute http(s) URL, got %q", s.PublicURL)
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("storage: unknown MEDIA_STORAGE %q", backend)
+	}
+}
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// absoluteURL reports whether u is an absolute http or https URL.
+func absoluteURL(u string) bool {
+	parsed, err := url.Parse(u)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// validKey rejects keys that could escape the storage root.
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("MEDIA_DIR", t.TempDir())
+
+	// Local files are served by the API unless MEDIA_URL says otherwise
+	t.Setenv("API_URL", "https://api.noble.kz/")
+	s, err := NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.noble.kz/media/a.jpg", s.URL("a.jpg"))
+
+	t.Setenv("MEDIA_URL", "https://cdn.noble.kz/media")
+	s, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.noble.kz/media/a.jpg", s.URL("a.jpg"))
+
+	// Relative URLs would not work in feeds and emails, so without an
+	// absolute one files are served from localhost
+	t.Setenv("MEDIA_URL", "/media")
+	t.Setenv("PORT", "3000")
+	s, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:3000/media/a.jpg", s.URL("a.jpg"))
+	t.Setenv("MEDIA_URL", "")
+	t.Setenv("API_URL", "")
+	t.Setenv("PORT", "")
+	s, err = NewFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/media/a.jpg", s.URL("a.jpg"))
+
+	t.Setenv("MEDIA_STORAGE", "s3")
+	t.Setenv("S3_ENDPOINT", "https://s3.example.com")
+	t.Setenv("S3_BUCKET", "media")
+	t.Setenv("S3_ACCESS_KEY_ID", "key")
+	t.Setenv("S3_SECRET_ACCESS_KEY", "secret")
+	_, err = NewFromEnv()
+	require.NoError(t, err)
+	t.Setenv("S3_PUBLIC_URL", "/media")
+	_, err = NewFromEnv()
+	assert.Error(t, err)
+}
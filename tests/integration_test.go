@@ -226,6 +226,7 @@ func TestManufacturersCRUD(t *testing.T) {
 	w = httptest.NewRecorder()
 	crud.ManufacturerItemHandler(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
 	var getM models.Manufacturer
 	json.NewDecoder(w.Body).Decode(&getM)
 	assert.Equal(t, createdM.ID, getM.ID)
@@ -235,15 +236,26 @@ func TestManufacturersCRUD(t *testing.T) {
 	createdM.Name = newName
 	updateJSON, _ := json.Marshal(createdM)
 	req = httptest.NewRequest(http.MethodPut, "/products/manufacturers/"+createdM.ID, bytes.NewBuffer(updateJSON))
+	req.Header.Set("If-Match", etag)
 	w = httptest.NewRecorder()
 	crud.ManufacturerItemHandler(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
 	var updatedM models.Manufacturer
 	json.NewDecoder(w.Body).Decode(&updatedM)
 	assert.Equal(t, newName, updatedM.Name)
+	staleETag, etag := etag, w.Header().Get("ETag")
+	assert.NotEqual(t, staleETag, etag)
+
+	// A write based on the old version is rejected
+	req = httptest.NewRequest(http.MethodDelete, "/products/manufacturers/"+createdM.ID, nil)
+	req.Header.Set("If-Match", staleETag)
+	w = httptest.NewRecorder()
+	crud.ManufacturerItemHandler(w, req)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
 
 	// 5. Delete
 	req = httptest.NewRequest(http.MethodDelete, "/products/manufacturers/"+createdM.ID, nil)
+	req.Header.Set("If-Match", etag)
 	w = httptest.NewRecorder()
 	crud.ManufacturerItemHandler(w, req)
 	assert.Equal(t, http.StatusNoContent, w.Code)
@@ -284,18 +296,22 @@ func TestCategoriesCRUD(t *testing.T) {
 	w = httptest.NewRecorder()
 	crud.CategoryItemHandler(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
 
 	// 4. Update
 	newName := cName + " Updated"
 	createdC.Name = newName
 	updateJSON, _ := json.Marshal(createdC)
 	req = httptest.NewRequest(http.MethodPut, "/products/categories/"+createdC.ID, bytes.NewBuffer(updateJSON))
+	req.Header.Set("If-Match", etag)
 	w = httptest.NewRecorder()
 	crud.CategoryItemHandler(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
+	etag = w.Header().Get("ETag")
 
 	// 5. Delete
 	req = httptest.NewRequest(http.MethodDelete, "/products/categories/"+createdC.ID, nil)
+	req.Header.Set("If-Match", etag)
 	w = httptest.NewRecorder()
 	crud.CategoryItemHandler(w, req)
 	assert.Equal(t, http.StatusNoContent, w.Code)
@@ -344,18 +360,22 @@ func TestProductsCRUD(t *testing.T) {
 	w = httptest.NewRecorder()
 	crud.ProductItemHandler(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
 
 	// 4. Update
 	newName := pName + " Updated"
 	createdP.Name = newName
 	updateJSON, _ := json.Marshal(createdP)
 	req = httptest.NewRequest(http.MethodPut, "/products/"+createdP.ID, bytes.NewBuffer(updateJSON))
+	req.Header.Set("If-Match", etag)
 	w = httptest.NewRecorder()
 	crud.ProductItemHandler(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
+	etag = w.Header().Get("ETag")
 
 	// 5. Delete
 	req = httptest.NewRequest(http.MethodDelete, "/products/"+createdP.ID, nil)
+	req.Header.Set("If-Match", etag)
 	w = httptest.NewRecorder()
 	crud.ProductItemHandler(w, req)
 	assert.Equal(t, http.StatusNoContent, w.Code)